@@ -26,15 +26,18 @@ package alog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -76,6 +79,56 @@ type LogEntry struct {
 	Servicename string
 	GoroutineID *uint64
 	MapData     map[string]interface{}
+	Component   string
+	ScopeID     string
+	ScopeSeq    uint64
+	RequestID   string
+	Subsystem   string
+	Labels      map[string]string
+	TraceID     string
+	SpanID      string
+	Sequence    uint64
+
+	// Keys of map data entries that are already represented in the formatted
+	// message so that the StdLogFormatter does not render them a second time.
+	// The structured helpers (LogValue, LogRetry, LogFlag, ...) set this for
+	// all of their fields since their message describes them in full, so with
+	// the StdLogFormatter only the message is rendered while the JSON formatter
+	// still writes each field.
+	formatKeys []string
+
+	// The logger that emitted the entry, whose configuration the built-in
+	// formatters use
+	logger *alogger
+
+	// Time since the previous line on the same writer for delta timestamps
+	delta time.Duration
+
+	// Whether the entry bypasses dedup, e.g. for dedup's own summaries
+	noDedup bool
+
+	// Whether the message redactors already ran on the entry in the hook
+	// pipeline (see RedactionHook)
+	redacted bool
+}
+
+// Get the configuration to format the entry with. Entries that were not
+// emitted by a logger (e.g. parsed from a line) use the package logger.
+func (e LogEntry) config() *alogger {
+	if nil != e.logger {
+		return e.logger
+	}
+	return std
+}
+
+// Determine whether a map data key is already represented in the message
+func (e LogEntry) inFormat(key string) bool {
+	for _, k := range e.formatKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
 }
 
 //-- Public Interfaces ---------------------------------------------------------
@@ -85,12 +138,44 @@ type LogFormatter interface {
 	FormatEntry(LogEntry) []string
 }
 
+// BufferLogFormatter - Optional interface for a LogFormatter that can append
+// the formatted lines for an entry directly to a buffer. When the configured
+// formatter implements it, this is used in place of FormatEntry to avoid
+// allocating the intermediate strings. Every line must end with a newline and
+// each line is passed to the writer with a separate Write call.
+type BufferLogFormatter interface {
+	LogFormatter
+	FormatEntryTo(buf *bytes.Buffer, e LogEntry)
+}
+
+// EntryWriter - Optional interface for an io.Writer that needs the entry each
+// line belongs to, for example to route lines by channel. When the configured
+// writer implements it, WriteEntry is called in place of Write for every
+// formatted line.
+type EntryWriter interface {
+	io.Writer
+	WriteEntry(e *LogEntry, p []byte) (int, error)
+}
+
+// FormatTagger - Optional interface for formatters and writers to declare an
+// output format tag (e.g. "json"). For a formatter, the tag names the format
+// it produces. For a writer, it names the format the sink requires. The tags
+// are compared by ValidateOutput.
+type FormatTagger interface {
+	FormatTag() string
+}
+
 // ScopedLogger - Interface for a scoped logger that logs Start/End blocks
 type ScopedLogger interface {
 	Close()
 }
 
-// ChannelLog - Interface for logger that always logs to a specific channel
+// ChannelLog - Interface for logger that always logs to a specific channel.
+//
+// NOTE: This interface is kept at its original method set so that existing
+//  implementations outside of this package continue to satisfy it. Methods
+//  added since are part of ChannelLogger, which is what UseChannel returns.
+////
 type ChannelLog interface {
 	Log(level LogLevel, format string, v ...interface{})
 	Printf(level LogLevel, format string, v ...interface{})
@@ -103,8 +188,58 @@ type ChannelLog interface {
 	DetailFnLog(level LogLevel, format string, v ...interface{}) ScopedLogger
 }
 
+// ChannelLogger - Extension of ChannelLog with the full set of channel logging
+// methods. This is the type returned by UseChannel, NopChannelLog and the
+// With* and Ctx methods. Code that accepts a ChannelLog can check for the
+// extension with a type assertion.
+//
+// NOTE: The level shorthands (Infof, Debugf, ...) are part of the interface so
+//  that they can be called on the value returned by UseChannel. Each behaves
+//  exactly like Log with the corresponding level. New methods may be added to
+//  this interface in minor releases, so implementations outside of this
+//  package should embed a ChannelLogger (e.g. from NopChannelLog) rather than
+//  implement it from scratch.
+////
+type ChannelLogger interface {
+	ChannelLog
+	LogWithMap(level LogLevel, mapData map[string]interface{}, format string, v ...interface{})
+	LogAt(ts time.Time, level LogLevel, format string, v ...interface{})
+	LogMapAt(ts time.Time, level LogLevel, mapData map[string]interface{})
+	LogWithMapAt(ts time.Time, level LogLevel, mapData map[string]interface{}, format string, v ...interface{})
+	LogValue(level LogLevel, name string, v interface{})
+	LogFunc(level LogLevel, fn func() string)
+	LogMapFunc(level LogLevel, fn func() map[string]interface{})
+	LogRetry(level LogLevel, attempt, maxAttempts int, lastErr error, nextDelay time.Duration)
+	LogFlag(level LogLevel, flagName string, value interface{}, reason string)
+	LogHealth(level LogLevel, component string, healthy bool, detail string)
+	LogMismatch(level LogLevel, field string, expected, actual interface{})
+	RequestScope(level LogLevel, requestID string, format string, v ...interface{}) ScopedLogger
+	FnLogR(result *error, format string, v ...interface{}) ScopedLogger
+	TraceFn(fn func() error) error
+	WithComponent(name string) ChannelLogger
+	WithFields(fields map[string]interface{}) ChannelLogger
+	Ctx(ctx context.Context) ChannelLogger
+
+	// Level shorthands
+	Errorf(format string, v ...interface{})
+	Warningf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Tracef(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+	Debug1f(format string, v ...interface{})
+	Debug2f(format string, v ...interface{})
+	Debug3f(format string, v ...interface{})
+	Debug4f(format string, v ...interface{})
+}
+
 //-- Core Implementation -------------------------------------------------------
 
+// A formatter paired with the writer its output goes to
+type output struct {
+	formatter LogFormatter
+	writer    io.Writer
+}
+
 // Core log config struct
 type alogger struct {
 
@@ -114,24 +249,62 @@ type alogger struct {
 	// The output writer
 	writer io.Writer
 
+	// Optional map from level to the writer used for it in place of writer
+	levelWriters map[LogLevel]io.Writer
+
+	// Optional formatter/writer pairs that each entry is written to in place
+	// of the formatter and writer
+	outputs []output
+
+	// The last error returned by each writer that is currently failing
+	sinkErrors sinkErrors
+
 	// Map from channel to level for specific channel configuration
 	channelMap ChannelMap
 
+	// Set of channels in channelMap that enable only their exact level
+	exactChannels map[LogChannel]bool
+
+	// Set of channels that are never logged, regardless of level
+	mutedChannels map[LogChannel]bool
+
+	// When non-nil, the only channels that are logged
+	allowedChannels map[LogChannel]bool
+
+	// Map from channel to the subsystem it belongs to
+	channelGroups map[LogChannel]string
+
 	// Default level to use for channels that aren't specifically configured
 	defaultLevel LogLevel
 
+	// Offset added to the configured level of every channel
+	levelOffset int
+
 	// Length of the channel section of the header
 	channelHeaderLen int
 
 	// Optional service name string
 	serviceName string
 
+	// Optional static labels logged with every entry
+	labels map[string]string
+
 	// String to use for each individual indent
 	indent string
 
 	// Current indentation level per GID
 	indentMap map[uint64]int
 
+	// Maximum number of indents rendered in the std header (0 for no limit)
+	maxIndent int
+
+	// Maximum indentation depth at which entries are logged (0 for no limit)
+	maxIndentDepth int
+
+	// Length above which slices in map data are rendered one element per line
+	// by the std formatter (0 to disable)
+	expandSlices int
+
 	// Bool to enable/disable indentation
 	enableIndent bool
 
@@ -141,8 +314,66 @@ type alogger struct {
 	// Bool to enable/disable displaying the full function signature for FnLog
 	fullFuncSig bool
 
+	// Bool to enable/disable tagging entries with the enclosing scope
+	enableScopeCorrelation bool
+
+	// Bool to enable/disable tagging entries with a global sequence number
+	enableSequence bool
+
+	// Bool to enable/disable logging the elapsed time on the End line of scopes
+	enableScopeTiming bool
+
+	// Bool to enable/disable capturing the chain of wrapped errors passed as
+	// format arguments
+	captureErrorChain bool
+
+	// Bool to enable/disable escalating unhealthy LogHealth results to WARNING
+	escalateHealth bool
+
+	// Optional cache used to coalesce repeated messages
+	dedup *dedupCache
+
+	// Stack of open correlated scopes per GID
+	scopeMap map[uint64][]*scopeState
+
 	// The configured log formatter
 	formatter LogFormatter
+
+	// Map from channel to formatter for channels that override the global one
+	channelFormatters map[LogChannel]LogFormatter
+
+	// Pending reverts of ConfigChannelFor calls
+	reverts channelReverts
+
+	// Optional transform applied to each formatted line before writing
+	outputTransform func([]byte) []byte
+
+	// Optional handler called when the writer returns an error
+	writeErrorHandler func(error, LogEntry)
+
+	// Exit code used by Fatalf
+	fatalExitCode int
+
+	// Optional function used by the std formatter to render map data values
+	mapValueRenderer func(interface{}) string
+
+	// Optional function used to extract trace and span ids from a context
+	traceExtractor func(context.Context) (string, string, bool)
+
+	// Optional function used to generate request ids
+	idGenerator func() string
+
+	// Optional buffers of suppressed entries replayed ahead of an error
+	errorContext *contextBuffer
+
+	// Optional function used to timestamp entries in place of time.Now
+	clock func() time.Time
+
+	// Location that timestamps are captured in
+	location *time.Location
+
+	// Optional tracker of the previous line per writer for delta timestamps
+	deltas *deltaTracker
 }
 
 // This function converts a level to a 4-character header string that is used
@@ -174,7 +405,21 @@ func levelToHeaderString(level LogLevel) string {
 	}
 }
 
+// The function set with SetGIDFunc. It is read without locking since the
+// goroutine id is needed both inside and outside of the logger's lock.
+var gidFunc atomic.Value
+
+// Get the id of the current goroutine from SetGIDFunc, or from the stack if it
+// is not set
 func getGID() uint64 {
+	if f, _ := gidFunc.Load().(func() uint64); nil != f {
+		return f()
+	}
+	return stackGID()
+}
+
+// Parse the id of the current goroutine from its stack trace
+func stackGID() uint64 {
 	b := make([]byte, 64)
 	b = b[:runtime.Stack(b, false)]
 	b = bytes.TrimPrefix(b, []byte("goroutine "))
@@ -183,61 +428,582 @@ func getGID() uint64 {
 	return n
 }
 
+// Determine whether a channel is muted or excluded by the allowlist, so that
+// nothing on it is ever written regardless of level
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) isSilenced(channel LogChannel) bool {
+	if cfg.mutedChannels[channel] {
+		return true
+	}
+	return nil != cfg.allowedChannels && !cfg.allowedChannels[channel]
+}
+
 // The primary "enabled" check to preempt work when not needed
 //
 // NOTE: This does not provide a lock since it is an implementation only
 //  function. Any use of it must be inside a read lock
 ////
 func (cfg *alogger) isEnabled(channel LogChannel, level LogLevel) bool {
+	if cfg.isSilenced(channel) {
+		return false
+	}
 	chanLvl := cfg.defaultLevel
 	if cLvl, ok := cfg.channelMap[channel]; ok {
 		chanLvl = cLvl
 	}
+	if cfg.levelOffset != 0 && chanLvl != OFF {
+		chanLvl = offsetLevel(chanLvl, cfg.levelOffset)
+	}
+	if len(cfg.exactChannels) > 0 && cfg.exactChannels[channel] {
+		return level > OFF && chanLvl == level
+	}
 	return level > OFF && chanLvl >= level
 }
 
-// Implementation of the scoped logger that can't be created directly
+// Apply a level offset, clamping to the range [FATAL, DEBUG4]
+func offsetLevel(level LogLevel, delta int) LogLevel {
+	lvl := int(level) + delta
+	if lvl < int(FATAL) {
+		return FATAL
+	} else if lvl > int(DEBUG4) {
+		return DEBUG4
+	}
+	return LogLevel(lvl)
+}
+
+// Implementation of the scoped logger that can't be created directly. The
+// entry holds everything needed to log both the Start and End lines.
 type scopedLoggerImpl struct {
-	channel LogChannel
-	level   LogLevel
-	format  string
-	v       []interface{}
+	cfg       *alogger
+	entry     LogEntry
+	scope     *scopeState
+	startTime time.Time
+	result    *error
+	closed    uint32
+}
+
+// State of a single open scope used for scope correlation and request ids
+type scopeState struct {
+	id        string
+	seq       uint64
+	requestID string
+}
+
+// Counter used to generate unique scope ids
+var scopeCounter uint64
+
+func (cfg *alogger) logScope(e LogEntry) ScopedLogger {
+	testHelper()()
+	scope := cfg.pushScope(e.RequestID)
+	start := e
+	start.Format = "Start: " + e.Format
+	cfg.log(start)
+	cfg.incrementIndent()
+	impl := &scopedLoggerImpl{cfg: cfg, entry: e, scope: scope}
+	cfg.mutex.RLock()
+	if cfg.enableScopeTiming {
+		impl.startTime = time.Now()
+	}
+	cfg.mutex.RUnlock()
+	return impl
+}
+
+// Open a new scope on the current goroutine if scope correlation is enabled or
+// the scope carries a request id. Returns nil if neither is the case. Nested
+// scopes inherit the request id of the enclosing scope.
+func (cfg *alogger) pushScope(requestID string) *scopeState {
+	cfg.mutex.Lock()
+	defer cfg.mutex.Unlock()
+	if !cfg.enableScopeCorrelation && len(requestID) == 0 {
+		return nil
+	}
+	gid := getGID()
+	stack := cfg.scopeMap[gid]
+	if len(requestID) == 0 && len(stack) > 0 {
+		requestID = stack[len(stack)-1].requestID
+	}
+	scope := &scopeState{
+		id:        strconv.FormatUint(atomic.AddUint64(&scopeCounter, 1), 16),
+		requestID: requestID,
+	}
+	cfg.scopeMap[gid] = append(stack, scope)
+	return scope
 }
 
-func (cfg *alogger) fnLogImpl(depth int, channel LogChannel, level LogLevel, format string, v ...interface{}) ScopedLogger {
+// Remove a correlated scope from the current goroutine's stack
+func (cfg *alogger) popScope(scope *scopeState) {
+	if nil == scope {
+		return
+	}
+	cfg.mutex.Lock()
+	defer cfg.mutex.Unlock()
+	gid := getGID()
+	stack := cfg.scopeMap[gid]
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == scope {
+			stack = append(stack[:i], stack[i+1:]...)
+			break
+		}
+	}
+	if len(stack) == 0 {
+		delete(cfg.scopeMap, gid)
+	} else {
+		cfg.scopeMap[gid] = stack
+	}
+}
+
+// Tag an entry with the innermost open scope on the current goroutine
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) setScope(e *LogEntry) {
+	if len(cfg.scopeMap) == 0 {
+		return
+	}
+	if stack := cfg.scopeMap[getGID()]; len(stack) > 0 {
+		scope := stack[len(stack)-1]
+		if cfg.enableScopeCorrelation {
+			e.ScopeID = scope.id
+			e.ScopeSeq = atomic.AddUint64(&scope.seq, 1) - 1
+		}
+		if len(e.RequestID) == 0 {
+			e.RequestID = scope.requestID
+		}
+	}
+}
+
+func (cfg *alogger) fnLogImpl(depth int, e LogEntry) ScopedLogger {
+	testHelper()()
 	pc, _, _, _ := runtime.Caller(depth)
 	name := runtime.FuncForPC(pc).Name()
 	if !cfg.fullFuncSig {
 		parts := strings.Split(name, ".")
 		name = parts[len(parts)-1]
 	}
-	newFormat := fmt.Sprintf("%s(%s)", name, format)
-	return LogScope(channel, level, newFormat, v...)
+	e.Format = fmt.Sprintf("%s(%s)", name, e.Format)
+	return cfg.logScope(e)
 }
 
 func (cfg *alogger) getIndentCount() int {
 	nIndent := 0
-	if cfg.enableIndent {
+	if cfg.enableIndent && len(cfg.indentMap) > 0 {
 		gid := getGID()
-		if n, ok := std.indentMap[gid]; ok {
+		if n, ok := cfg.indentMap[gid]; ok {
 			nIndent = n
 		}
 	}
 	return nIndent
 }
 
+// Increase the indent level for the current goroutine
+func (cfg *alogger) incrementIndent() {
+	cfg.mutex.Lock()
+	if cfg.enableIndent {
+		gid := getGID()
+		nIndent := 0
+		if n, ok := cfg.indentMap[gid]; ok {
+			nIndent = n
+		}
+		nIndent++
+		cfg.indentMap[gid] = nIndent
+	}
+	cfg.mutex.Unlock()
+}
+
+// Decrease the indent level for the current goroutine
+func (cfg *alogger) decrementIndent() {
+	cfg.mutex.Lock()
+	if cfg.enableIndent && len(cfg.indentMap) > 0 {
+		gid := getGID()
+		if n, ok := cfg.indentMap[gid]; ok {
+			if n > 0 {
+				cfg.indentMap[gid] = n - 1
+			} else {
+				delete(cfg.indentMap, gid)
+			}
+		}
+	}
+	cfg.mutex.Unlock()
+}
+
 func (cfg *alogger) reset() {
 	cfg.channelMap = ChannelMap{}
+	cfg.exactChannels = map[LogChannel]bool{}
+	cfg.mutedChannels = map[LogChannel]bool{}
+	cfg.allowedChannels = nil
+	cfg.channelGroups = map[LogChannel]string{}
 	cfg.defaultLevel = OFF
 	cfg.channelHeaderLen = 5
 	cfg.indent = "  "
+	cfg.maxIndent = 0
+	cfg.maxIndentDepth = 0
+	cfg.expandSlices = 0
+	cfg.levelOffset = 0
 	cfg.indentMap = map[uint64]int{}
 	cfg.enableIndent = true
 	cfg.enableGID = false
 	cfg.fullFuncSig = false
+	cfg.enableScopeCorrelation = false
+	cfg.enableSequence = false
+	cfg.enableScopeTiming = false
+	cfg.captureErrorChain = false
+	cfg.escalateHealth = false
+	if nil != cfg.dedup {
+		cfg.dedup.close()
+		cfg.dedup = nil
+	}
+	cfg.scopeMap = map[uint64][]*scopeState{}
 	cfg.serviceName = ""
+	cfg.labels = nil
 	cfg.formatter = StdLogFormatter{}
+	cfg.channelFormatters = map[LogChannel]LogFormatter{}
 	cfg.writer = os.Stderr
+	cfg.levelWriters = nil
+	cfg.outputs = nil
+	cfg.sinkErrors.clear()
+	cfg.outputTransform = nil
+	cfg.writeErrorHandler = nil
+	cfg.fatalExitCode = 1
+	cfg.mapValueRenderer = nil
+	cfg.idGenerator = nil
+	cfg.traceExtractor = nil
+	cfg.errorContext = nil
+	cfg.clock = nil
+	cfg.location = time.UTC
+	cfg.deltas = nil
+}
+
+// Counter for entry sequence numbers. This is shared by all loggers and never
+// reset so that the numbers are unique for the life of the process.
+var entrySequence uint64
+
+// Tag an entry with the next sequence number if enabled
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) setSequence(e *LogEntry) {
+	if cfg.enableSequence {
+		e.Sequence = atomic.AddUint64(&entrySequence, 1)
+	}
+}
+
+// Fill in the fields of an entry that come from the logger configuration,
+// keeping a timestamp that was set explicitly
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) fillEntry(e *LogEntry) {
+	e.logger = cfg
+	if e.Timestamp.IsZero() {
+		e.Timestamp = cfg.now()
+	}
+	e.Servicename = cfg.serviceName
+	e.Labels = cfg.labels
+	e.Subsystem = cfg.channelGroups[e.Channel]
+}
+
+// Create an entry with the same fields as the log path, without writing it
+func (cfg *alogger) newEntry(channel LogChannel, level LogLevel, format string, v []interface{}) LogEntry {
+	e := LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	}
+	cfg.mutex.RLock()
+	e.NIndent = cfg.getIndentCount()
+	cfg.fillEntry(&e)
+	cfg.mutex.RUnlock()
+	return e
+}
+
+// Common implementation for all log functions that write an entry. The
+// runtime fields of the entry (indentation, timestamp and service name) are
+// filled in here if the channel and level are enabled, keeping a timestamp
+// that was set explicitly. Entries nested deeper than the maximum indent depth
+// are suppressed, and the registered hooks may drop the entry.
+func (cfg *alogger) log(e LogEntry) {
+	testHelper()()
+	var err error
+	var handler func(error, LogEntry)
+	cfg.mutex.RLock()
+	enabled := cfg.isEnabled(e.Channel, e.Level)
+	if enabled {
+		e.NIndent = cfg.getIndentCount()
+		enabled = cfg.maxIndentDepth <= 0 || e.NIndent <= cfg.maxIndentDepth
+	}
+	if enabled {
+		cfg.fillEntry(&e)
+		cfg.setScope(&e)
+		keep := runHooks(&e)
+		if keep {
+			var summary *LogEntry
+			if keep, summary = cfg.dedup.check(&e); nil != summary {
+				summary.NIndent = e.NIndent
+				cfg.fillEntry(summary)
+				cfg.setSequence(summary)
+				countEmitted(summary.Level)
+				cfg.writeEntry(*summary)
+			}
+		}
+		if keep {
+			if cfg.captureErrorChain {
+				addErrorChain(&e)
+			}
+			cfg.setSequence(&e)
+			ctxErr := cfg.replayContext(e)
+			countEmitted(e.Level)
+			if err = cfg.writeEntry(e); nil == err {
+				err = ctxErr
+			}
+			if nil != err {
+				handler = cfg.writeErrorHandler
+			}
+		}
+	} else {
+		countSuppressed(e.Channel)
+		cfg.bufferContext(e)
+	}
+	cfg.mutex.RUnlock()
+
+	// Call the handler outside of the lock so that it is free to log or
+	// reconfigure
+	if nil != handler {
+		handler(err, e)
+	}
+	cfg.reportReservedKeys()
+}
+
+// PanicError - The value Panicf panics with, so that a recover() handler can
+// tell which channel and level the panic came from. Message is the formatted
+// message. Formatted holds the lines the configured formatter produced for the
+// entry, or is empty if the level was disabled for the channel.
+type PanicError struct {
+	Channel   LogChannel
+	Level     LogLevel
+	Message   string
+	Formatted string
+}
+
+// Error - Get the formatted lines, or the message if the level was disabled
+func (e *PanicError) Error() string {
+	if len(e.Formatted) > 0 {
+		return e.Formatted
+	}
+	return e.Message
+}
+
+// Common implementation for the Panicf functions
+func (cfg *alogger) panicf(e LogEntry) {
+	resolveLazyValues(&e)
+	pe := &PanicError{
+		Channel: e.Channel,
+		Level:   e.Level,
+		Message: fmt.Sprintf(e.Format, e.Expansion...),
+	}
+	cfg.mutex.RLock()
+	if cfg.isEnabled(e.Channel, e.Level) {
+		e.NIndent = cfg.getIndentCount()
+		cfg.fillEntry(&e)
+		cfg.setScope(&e)
+		cfg.setSequence(&e)
+		countEmitted(e.Level)
+		pe.Formatted = strings.Join(cfg.formatterFor(e.Channel).FormatEntry(e), "\n")
+	} else {
+		countSuppressed(e.Channel)
+	}
+	cfg.mutex.RUnlock()
+	panic(pe)
+}
+
+// Function used to exit the process from Fatalf. This is a variable so that
+// tests can verify the exit without terminating. It is guarded by std.mutex.
+var exitFunc = os.Exit
+
+// Common implementation for the Fatalf functions
+func (cfg *alogger) fatalf(e LogEntry, code int) {
+	testHelper()()
+	cfg.log(e)
+	cfg.mutex.Lock()
+	cfg.flush()
+	cfg.mutex.Unlock()
+	std.mutex.RLock()
+	exit := exitFunc
+	std.mutex.RUnlock()
+	exit(code)
+}
+
+// Format an entry and write each resulting line to the writer, or to each of
+// the outputs if set. All lines are written even if one fails and the first
+// error is returned.
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) writeEntry(e LogEntry) error {
+	testHelper()()
+	resolveLazyValues(&e)
+	if nil != cfg.deltas {
+		e.delta = cfg.deltas.since(cfg.deltaWriter(e.Level), e.Timestamp)
+	}
+	if len(cfg.outputs) == 0 {
+		return cfg.writeFormatted(e, cfg.formatterFor(e.Channel), nil)
+	}
+	var outErr error
+	for _, o := range cfg.outputs {
+		if err := cfg.writeFormatted(e, o.formatter, o.writer); nil != err && nil == outErr {
+			outErr = err
+		}
+	}
+	return outErr
+}
+
+// Format an entry with the given formatter and write each resulting line. If
+// writer is nil, the configured writer for the entry's level is used.
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) writeFormatted(e LogEntry, formatter LogFormatter, writer io.Writer) error {
+	testHelper()()
+	var outErr error
+	if f, ok := formatter.(BufferLogFormatter); ok {
+		buf := getBuffer()
+		f.FormatEntryTo(buf, e)
+		b := buf.Bytes()
+		for len(b) > 0 {
+			n := bytes.IndexByte(b, '\n') + 1
+			if n == 0 {
+				n = len(b)
+			}
+			if err := cfg.writeLine(&e, b[:n], writer); nil != err && nil == outErr {
+				outErr = err
+			}
+			b = b[n:]
+		}
+		putBuffer(buf)
+		return outErr
+	}
+	for _, m := range formatter.FormatEntry(e) {
+		if err := cfg.writeLine(&e, []byte(m), writer); nil != err && nil == outErr {
+			outErr = err
+		}
+	}
+	return outErr
+}
+
+// Get the formatter to use for the given channel
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) formatterFor(channel LogChannel) LogFormatter {
+	if len(cfg.channelFormatters) > 0 {
+		if f, ok := cfg.channelFormatters[channel]; ok {
+			return f
+		}
+	}
+	return cfg.formatter
+}
+
+// Write a single formatted line, applying the output transform if set. If
+// writer is nil, the configured writer for the entry's level is used.
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) writeLine(e *LogEntry, b []byte, writer io.Writer) error {
+	testHelper()()
+	if nil != cfg.outputTransform {
+		b = cfg.outputTransform(b)
+	}
+	if nil == writer {
+		writer = cfg.writer
+		if nil != cfg.levelWriters {
+			if w, ok := cfg.levelWriters[e.Level]; ok {
+				writer = w
+			}
+		}
+	}
+	var err error
+	if ew, ok := writer.(EntryWriter); ok {
+		_, err = ew.WriteEntry(e, b)
+	} else {
+		_, err = writer.Write(b)
+	}
+	cfg.sinkErrors.record(writer, err)
+	return err
+}
+
+// Pool of buffers used to format entries
+var bufferPool = sync.Pool{
+	New: func() interface{} { return &bytes.Buffer{} },
+}
+
+// Buffers that have grown beyond this are not returned to the pool so that one
+// very large entry does not pin a large allocation
+const maxPooledBufferSize = 64 * 1024
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// Flush the writer if it supports it
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a lock
+////
+func (cfg *alogger) flush() error {
+	outErr := flushWriter(cfg.writer)
+	flushed := map[io.Writer]bool{cfg.writer: true}
+	others := []io.Writer{}
+	for _, w := range cfg.levelWriters {
+		others = append(others, w)
+	}
+	for _, o := range cfg.outputs {
+		others = append(others, o.writer)
+	}
+	for _, w := range others {
+		if !flushed[w] {
+			if err := flushWriter(w); nil != err && nil == outErr {
+				outErr = err
+			}
+			flushed[w] = true
+		}
+	}
+	return outErr
+}
+
+// Flush or sync an arbitrary writer if it supports either
+func flushWriter(writer io.Writer) error {
+	switch w := writer.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case *os.File:
+		// The standard streams are unbuffered and Sync fails on them when they
+		// are attached to a terminal or pipe
+		if w == os.Stdout || w == os.Stderr {
+			return nil
+		}
+		return w.Sync()
+	case interface{ Sync() error }:
+		return w.Sync()
+	}
+	return nil
 }
 
 func (cfg *alogger) formatTimestamp(ts time.Time) string {
@@ -254,69 +1020,284 @@ func new() *alogger {
 // The package-level log instance
 var std = new()
 
+// The function used to mark frames on the logging path as test helpers. This
+// is set when a TestingWriter is configured so that output is attributed to the
+// caller's test line. Each function on the path calls testHelper()() directly
+// since Helper only marks its immediate caller.
+var testHelperFunc atomic.Value
+
+func nopTestHelper() {}
+
+func testHelper() func() {
+	if h, ok := testHelperFunc.Load().(func()); ok {
+		return h
+	}
+	return nopTestHelper
+}
+
 //-- StdLogFormatter Implementation --------------------------------------------
 
 // StdLogFormatter - LogFormatter instance that wraps golang's log package
 type StdLogFormatter struct{}
 
-// Generate the header
-func (p StdLogFormatter) makeHeader(e LogEntry) string {
+// FormatTag - The StdLogFormatter produces the "std" format
+func (p StdLogFormatter) FormatTag() string {
+	return "std"
+}
+
+// Write the header to the buffer
+func (p StdLogFormatter) makeHeader(buf *bytes.Buffer, e LogEntry) {
+	cfg := e.config()
 
 	// Format the timestamp
-	tsStr := std.formatTimestamp(e.Timestamp)
+	if nil != cfg.deltas {
+		buf.WriteString(formatDelta(e.delta))
+	} else {
+		buf.WriteString(cfg.formatTimestamp(e.Timestamp))
+	}
 
 	// Format the serviceName if present
-	svcNmStr := ""
 	if len(e.Servicename) > 0 {
-		svcNmStr = fmt.Sprintf(" <%s>", e.Servicename)
+		buf.WriteString(" <")
+		buf.WriteString(e.Servicename)
+		buf.WriteByte('>')
+	}
+
+	// Format the labels if present, sorted by key
+	if len(e.Labels) > 0 {
+		buf.WriteString(" <")
+		for i, k := range sortedLabelKeys(e.Labels) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(e.Labels[k])
+		}
+		buf.WriteByte('>')
 	}
 
-	// Get the channel string
-	chStr := e.Channel
-	if len(e.Channel) > std.channelHeaderLen {
-		chStr = e.Channel[:std.channelHeaderLen]
-	} else if len(e.Channel) < std.channelHeaderLen {
-		formatString := fmt.Sprintf("%%-%ds", std.channelHeaderLen)
-		chStr = LogChannel(fmt.Sprintf(formatString, e.Channel))
+	// Get the channel string, truncated or padded to the header length
+	buf.WriteString(" [")
+	writeChannelHeader(buf, string(e.Channel), cfg.channelHeaderLen)
+	buf.WriteByte(':')
+	buf.WriteString(levelToHeaderString(e.Level))
+
+	// Add goroutine ID string. The lookup parses the runtime stack, so it is
+	// only done when the GID is actually displayed.
+	if cfg.enableGID {
+		buf.WriteByte(':')
+		buf.WriteString(strconv.FormatUint(getGID(), 10))
 	}
 
-	// Get goroutine ID string
-	gidString := ""
-	gid := getGID()
-	if std.enableGID {
-		gidString = fmt.Sprintf(":%d", gid)
+	// Add the sequence number if enabled
+	if e.Sequence > 0 {
+		buf.WriteString(" seq=")
+		buf.WriteString(strconv.FormatUint(e.Sequence, 10))
+	}
+
+	// Add the scope id if present so that the Start and End lines of a scope
+	// can be paired
+	if len(e.ScopeID) > 0 {
+		buf.WriteString(" scope=")
+		buf.WriteString(e.ScopeID)
 	}
 
-	// Get the indent string
-	indentStr := ""
-	for i := 0; i < e.NIndent; i++ {
-		indentStr = indentStr + std.indent
+	// Add the trace and span ids if present
+	if len(e.TraceID) > 0 {
+		buf.WriteString(" trace=")
+		buf.WriteString(e.TraceID)
+		buf.WriteString(" span=")
+		buf.WriteString(e.SpanID)
 	}
+	buf.WriteString("] ")
 
-	// Create the header
-	return fmt.Sprintf("%s%s [%s:%s%s] %s", tsStr, svcNmStr, chStr, levelToHeaderString(e.Level), gidString, indentStr)
+	// Add the indentation, capped at the configured maximum
+	nIndent := e.NIndent
+	if cfg.maxIndent > 0 && nIndent > cfg.maxIndent {
+		nIndent = cfg.maxIndent
+	}
+	if nIndent > 0 {
+		buf.Grow(nIndent * len(cfg.indent))
+		for i := 0; i < nIndent; i++ {
+			buf.WriteString(cfg.indent)
+		}
+	}
+
+	// Add the component after the indentation if present
+	if len(e.Component) > 0 {
+		buf.WriteByte('(')
+		buf.WriteString(e.Component)
+		buf.WriteString(") ")
+	}
+}
+
+// Write a channel name truncated or padded to n runes so that multi-byte
+// characters are never split and headers line up
+func writeChannelHeader(buf *bytes.Buffer, channel string, n int) {
+	count := 0
+	for i := range channel {
+		if count == n {
+			buf.WriteString(channel[:i])
+			return
+		}
+		count++
+	}
+	buf.WriteString(channel)
+	for ; count < n; count++ {
+		buf.WriteByte(' ')
+	}
 }
 
 // FormatEntry - Format an entry using go's log package
 func (p StdLogFormatter) FormatEntry(e LogEntry) []string {
-	header := p.makeHeader(e)
-	body := fmt.Sprintf(e.Format, e.Expansion...)
-	out := []string{}
-	if len(body) > 0 {
-		for _, line := range strings.Split(body, "\n") {
-			out = append(out, header+line+"\n")
+	buf := getBuffer()
+	p.FormatEntryTo(buf, e)
+	out := splitLines(buf.Bytes())
+	putBuffer(buf)
+	return out
+}
+
+// FormatEntryTo - Format an entry directly into a buffer
+func (p StdLogFormatter) FormatEntryTo(buf *bytes.Buffer, e LogEntry) {
+	cfg := e.config()
+
+	// Format the header once and copy it for each line
+	hdr := getBuffer()
+	p.makeHeader(hdr, e)
+	header := hdr.Bytes()
+
+	// Format the body in a separate buffer so it can be split into lines
+	body := getBuffer()
+	fmt.Fprintf(body, e.Format, e.Expansion...)
+	b := body.Bytes()
+	if !e.redacted {
+		b = redactMessageBytes(b)
+	}
+	if len(b) > 0 {
+		for {
+			n := bytes.IndexByte(b, '\n')
+			buf.Write(header)
+			if n < 0 {
+				buf.Write(b)
+				buf.WriteByte('\n')
+				break
+			}
+			buf.Write(b[:n+1])
+			b = b[n+1:]
 		}
 	}
+	putBuffer(body)
+
+	// Add one line per map entry in key order
 	if len(e.MapData) > 0 {
 		keys := []string{}
 		for k := range e.MapData {
-			keys = append(keys, k)
+			if !e.inFormat(k) {
+				keys = append(keys, k)
+			}
 		}
 		sort.Strings(keys)
 		for _, k := range keys {
-			out = append(out, header+fmt.Sprintf("%s: %v\n", k, e.MapData[k]))
+			if elems, ok := cfg.expandedSlice(e.MapData[k]); ok {
+				buf.Write(header)
+				fmt.Fprintf(buf, "%s:\n", k)
+				for i := 0; i < elems.Len(); i++ {
+					buf.Write(header)
+					buf.WriteString(cfg.indent)
+					buf.WriteString(cfg.renderMapValue(elems.Index(i).Interface()))
+					buf.WriteByte('\n')
+				}
+			} else {
+				buf.Write(header)
+				buf.WriteString(k)
+				buf.WriteString(": ")
+				buf.WriteString(cfg.renderMapValue(e.MapData[k]))
+				buf.WriteByte('\n')
+			}
 		}
 	}
+	putBuffer(hdr)
+}
+
+// Render a single map data value for the std formatter
+func (cfg *alogger) renderMapValue(v interface{}) string {
+	if nil != cfg.mapValueRenderer {
+		return cfg.mapValueRenderer(v)
+	}
+	return DefaultMapValueRenderer(v)
+}
+
+// DefaultMapValueRenderer - The default rendering of map data values used by
+// the StdLogFormatter. Values are rendered as with %v, except that nested maps
+// are always rendered with their keys sorted by their rendered form, byte
+// slices are rendered as strings, and both rules are applied recursively
+// inside maps and slices.
+func DefaultMapValueRenderer(v interface{}) string {
+	if nil == v {
+		return fmt.Sprintf("%v", v)
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		type kv struct {
+			k string
+			v string
+		}
+		pairs := make([]kv, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, kv{
+				k: DefaultMapValueRenderer(iter.Key().Interface()),
+				v: DefaultMapValueRenderer(iter.Value().Interface()),
+			})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].k < pairs[j].k })
+		parts := make([]string, len(pairs))
+		for i, p := range pairs {
+			parts[i] = p.k + ":" + p.v
+		}
+		return "map[" + strings.Join(parts, " ") + "]"
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, rv.Len())
+		for i := range parts {
+			parts[i] = DefaultMapValueRenderer(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Determine whether a map value should be rendered as one element per line
+// based on the ExpandSlices threshold. Byte slices are never expanded.
+func (cfg *alogger) expandedSlice(v interface{}) (reflect.Value, bool) {
+	if cfg.expandSlices <= 0 || nil == v {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() != reflect.Uint8 && rv.Len() > cfg.expandSlices {
+			return rv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Split a buffer of newline-terminated lines into strings
+func splitLines(b []byte) []string {
+	out := []string{}
+	for len(b) > 0 {
+		n := bytes.IndexByte(b, '\n') + 1
+		if n == 0 {
+			n = len(b)
+		}
+		out = append(out, string(b[:n]))
+		b = b[n:]
+	}
 	return out
 }
 
@@ -325,278 +1306,644 @@ func (p StdLogFormatter) FormatEntry(e LogEntry) []string {
 // JSONLogFormatter - LogFormatter intance that prints LogEntry objects as json
 type JSONLogFormatter struct{}
 
+// FormatTag - The JSONLogFormatter produces the "json" format
+func (p JSONLogFormatter) FormatTag() string {
+	return "json"
+}
+
 // FormatEntry - Implementation of the creation of the log string
 func (p JSONLogFormatter) FormatEntry(e LogEntry) []string {
+	buf := getBuffer()
+	p.FormatEntryTo(buf, e)
+	out := splitLines(buf.Bytes())
+	putBuffer(buf)
+	return out
+}
+
+// FormatEntryTo - Serialize an entry directly into a buffer
+func (p JSONLogFormatter) FormatEntryTo(buf *bytes.Buffer, e LogEntry) {
+	cfg := e.config()
 
 	// Set up the output json struct
 	outMap := map[string]interface{}{}
 
-	// Merge in map data
-	for k, v := range e.MapData {
-		outMap[k] = v
+	// Add map data, either nested under the field namespace or merged into the
+	// top level with keys that collide with a standard field renamed
+	if len(e.MapData) > 0 {
+		if ns := GetJSONFieldNamespace(); len(ns) > 0 {
+			outMap[ns] = e.MapData
+		} else {
+			for k, v := range e.MapData {
+				if reservedJSONKeys[k] {
+					noteReservedKey(k)
+					k = reservedKeyPrefix + k
+				}
+				outMap[k] = v
+			}
+		}
 	}
 
 	// Add standard fields
 	outMap["channel"] = string(e.Channel)
+	outMap["level"] = int(e.Level)
 	outMap["level_str"] = LevelToHumanString(e.Level)
-	outMap["message"] = fmt.Sprintf(e.Format, e.Expansion...)
-	outMap["timestamp"] = std.formatTimestamp(e.Timestamp)
+	outMap["message"] = e.message()
+	outMap["timestamp"] = formatJSONTimestamp(cfg.formatTimestamp(e.Timestamp), e.Timestamp)
 	outMap["num_indent"] = e.NIndent
 	outMap["service_name"] = e.Servicename
 
+	// Add labels if present
+	if len(e.Labels) > 0 {
+		outMap["labels"] = e.Labels
+	}
+
+	// Add component if present
+	if len(e.Component) > 0 {
+		outMap["component"] = e.Component
+	}
+
+	// Add the scope if present
+	if len(e.ScopeID) > 0 {
+		outMap["scope_id"] = e.ScopeID
+		outMap["scope_seq"] = e.ScopeSeq
+	}
+
+	// Add the request id if present
+	if len(e.RequestID) > 0 {
+		outMap["request_id"] = e.RequestID
+	}
+
+	// Add the sequence number if enabled
+	if e.Sequence > 0 {
+		outMap["sequence"] = e.Sequence
+	}
+
+	// Add the trace and span ids if present
+	if len(e.TraceID) > 0 {
+		outMap["trace_id"] = e.TraceID
+		outMap["span_id"] = e.SpanID
+	}
+
+	// Add the subsystem if the channel is grouped
+	if len(e.Subsystem) > 0 {
+		outMap["subsystem"] = e.Subsystem
+	}
+
 	// Add gid if enabled
-	if std.enableGID {
+	if cfg.enableGID {
 		outMap["thread_id"] = getGID()
 	}
 
-	// Serialize to json
-	out := []byte{}
-	if jBytes, err := json.Marshal(outMap); nil != err {
-		out = []byte(fmt.Sprintf("{\"error\": \"Failed to marshal json line [%v]\"}", err))
-	} else {
-		out = append(jBytes, '\n')
+	// Serialize to json. The encoder adds the trailing newline and writes
+	// nothing if encoding fails.
+	if err := json.NewEncoder(buf).Encode(outMap); nil != err {
+		fmt.Fprintf(buf, "{\"error\": \"Failed to marshal json line [%v]\"}", err)
 	}
-	return []string{string(out)}
 }
 
-//-- Public Config Methods -----------------------------------------------------
+//-- NullFormatter Implementation ----------------------------------------------
+
+// NullFormatter - LogFormatter instance that produces no output. Level
+// filtering is still applied as usual, so IsEnabled checks behave exactly as
+// they would with a real formatter.
+type NullFormatter struct{}
+
+// FormatEntry - Produce no lines for the entry
+func (p NullFormatter) FormatEntry(e LogEntry) []string {
+	return []string{}
+}
+
+// FormatEntryTo - Append nothing to the buffer
+func (p NullFormatter) FormatEntryTo(buf *bytes.Buffer, e LogEntry) {}
+
+//-- MultiFormatter Implementation ---------------------------------------------
+
+// MultiFormatter - LogFormatter instance that formats each entry with several
+// formatters and concatenates their lines in the order the formatters were
+// given. This is useful for validating a migration, e.g. writing both a std
+// line and a JSON line for every entry.
+type MultiFormatter struct {
+	Formatters []LogFormatter
+}
+
+// FormatEntry - Concatenate the lines of each formatter
+func (p MultiFormatter) FormatEntry(e LogEntry) []string {
+	out := []string{}
+	for _, f := range p.Formatters {
+		out = append(out, f.FormatEntry(e)...)
+	}
+	return out
+}
+
+//-- Public Config Methods -----------------------------------------------------
+
+// SetFormatter - Set the LogFormatter instance to use
+func SetFormatter(f LogFormatter) {
+	defaultLogger.SetFormatter(f)
+}
+
+// SetChannelFormatter - Set the LogFormatter instance to use for a single
+// channel, overriding the global formatter. Pass nil to remove the override so
+// that the channel uses the global formatter again.
+func SetChannelFormatter(channel LogChannel, f LogFormatter) {
+	defaultLogger.SetChannelFormatter(channel, f)
+}
+
+// SetChannelGroups - Assign channels to subsystems (e.g. HTTP and GRPC to
+// "api"). Entries on a grouped channel carry the name of its subsystem, which
+// the JSONLogFormatter adds as the subsystem field so that dashboards can
+// aggregate above the channel level. The map replaces any previous grouping,
+// and passing nil removes it.
+func SetChannelGroups(groups map[LogChannel]string) {
+	defaultLogger.SetChannelGroups(groups)
+}
+
+// ValidateOutput - Check that the configured formatters produce the formats
+// required by the configured writers. A mismatch is only reported when both
+// the writer and the formatter declare a format tag (see FormatTagger), so
+// untagged writers accept any format. All mismatches, including those for
+// channel formatters and the std stream split, are returned in one error.
+func ValidateOutput() error {
+	return defaultLogger.ValidateOutput()
+}
+
+// ResetDefaults - Reset to package default configuration. Pending
+// ConfigChannelFor reverts are cancelled.
+func ResetDefaults() {
+	defaultLogger.ResetDefaults()
+}
+
+// ResetAll - Reset to package default configuration and clear all other state
+// held by the package: active temporary dynamic configurations are discarded
+// (without reverting, since the configuration is reset anyway), the stats
+// counters are zeroed, the message redactors and hooks are removed, the JSON
+// field namespace is cleared, the periodic flush and config file watch are
+// stopped and the exit and goroutine id functions are restored. This is
+// intended for isolation between tests.
+func ResetAll() {
+	stdDynamicLogLock.clear()
+	ResetStats()
+	ClearMessageRedactors()
+	ClearHooks()
+	SetJSONFieldNamespace("")
+	clearReservedKeyWarnings()
+	StopPeriodicFlush()
+	StopWatchingConfigSignal()
+	SetExitFunc(nil)
+	SetGIDFunc(nil)
+	ResetDefaults()
+}
+
+// ConfigChannel - Set the level for a specific channel
+func ConfigChannel(channel LogChannel, level LogLevel) {
+	defaultLogger.ConfigChannel(channel, level)
+}
+
+// MergeConfig - Overlay the given channel levels onto the current channel map.
+// Unlike Config, the default level and channels not in the map are left as
+// they are. Each given channel is set as with ConfigChannel.
+func MergeConfig(channelMap ChannelMap) {
+	defaultLogger.MergeConfig(channelMap)
+}
+
+// ConfigChannelExact - Set a specific channel to enable only the given level,
+// rather than the level and everything more severe. For example, a channel set
+// to exactly WARNING logs neither ERROR nor INFO entries. Setting the channel
+// again with ConfigChannel restores the usual threshold.
+func ConfigChannelExact(channel LogChannel, level LogLevel) {
+	defaultLogger.ConfigChannelExact(channel, level)
+}
+
+// GetExactChannels - Get the set of channels configured to enable only their
+// exact level
+func GetExactChannels() map[LogChannel]bool {
+	return defaultLogger.GetExactChannels()
+}
 
-// SetFormatter - Set the LogFormatter instance to use
-func SetFormatter(f LogFormatter) {
-	std.mutex.Lock()
-	std.formatter = f
-	std.mutex.Unlock()
+// MuteChannel - Silence a channel entirely, regardless of its configured level.
+// A muted channel logs nothing, not even FATAL entries.
+func MuteChannel(channel LogChannel) {
+	defaultLogger.MuteChannel(channel)
 }
 
-// ResetDefaults - Reset to package default configuration
-func ResetDefaults() {
-	std.mutex.Lock()
-	std.reset()
-	std.mutex.Unlock()
+// UnmuteChannel - Restore a muted channel to its configured level
+func UnmuteChannel(channel LogChannel) {
+	defaultLogger.UnmuteChannel(channel)
 }
 
-// ConfigChannel - Set the level for a specific channel
-func ConfigChannel(channel LogChannel, level LogLevel) {
-	std.mutex.Lock()
-	if nil == std.channelMap {
-		std.channelMap = ChannelMap{}
-	}
-	std.channelMap[channel] = level
-	std.mutex.Unlock()
+// AllowOnlyChannels - Log only the given channels, dropping entries on every
+// other channel regardless of level. The listed channels still follow their
+// configured levels. Calling this with no channels turns the allowlist off.
+func AllowOnlyChannels(channels ...LogChannel) {
+	defaultLogger.AllowOnlyChannels(channels...)
 }
 
 // ConfigDefaultLevel - Set the level to use for channels not otherwise set
 func ConfigDefaultLevel(level LogLevel) {
-	std.mutex.Lock()
-	std.defaultLevel = level
-	std.mutex.Unlock()
+	defaultLogger.ConfigDefaultLevel(level)
 }
 
 // EnableIndent - Enable indentation tracking
 func EnableIndent() {
-	std.mutex.Lock()
-	std.enableIndent = true
-	std.mutex.Unlock()
+	defaultLogger.EnableIndent()
 }
 
 // DisableIndent - Disable indentation tracking
 func DisableIndent() {
-	std.mutex.Lock()
-	std.enableIndent = false
-	std.mutex.Unlock()
+	defaultLogger.DisableIndent()
 }
 
 // EnableGID - Enable logging the goroutine-id for each message
 func EnableGID() {
-	std.mutex.Lock()
-	std.enableGID = true
-	std.mutex.Unlock()
+	defaultLogger.EnableGID()
 }
 
 // DisableGID - Disable logging the goroutine-id for each message
 func DisableGID() {
-	std.mutex.Lock()
-	std.enableGID = false
-	std.mutex.Unlock()
+	defaultLogger.DisableGID()
+}
+
+// EnableScopeCorrelation - Enable tagging each entry logged inside a LogScope
+// or FnLog block with the id of the innermost scope on the same goroutine and
+// the entry's sequence number within that scope. These are added to JSON
+// output as scope_id and scope_seq, and the scope id is shown as scope= in the
+// std header.
+func EnableScopeCorrelation() {
+	defaultLogger.EnableScopeCorrelation()
+}
+
+// DisableScopeCorrelation - Disable tagging entries with their scope
+func DisableScopeCorrelation() {
+	defaultLogger.DisableScopeCorrelation()
+}
+
+// EnableScopeTiming - Enable logging how long each LogScope or FnLog block
+// took. The elapsed time is appended to the End line as "(1.23ms)" and added
+// to JSON output as duration_ms. Scopes opened before this is called are not
+// timed.
+func EnableScopeTiming() {
+	defaultLogger.EnableScopeTiming()
+}
+
+// DisableScopeTiming - Disable logging the elapsed time of scopes
+func DisableScopeTiming() {
+	defaultLogger.DisableScopeTiming()
+}
+
+// EnableSequence - Enable tagging every entry with a sequence number that
+// increases monotonically across all channels and goroutines, starting at 1.
+// This gives a strict emission order for entries whose timestamps tie. The
+// number is shown as seq= in the std header and added to JSON output as
+// sequence.
+func EnableSequence() {
+	defaultLogger.EnableSequence()
+}
+
+// DisableSequence - Disable tagging entries with a sequence number
+func DisableSequence() {
+	defaultLogger.DisableSequence()
 }
 
 // EnableFullFuncSig - Enable logging fully qualified function signatures
 func EnableFullFuncSig() {
-	std.mutex.Lock()
-	std.fullFuncSig = true
-	std.mutex.Unlock()
+	defaultLogger.EnableFullFuncSig()
 }
 
 // DisableFullFuncSig - Disable logging fully qualified funciton signatures
 func DisableFullFuncSig() {
-	std.mutex.Lock()
-	std.fullFuncSig = false
-	std.mutex.Unlock()
+	defaultLogger.DisableFullFuncSig()
 }
 
 // Config - Set the default level and channel filter map
 func Config(defaultLevel LogLevel, channelMap ChannelMap) {
-	std.mutex.Lock()
-	std.defaultLevel = defaultLevel
-	std.channelMap = channelMap
-	std.mutex.Unlock()
+	defaultLogger.Config(defaultLevel, channelMap)
 }
 
-// SetMaxChannelLen - Set the truncation length for channel headers
+// SetMaxChannelLen - Set the truncation length for channel headers in runes
 func SetMaxChannelLen(n int) {
-	std.mutex.Lock()
-	std.channelHeaderLen = n
-	std.mutex.Unlock()
+	defaultLogger.SetMaxChannelLen(n)
+}
+
+// SetIndentString - Set the string used for each level of indentation. It must
+// be non-empty and consist only of spaces and tabs. If it is not, the indent
+// string is left unchanged and an error is returned. ResetDefaults restores
+// the default of two spaces.
+//
+// NOTE: The indentation in plain text lines is converted to and from a number
+//  of indents using the current indent string. This means that converting
+//  JSON lines with JSONToPlainText, or parsing plain text lines with
+//  PlainTextToLogEntry, only reproduces the original indentation if the same
+//  indent string is configured as when the lines were logged.
+////
+func SetIndentString(indent string) error {
+	return defaultLogger.SetIndentString(indent)
+}
+
+// SetMaxIndent - Set the maximum number of indents rendered in the header of
+// each std formatted line. This guards against unbounded indentation when
+// scopes are not closed correctly. The indent count itself (and num_indent in
+// JSON output) is not capped. A value of 0 (the default) disables the limit.
+func SetMaxIndent(n int) {
+	defaultLogger.SetMaxIndent(n)
+}
+
+// SetMapValueRenderer - Set the function used by the StdLogFormatter to render
+// each map data value (e.g. to JSON-encode complex values). The function is
+// called while formatting and must not log. Pass nil to restore
+// DefaultMapValueRenderer. JSON output is unaffected.
+func SetMapValueRenderer(f func(interface{}) string) {
+	defaultLogger.SetMapValueRenderer(f)
+}
+
+// ExpandSlices - Render slices and arrays in map data that have more than
+// threshold elements as a block with one element per line under the key when
+// using the StdLogFormatter. JSON output is unchanged. A threshold of 0 (the
+// default) disables this.
+func ExpandSlices(threshold int) {
+	defaultLogger.ExpandSlices(threshold)
+}
+
+// MaxIndentDepth - Suppress all entries, including the Start/End lines of
+// LogScope and FnLog, that would be logged with more than n indents. Scopes
+// opened beyond the depth are still counted, so the indentation unwinds
+// correctly as they close. This keeps deeply recursive traces readable. A
+// depth of 0 (the default) disables this. The depth is only tracked while
+// indentation is enabled.
+func MaxIndentDepth(n int) {
+	defaultLogger.MaxIndentDepth(n)
+}
+
+// SetLevelOffset - Shift the effective level of every channel by delta. A
+// negative offset makes all channels less verbose (-1 turns INFO into WARNING)
+// and a positive offset makes them more verbose (+1 turns INFO into TRACE).
+// The shifted level is clamped to the range [FATAL, DEBUG4], so channels that
+// are not OFF always keep FATAL logging, and channels that are OFF stay off.
+// An offset of 0 (the default) disables this.
+func SetLevelOffset(delta int) {
+	defaultLogger.SetLevelOffset(delta)
 }
 
 // UseJSONLogFormatter - Set the formatter to print JSON output lines
 func UseJSONLogFormatter() {
-	std.mutex.Lock()
-	std.formatter = JSONLogFormatter{}
-	std.mutex.Unlock()
+	defaultLogger.UseJSONLogFormatter()
 }
 
 // UseStdLogFormatter - Set the formatter to use the default StdLogFormatter
 func UseStdLogFormatter() {
-	std.mutex.Lock()
-	std.formatter = StdLogFormatter{}
-	std.mutex.Unlock()
+	defaultLogger.UseStdLogFormatter()
+}
+
+// UseNullFormatter - Set the formatter to a NullFormatter so that nothing is
+// written while the level configuration stays in place
+func UseNullFormatter() {
+	defaultLogger.UseNullFormatter()
+}
+
+// UseMultiFormatter - Set the formatter to a MultiFormatter that writes the
+// lines of each of the given formatters in order
+func UseMultiFormatter(formatters ...LogFormatter) {
+	defaultLogger.UseMultiFormatter(formatters...)
 }
 
 // SetWriter - Set the io.Writer object to use
 func SetWriter(w io.Writer) {
+	defaultLogger.SetWriter(w)
+}
+
+// Route entries at WARNING and more severe to errWriter and all others to
+// outWriter
+func (cfg *alogger) enableStreamSplit(errWriter, outWriter io.Writer) {
+	cfg.mutex.Lock()
+	cfg.levelWriters = map[LogLevel]io.Writer{}
+	for lvl := FATAL; lvl <= DEBUG4; lvl++ {
+		if lvl <= WARNING {
+			cfg.levelWriters[lvl] = errWriter
+		} else {
+			cfg.levelWriters[lvl] = outWriter
+		}
+	}
+	cfg.mutex.Unlock()
+}
+
+// EnableStdStreamSplit - Write entries at WARNING and more severe (WARNING,
+// ERROR and FATAL) to os.Stderr and all others to os.Stdout, using the
+// configured formatter for both. While enabled, this takes precedence over the
+// writer set with SetWriter.
+func EnableStdStreamSplit() {
+	defaultLogger.EnableStdStreamSplit()
+}
+
+// DisableStdStreamSplit - Write all entries to the writer set with SetWriter
+// again
+func DisableStdStreamSplit() {
+	defaultLogger.DisableStdStreamSplit()
+}
+
+// EnableDualOutput - Write every entry to two writers at once: formatted with
+// the StdLogFormatter to stdWriter and with the JSONLogFormatter to jsonWriter.
+// This is intended for migration windows where an old and a new log pipeline
+// run side by side. While enabled, this takes precedence over the formatter
+// and writer set with SetFormatter and SetWriter, channel formatters and the
+// std stream split.
+func EnableDualOutput(stdWriter, jsonWriter io.Writer) {
+	defaultLogger.EnableDualOutput(stdWriter, jsonWriter)
+}
+
+// DisableDualOutput - Write entries with the configured formatter and writer
+// again
+func DisableDualOutput() {
+	defaultLogger.DisableDualOutput()
+}
+
+// Flush - Flush any output buffered by the configured writer. If the writer
+// implements Flush() error (e.g. bufio.Writer) or Sync() error (e.g. os.File),
+// it is invoked. For other writers this is a no-op that returns nil.
+//
+// NOTE: Fatalf calls Flush before exiting since os.Exit does not run deferred
+//  functions. Panicf does not flush, so programs using a buffered writer should
+//  defer a call to Flush in main to capture output from a panic.
+////
+func Flush() error {
+	return defaultLogger.Flush()
+}
+
+// SetOutputTransform - Set a function that is applied to the bytes of each
+// formatted line just before it is written. This can be used to add
+// transport-specific framing (e.g. length prefixes) without a custom
+// formatter. Pass nil to disable. The line passed in is only valid for the
+// duration of the call, so it must not be retained.
+func SetOutputTransform(f func([]byte) []byte) {
+	defaultLogger.SetOutputTransform(f)
+}
+
+// SetGIDFunc - Set the function used to get the id of the current goroutine.
+// The id is shown in the header when GID display is enabled, added to JSON
+// output as thread_id, and keys the per-goroutine indentation, scopes and error
+// context. The default parses it from the goroutine's stack, so a library that
+// provides goroutine-local ids can supply a cheaper one, and tests can inject
+// deterministic ids. Pass nil to restore the default. This applies to all
+// Loggers in the process.
+//
+// NOTE: Indentation and open scopes are tracked by id, so this should be set
+//  before any scopes are opened.
+////
+func SetGIDFunc(f func() uint64) {
+	gidFunc.Store(f)
+}
+
+// SetExitFunc - Set the function Fatalf calls to exit the process once the line
+// is written and the writer flushed. The default is os.Exit. This lets tests
+// check a fatal path, and the exit code it uses, without terminating. Pass nil
+// to restore os.Exit.
+//
+// NOTE: Code after a Fatalf call runs when the function returns, so a
+//  replacement that does not exit should only be used in tests.
+////
+func SetExitFunc(f func(code int)) {
+	if nil == f {
+		f = os.Exit
+	}
 	std.mutex.Lock()
-	std.writer = w
+	exitFunc = f
 	std.mutex.Unlock()
 }
 
+// SetFatalExitCode - Set the exit code used by Fatalf. The default is 1.
+func SetFatalExitCode(code int) {
+	defaultLogger.SetFatalExitCode(code)
+}
+
+// SetWriteErrorHandler - Set a function that is called when the writer returns
+// an error for an entry, e.g. to count failures or fall back to another output.
+// It is called at most once per entry with the first error, after the logger's
+// lock has been released, so it may log or change the configuration. Pass nil
+// (the default) to ignore write errors.
+func SetWriteErrorHandler(f func(err error, entry LogEntry)) {
+	defaultLogger.SetWriteErrorHandler(f)
+}
+
 // SetServiceName - Set a service name to be logged
 func SetServiceName(sn string) {
-	std.mutex.Lock()
-	std.serviceName = sn
-	std.mutex.Unlock()
+	defaultLogger.SetServiceName(sn)
+}
+
+// SetLabels - Set static labels (e.g. tenant or region) to be logged with every
+// entry, alongside the service name. The labels are copied. Pass nil to clear
+// them.
+func SetLabels(labels map[string]string) {
+	defaultLogger.SetLabels(labels)
 }
 
 //-- Public Log Methods --------------------------------------------------------
 
 // Log - Alias to Printf. This is the standard log function.
 func Log(channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
 	Printf(channel, level, format, v...)
 }
 
 // Printf - The standard Printf function. This wraps log.Printf
 func Printf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
-	std.mutex.RLock()
-	if std.isEnabled(channel, level) {
-		for _, m := range std.formatter.FormatEntry(LogEntry{
-			Channel:     channel,
-			Level:       level,
-			Format:      format,
-			Expansion:   v,
-			NIndent:     std.getIndentCount(),
-			Timestamp:   time.Now().UTC(),
-			Servicename: std.serviceName,
-		}) {
-			std.writer.Write([]byte(m))
-		}
-	}
-	std.mutex.RUnlock()
+	testHelper()()
+	defaultLogger.Printf(channel, level, format, v...)
 }
 
-// Fatalf - The standard Fatalf function. This wraps log.Fatalf
+// Fatalf - The standard Fatalf function. This wraps log.Fatalf. The writer is
+// flushed before exiting.
 func Fatalf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
-	Printf(channel, level, format, v...)
-	os.Exit(1)
+	testHelper()()
+	defaultLogger.Fatalf(channel, level, format, v...)
+}
+
+// FatalfWithCode - Fatalf that exits with the given code rather than the
+// configured fatal exit code
+func FatalfWithCode(code int, channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	defaultLogger.FatalfWithCode(code, channel, level, format, v...)
 }
 
-// Panicf - The standard Panicf function. This wraps log.Panicf
+// Panicf - The standard Panicf function. This wraps log.Panicf. It always
+// panics with a *PanicError, even if the level is disabled for the channel.
 func Panicf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
-	msg := ""
-	std.mutex.RLock()
-	if std.isEnabled(channel, level) {
-		msg = strings.Join(std.formatter.FormatEntry(LogEntry{
-			Channel:     channel,
-			Level:       level,
-			Format:      format,
-			Expansion:   v,
-			NIndent:     std.getIndentCount(),
-			Timestamp:   time.Now().UTC(),
-			Servicename: std.serviceName,
-		}), "\n")
-	}
-	std.mutex.RUnlock()
-	panic(msg)
+	defaultLogger.Panicf(channel, level, format, v...)
 }
 
 // LogMap - Log a structured map entry
 func LogMap(channel LogChannel, level LogLevel, mapData map[string]interface{}) {
-	std.mutex.RLock()
-	if std.isEnabled(channel, level) {
-		for _, m := range std.formatter.FormatEntry(LogEntry{
-			Channel:     channel,
-			Level:       level,
-			MapData:     mapData,
-			NIndent:     std.getIndentCount(),
-			Timestamp:   time.Now().UTC(),
-			Servicename: std.serviceName,
-		}) {
-			std.writer.Write([]byte(m))
-		}
-	}
-	std.mutex.RUnlock()
+	testHelper()()
+	defaultLogger.LogMap(channel, level, mapData)
 }
 
 // LogWithMap - Log a message with additional structured map data
 func LogWithMap(channel LogChannel, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
-	std.mutex.RLock()
-	if std.isEnabled(channel, level) {
-		for _, m := range std.formatter.FormatEntry(LogEntry{
-			Channel:     channel,
-			Level:       level,
-			Format:      format,
-			Expansion:   v,
-			MapData:     mapData,
-			NIndent:     std.getIndentCount(),
-			Timestamp:   time.Now().UTC(),
-			Servicename: std.serviceName,
-		}) {
-			std.writer.Write([]byte(m))
-		}
+	testHelper()()
+	defaultLogger.LogWithMap(channel, level, mapData, format, v...)
+}
+
+// LogValue - Log a single named value. The value is logged as structured map
+// data ({name: v}) and as a single "name = v" line with the StdLogFormatter.
+func LogValue(channel LogChannel, level LogLevel, name string, v interface{}) {
+	testHelper()()
+	defaultLogger.LogValue(channel, level, name, v)
+}
+
+// NewLogEntry - Create the entry that Printf would write, without writing it.
+// The indentation, service name and subsystem are read from the current
+// configuration and the timestamp is captured with time.Now().UTC() (or the
+// clock set with SetClock, in the location set with SetTimeLocation). This is
+// intended for testing custom LogFormatter implementations, e.g.
+// f.FormatEntry(alog.NewLogEntry(...)). Scope correlation fields are not set
+// since that would advance the sequence of the current scope.
+func NewLogEntry(channel LogChannel, level LogLevel, format string, v ...interface{}) LogEntry {
+	return defaultLogger.NewLogEntry(channel, level, format, v...)
+}
+
+// Create the entry for a LogValue call
+func valueEntry(channel LogChannel, level LogLevel, name string, v interface{}) LogEntry {
+	return LogEntry{
+		Channel:    channel,
+		Level:      level,
+		Format:     "%s = %v",
+		Expansion:  []interface{}{name, v},
+		MapData:    map[string]interface{}{name: v},
+		formatKeys: []string{name},
 	}
-	std.mutex.RUnlock()
 }
 
 //-- Convenience Methods -------------------------------------------------------
 
 // Indent - Increase the indent level
 func Indent() {
-	std.mutex.Lock()
-	if std.enableIndent {
-		gid := getGID()
-		nIndent := 0
-		if n, ok := std.indentMap[gid]; ok {
-			nIndent = n
-		}
-		nIndent++
-		std.indentMap[gid] = nIndent
-	}
-	std.mutex.Unlock()
+	defaultLogger.Indent()
 }
 
 // Deindent - Decrease the indent level
 func Deindent() {
-	std.mutex.Lock()
-	if std.enableIndent {
-		gid := getGID()
-		if n, ok := std.indentMap[gid]; ok {
-			if n > 0 {
-				std.indentMap[gid] = n - 1
-			} else {
-				delete(std.indentMap, gid)
-			}
-		}
-	}
-	std.mutex.Unlock()
+	defaultLogger.Deindent()
+}
+
+// Implementation of the ScopedLogger interface returned by IndentScope
+type indentScopeImpl struct {
+	cfg  *alogger
+	once sync.Once
+}
+
+// Close - Decrease the indent level. Only the first call has any effect.
+func (s *indentScopeImpl) Close() {
+	s.once.Do(s.cfg.decrementIndent)
+}
+
+// IndentScope - Increase the indent level and return a ScopedLogger whose
+// Close decreases it again exactly once. Unlike LogScope, no Start/End lines
+// are logged. This makes manual indentation safe across early returns:
+//
+//	defer alog.IndentScope().Close()
+//
+// NOTE: Like Indent/Deindent, Close must be called on the same goroutine that
+//  created the scope.
+////
+func IndentScope() ScopedLogger {
+	return defaultLogger.IndentScope()
 }
 
 // IsEnabled - Determine if a given channel/level combo is enabled
@@ -605,10 +1952,7 @@ func Deindent() {
 //  inside an if block using IsEnabled.
 ////
 func IsEnabled(channel LogChannel, level LogLevel) bool {
-	std.mutex.RLock()
-	out := std.isEnabled(channel, level)
-	std.mutex.RUnlock()
-	return out
+	return defaultLogger.IsEnabled(channel, level)
 }
 
 // Close - Closer for the scopedLoggerImpl type
@@ -634,92 +1978,181 @@ func IsEnabled(channel LogChannel, level LogLevel) bool {
 //   }
 //   ch.Log(alog.INFO, "Log after the local scope is closed")
 // }
+//
+// Calling Close more than once has no further effect.
 ////
 func (scope *scopedLoggerImpl) Close() {
-	Deindent()
-	Log(scope.channel, scope.level, "End: "+scope.format, scope.v...)
+	testHelper()()
+
+	// Only the first call closes the scope so that an explicit Close combined
+	// with a deferred one does not deindent twice
+	if !atomic.CompareAndSwapUint32(&scope.closed, 0, 1) {
+		return
+	}
+	scope.cfg.decrementIndent()
+	end := scope.entry
+	end.Format = "End: " + scope.entry.Format
+	if nil != scope.result {
+		addScopeResult(&end, *scope.result)
+	}
+	if !scope.startTime.IsZero() {
+		addScopeDuration(&end, time.Since(scope.startTime))
+	}
+	scope.cfg.log(end)
+	scope.cfg.popScope(scope.scope)
+}
+
+// Add the elapsed time of a scope to its End entry, as "(1.23ms)" after the
+// message and as duration_ms in the map data
+func addScopeDuration(end *LogEntry, elapsed time.Duration) {
+	ms := float64(elapsed) / float64(time.Millisecond)
+	end.Format += " (%.2fms)"
+	end.Expansion = append(append([]interface{}{}, end.Expansion...), ms)
+	mapData := make(map[string]interface{}, len(end.MapData)+1)
+	for k, v := range end.MapData {
+		mapData[k] = v
+	}
+	mapData["duration_ms"] = ms
+	end.MapData = mapData
+	end.formatKeys = append(append([]string{}, end.formatKeys...), "duration_ms")
 }
 
 // LogScope - Create a log scope object to log a Start/End block
 func LogScope(channel LogChannel, level LogLevel, format string, v ...interface{}) ScopedLogger {
-	Log(channel, level, "Start: "+format, v...)
-	Indent()
-	return &scopedLoggerImpl{
-		channel: channel,
-		level:   level,
-		format:  format,
-		v:       v,
-	}
+	testHelper()()
+	return defaultLogger.LogScope(channel, level, format, v...)
 }
 
 // FnLog - Create a log scope object with Start/End block containing the
 // function signature. This is always logged to the TRACE level.
+//
+// NOTE: This calls the default Logger's implementation directly rather than
+//  delegating to Logger.FnLog so that the caller depth used to find the
+//  function name is the same.
+////
 func FnLog(channel LogChannel, format string, v ...interface{}) ScopedLogger {
-	return std.fnLogImpl(2, channel, TRACE, format, v...)
+	testHelper()()
+	return std.fnLogImpl(2, LogEntry{
+		Channel:   channel,
+		Level:     TRACE,
+		Format:    format,
+		Expansion: v,
+	})
 }
 
 // DetailFnLog - Create a log scope object with Start/End block containing the
 // function signature. This allows you to specify the log level.
 func DetailFnLog(channel LogChannel, level LogLevel, format string, v ...interface{}) ScopedLogger {
-	return std.fnLogImpl(2, channel, level, format, v...)
+	testHelper()()
+	return std.fnLogImpl(2, LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	})
 }
 
 //-- Getters -------------------------------------------------------------------
 
 // GetDefaultLevel - Get the configured default level
 func GetDefaultLevel() LogLevel {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.defaultLevel
+	return defaultLogger.GetDefaultLevel()
 }
 
 // GetChannelMap - Get the configured channel map
 func GetChannelMap() ChannelMap {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.channelMap
+	return defaultLogger.GetChannelMap()
+}
+
+// GetFormatter - Get the configured formatter. Channels with their own
+// formatter (see SetChannelFormatter) are not reflected.
+func GetFormatter() LogFormatter {
+	return defaultLogger.GetFormatter()
+}
+
+// IsJSONOutput - Get whether the configured formatter produces JSON, i.e. it
+// declares the "json" format through FormatTagger as the JSONLogFormatter
+// does. This lets applications decide whether to emit structured fields.
+func IsJSONOutput() bool {
+	return defaultLogger.IsJSONOutput()
 }
 
 // GetChannelHeaderLen - Get the configured channel header length
 func GetChannelHeaderLen() int {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.channelHeaderLen
+	return defaultLogger.GetChannelHeaderLen()
 }
 
 // GetServiceName - Get the configured service name
 func GetServiceName() string {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.serviceName
+	return defaultLogger.GetServiceName()
+}
+
+// GetLabels - Get a copy of the configured labels
+func GetLabels() map[string]string {
+	return defaultLogger.GetLabels()
+}
+
+// Get the keys of a set of labels in sorted order
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // GetIndentString - Get a copy of the indent string
 func GetIndentString() string {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.indent
+	return defaultLogger.GetIndentString()
+}
+
+// Get the exit code used by Fatalf
+func (cfg *alogger) getFatalExitCode() int {
+	cfg.mutex.RLock()
+	defer cfg.mutex.RUnlock()
+	return cfg.fatalExitCode
+}
+
+// GetFatalExitCode - Get the exit code used by Fatalf
+func GetFatalExitCode() int {
+	return defaultLogger.GetFatalExitCode()
+}
+
+// GetLevelOffset - Get the offset applied to the level of every channel
+func GetLevelOffset() int {
+	return defaultLogger.GetLevelOffset()
+}
+
+// GetMaxIndentDepth - Get the maximum indentation depth at which entries are
+// logged (0 for no limit)
+func GetMaxIndentDepth() int {
+	return defaultLogger.GetMaxIndentDepth()
+}
+
+// GetMaxIndent - Get the maximum number of rendered indents (0 for no limit)
+func GetMaxIndent() int {
+	return defaultLogger.GetMaxIndent()
 }
 
 // IndentEnabled - Get state of whether indentation is enabled
 func IndentEnabled() bool {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.enableIndent
+	return defaultLogger.IndentEnabled()
 }
 
 // GIDEnabled - Get state of whether the Goroutine ID is enabled
 func GIDEnabled() bool {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.enableGID
+	return defaultLogger.GIDEnabled()
+}
+
+// ScopeCorrelationEnabled - Get state of whether scope correlation is enabled
+func ScopeCorrelationEnabled() bool {
+	return defaultLogger.ScopeCorrelationEnabled()
 }
 
 // FuncSigEnabled - Get state of whether the full function signature is enabled
 func FuncSigEnabled() bool {
-	std.mutex.RLock()
-	defer std.mutex.RUnlock()
-	return std.fullFuncSig
+	return defaultLogger.FuncSigEnabled()
 }
 
 // LevelToHumanString - Convert a level value to a human readable string
@@ -750,21 +2183,52 @@ func LevelToHumanString(level LogLevel) string {
 	}
 }
 
-// PrintConfig - Create a string representation of the current configuration
+// PrintConfig - Create a string representation of the current configuration.
+// Channels are listed in sorted order.
 func PrintConfig() string {
-	s := fmt.Sprintf("Default Level: %s\n", levelToHeaderString(std.defaultLevel))
-	s += fmt.Sprintf("Channel Map:\n")
-	for k, v := range std.channelMap {
-		s += fmt.Sprintf("  %s: %s\n", string(k), LevelToHumanString(v))
+	return defaultLogger.PrintConfig()
+}
+
+// Get the human readable level of a configured channel, prefixed with "=" if
+// it is exact as in the channel filter syntax
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) channelLevelString(channel LogChannel) string {
+	lvl := LevelToHumanString(cfg.channelMap[channel])
+	if cfg.exactChannels[channel] {
+		return "=" + lvl
+	}
+	return lvl
+}
+
+// PrintConfigMap - Create a map representation of the full current
+// configuration. Levels are given in their human readable form.
+func PrintConfigMap() map[string]interface{} {
+	return defaultLogger.PrintConfigMap()
+}
+
+// PrintConfigJSON - Create a JSON representation of the full current
+// configuration as given by PrintConfigMap
+func PrintConfigJSON() string {
+	b, err := json.Marshal(PrintConfigMap())
+	if nil != err {
+		return fmt.Sprintf("{\"error\": \"Failed to marshal config [%v]\"}", err)
 	}
-	return s[:len(s)-1]
+	return string(b)
 }
 
 //-- Channel Log ---------------------------------------------------------------
 
 // Implementation of the ChannelLog interface that can't be constructed directly
 type channelLogImpl struct {
-	channel LogChannel
+	cfg       *alogger
+	channel   LogChannel
+	component string
+	fields    map[string]interface{}
+	traceID   string
+	spanID    string
 }
 
 // UseChannel - Create a channel object that allows subsequent log statements to
@@ -783,58 +2247,250 @@ type channelLogImpl struct {
 //   defer d.ch.FnLog("").Close()
 //   d.ch.Log(alog.INFO, "It's DONE!")
 // }
-func UseChannel(channel LogChannel) ChannelLog {
-	return &channelLogImpl{
-		channel: channel,
+func UseChannel(channel LogChannel) ChannelLogger {
+	return defaultLogger.UseChannel(channel)
+}
+
+// Create an entry for this channel. The persistent fields are merged into the
+// map data with the given map data taking precedence on key collisions.
+func (ch *channelLogImpl) entry(level LogLevel, mapData map[string]interface{}, format string, v []interface{}) LogEntry {
+	if len(ch.fields) > 0 {
+		merged := make(map[string]interface{}, len(ch.fields)+len(mapData))
+		for k, val := range ch.fields {
+			merged[k] = val
+		}
+		for k, val := range mapData {
+			merged[k] = val
+		}
+		mapData = merged
+	}
+	return LogEntry{
+		Channel:   ch.channel,
+		Component: ch.component,
+		TraceID:   ch.traceID,
+		SpanID:    ch.spanID,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		MapData:   mapData,
 	}
 }
 
+// Fill in the channel's persistent fields, trace ids and component on an entry
+// built by one of the structured helpers (LogValue, LogFlag, ...). A component
+// already set by the helper (e.g. LogHealth) is kept, as are the helper's
+// formatKeys, so the channel's own fields are still rendered by the
+// StdLogFormatter.
+func (ch *channelLogImpl) structuredEntry(e LogEntry) LogEntry {
+	base := ch.entry(e.Level, e.MapData, "", nil)
+	e.MapData = base.MapData
+	if e.Component == "" {
+		e.Component = base.Component
+	}
+	e.TraceID = base.TraceID
+	e.SpanID = base.SpanID
+	return e
+}
+
 // Log - Log to a LogChannel instance
 func (ch *channelLogImpl) Log(level LogLevel, format string, v ...interface{}) {
-	Log(ch.channel, level, format, v...)
+	testHelper()()
+	ch.Printf(level, format, v...)
 }
 
 // Printf - Printf to a LogChannel instance
 func (ch *channelLogImpl) Printf(level LogLevel, format string, v ...interface{}) {
-	Printf(ch.channel, level, format, v...)
+	testHelper()()
+	ch.cfg.log(ch.entry(level, nil, format, v))
 }
 
 // Panicf - Panicf to a LogChannel instance
 func (ch *channelLogImpl) Panicf(level LogLevel, format string, v ...interface{}) {
-	Panicf(ch.channel, level, format, v...)
+	ch.cfg.panicf(ch.entry(level, nil, format, v))
 }
 
 // Fatalf - Fatalf to a LogChannel instance
 func (ch *channelLogImpl) Fatalf(level LogLevel, format string, v ...interface{}) {
-	Fatalf(ch.channel, level, format, v...)
+	testHelper()()
+	ch.cfg.fatalf(ch.entry(level, nil, format, v), ch.cfg.getFatalExitCode())
 }
 
 // LogMap - LogMap to a LogChannel instance
 func (ch *channelLogImpl) LogMap(level LogLevel, mapData map[string]interface{}) {
-	LogMap(ch.channel, level, mapData)
+	testHelper()()
+	ch.cfg.log(ch.entry(level, mapData, "", nil))
 }
 
 // LogWithMap - LogWithMap to a LogChannel instance
 func (ch *channelLogImpl) LogWithMap(level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
-	LogWithMap(ch.channel, level, mapData, format, v...)
+	testHelper()()
+	ch.cfg.log(ch.entry(level, mapData, format, v))
+}
+
+// LogValue - LogValue to a LogChannel instance
+func (ch *channelLogImpl) LogValue(level LogLevel, name string, v interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.structuredEntry(valueEntry(ch.channel, level, name, v)))
 }
 
 // IsEnabled - IsEnabled for a LogChannel instance
 func (ch *channelLogImpl) IsEnabled(level LogLevel) bool {
-	return IsEnabled(ch.channel, level)
+	ch.cfg.mutex.RLock()
+	out := ch.cfg.isEnabled(ch.channel, level)
+	ch.cfg.mutex.RUnlock()
+	return out
 }
 
 // LogScope - LogScope for a LogChannel instance
 func (ch *channelLogImpl) LogScope(level LogLevel, format string, v ...interface{}) ScopedLogger {
-	return LogScope(ch.channel, level, format, v...)
+	testHelper()()
+	return ch.cfg.logScope(ch.entry(level, nil, format, v))
 }
 
 // FnLog - FnLog for a LogChannel instance
 func (ch *channelLogImpl) FnLog(format string, v ...interface{}) ScopedLogger {
-	return std.fnLogImpl(2, ch.channel, TRACE, format, v...)
+	testHelper()()
+	return ch.cfg.fnLogImpl(2, ch.entry(TRACE, nil, format, v))
 }
 
 // DetailFnLog - DetailFnLog for a LogChannel instance
 func (ch *channelLogImpl) DetailFnLog(level LogLevel, format string, v ...interface{}) ScopedLogger {
-	return std.fnLogImpl(2, ch.channel, level, format, v...)
+	testHelper()()
+	return ch.cfg.fnLogImpl(2, ch.entry(level, nil, format, v))
+}
+
+// WithComponent - Create a copy of this ChannelLog that adds the given
+// component name to every entry. The component is a finer-grained tag than the
+// channel and does not affect level filtering.
+func (ch *channelLogImpl) WithComponent(name string) ChannelLogger {
+	return &channelLogImpl{
+		cfg:       ch.cfg,
+		channel:   ch.channel,
+		component: name,
+		fields:    ch.fields,
+		traceID:   ch.traceID,
+		spanID:    ch.spanID,
+	}
+}
+
+// WithFields - Create a copy of this ChannelLog that adds the given fields to
+// the map data of every entry. Fields passed to an individual call (LogMap,
+// LogWithMap, LogValue) take precedence over these on key collisions, and
+// these take precedence over fields inherited from the parent.
+func (ch *channelLogImpl) WithFields(fields map[string]interface{}) ChannelLogger {
+	merged := make(map[string]interface{}, len(ch.fields)+len(fields))
+	for k, v := range ch.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &channelLogImpl{
+		cfg:       ch.cfg,
+		channel:   ch.channel,
+		component: ch.component,
+		fields:    merged,
+		traceID:   ch.traceID,
+		spanID:    ch.spanID,
+	}
+}
+
+//-- Nop Channel Log -----------------------------------------------------------
+
+// Implementation of the ChannelLog interface that discards everything
+type nopChannelLog struct{}
+
+// Implementation of the ScopedLogger interface that does nothing on Close
+type nopScopedLogger struct{}
+
+// Close - No-op
+func (nopScopedLogger) Close() {}
+
+// NopChannelLog - Create a ChannelLog that never produces any output. This is
+// useful as a default value for ChannelLog struct members so that they are safe
+// to use before being initialized with UseChannel.
+//
+// NOTE: Panicf and Fatalf still panic and exit respectively so that control
+//  flow is unchanged, but nothing is logged.
+////
+func NopChannelLog() ChannelLogger {
+	return nopChannelLog{}
+}
+
+// Log - No-op
+func (nopChannelLog) Log(level LogLevel, format string, v ...interface{}) {
+}
+
+// Printf - No-op
+func (nopChannelLog) Printf(level LogLevel, format string, v ...interface{}) {
+}
+
+// Panicf - Panic without logging
+func (nopChannelLog) Panicf(level LogLevel, format string, v ...interface{}) {
+	panic(&PanicError{Level: level, Message: fmt.Sprintf(format, v...)})
+}
+
+// Fatalf - Exit with the configured fatal exit code without logging
+func (nopChannelLog) Fatalf(level LogLevel, format string, v ...interface{}) {
+	code := GetFatalExitCode()
+	std.mutex.RLock()
+	exit := exitFunc
+	std.mutex.RUnlock()
+	exit(code)
+}
+
+// LogMap - No-op
+func (nopChannelLog) LogMap(level LogLevel, mapData map[string]interface{}) {}
+
+// LogWithMap - No-op
+func (nopChannelLog) LogWithMap(level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+}
+
+// LogValue - No-op
+func (nopChannelLog) LogValue(level LogLevel, name string, v interface{}) {}
+
+// LogRetry - No-op
+func (nopChannelLog) LogRetry(level LogLevel, attempt, maxAttempts int, lastErr error, nextDelay time.Duration) {
+}
+
+// LogFlag - No-op
+func (nopChannelLog) LogFlag(level LogLevel, flagName string, value interface{}, reason string) {
+}
+
+// LogMismatch - No-op
+func (nopChannelLog) LogMismatch(level LogLevel, field string, expected, actual interface{}) {
+}
+
+// LogHealth - No-op
+func (nopChannelLog) LogHealth(level LogLevel, component string, healthy bool, detail string) {
+}
+
+// WithComponent - Returns the same no-op logger
+func (n nopChannelLog) WithComponent(name string) ChannelLogger {
+	return n
+}
+
+// WithFields - Returns the same no-op logger
+func (n nopChannelLog) WithFields(fields map[string]interface{}) ChannelLogger {
+	return n
+}
+
+// IsEnabled - Always false
+func (nopChannelLog) IsEnabled(level LogLevel) bool {
+	return false
+}
+
+// LogScope - Returns a ScopedLogger that does nothing
+func (nopChannelLog) LogScope(level LogLevel, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+
+// FnLog - Returns a ScopedLogger that does nothing
+func (nopChannelLog) FnLog(format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+
+// DetailFnLog - Returns a ScopedLogger that does nothing
+func (nopChannelLog) DetailFnLog(level LogLevel, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
 }
@@ -0,0 +1,190 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+//-- Async Writer --------------------------------------------------------------
+
+// DropPolicy - Policy used by the AsyncWriter when its buffer is full
+type DropPolicy int
+
+// Available drop policies
+const (
+	// Block the caller until there is room in the buffer
+	BlockWhenFull DropPolicy = iota
+	// Discard the line being written
+	DropNewest
+	// Discard the oldest buffered line to make room for the new one
+	DropOldest
+)
+
+// AsyncWriter - io.Writer implementation that hands each line to a background
+// goroutine which writes it to the wrapped writer. This keeps slow writers
+// (network, disk) off the logging path.
+//
+// Lines are buffered up to a fixed number and the DropPolicy decides what
+// happens when the buffer is full. All methods are safe to call from multiple
+// goroutines.
+type AsyncWriter struct {
+	// Accessed atomically, so kept first for 64-bit alignment
+	dropped uint64
+
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	writer   io.Writer
+	queue    [][]byte
+	capacity int
+	policy   DropPolicy
+	writing  bool
+	closed   bool
+	lastErr  error
+	done     chan struct{}
+}
+
+// NewAsyncWriter - Create an AsyncWriter that buffers up to bufSize lines in
+// front of the given writer. A bufSize less than 1 is treated as 1.
+func NewAsyncWriter(w io.Writer, bufSize int, policy DropPolicy) *AsyncWriter {
+	if bufSize < 1 {
+		bufSize = 1
+	}
+	aw := &AsyncWriter{
+		writer:   w,
+		capacity: bufSize,
+		policy:   policy,
+		done:     make(chan struct{}),
+	}
+	aw.cond = sync.NewCond(&aw.mutex)
+	go aw.run()
+	return aw
+}
+
+// Write - Queue a single log line. With the DropNewest and DropOldest policies
+// this never blocks and never reports dropped lines as errors; use
+// DroppedCount to find out how many were lost.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// Loop because a blocked writer may wake up to find the writer closed
+	for {
+		if w.closed {
+			return 0, errors.New("Write to closed AsyncWriter")
+		}
+		if len(w.queue) < w.capacity {
+			break
+		}
+		switch w.policy {
+		case DropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case DropOldest:
+			w.queue = w.queue[1:]
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			w.cond.Wait()
+		}
+	}
+
+	// Always queue a copy since the caller may reuse the buffer
+	line := make([]byte, len(p))
+	copy(line, p)
+	w.queue = append(w.queue, line)
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// DroppedCount - Get the total number of lines dropped because the buffer was
+// full
+func (w *AsyncWriter) DroppedCount() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Err - Get the most recent error returned by the wrapped writer
+func (w *AsyncWriter) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.lastErr
+}
+
+// Flush - Wait for all buffered lines to be written, then flush the wrapped
+// writer if it supports it
+func (w *AsyncWriter) Flush() error {
+	w.mutex.Lock()
+	for len(w.queue) > 0 || w.writing {
+		w.cond.Wait()
+	}
+	w.mutex.Unlock()
+	return flushWriter(w.writer)
+}
+
+// Close - Write all buffered lines and stop the background goroutine. The
+// wrapped writer is not closed.
+func (w *AsyncWriter) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mutex.Unlock()
+	<-w.done
+	return flushWriter(w.writer)
+}
+
+// Background loop that writes lines from the queue until closed and drained
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			return
+		}
+
+		// Pop the next line and write it without holding the lock
+		line := w.queue[0]
+		w.queue = w.queue[1:]
+		w.writing = true
+		w.cond.Broadcast()
+		w.mutex.Unlock()
+		_, err := w.writer.Write(line)
+		w.mutex.Lock()
+		w.writing = false
+		if nil != err {
+			w.lastErr = err
+		}
+		w.cond.Broadcast()
+	}
+}
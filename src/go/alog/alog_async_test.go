@@ -0,0 +1,172 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Writer that blocks every write until released and records what it got
+type gatedWriter struct {
+	mutex   sync.Mutex
+	lines   []string
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	w.mutex.Lock()
+	w.lines = append(w.lines, string(p))
+	w.mutex.Unlock()
+	return len(p), nil
+}
+
+func (w *gatedWriter) Lines() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]string{}, w.lines...)
+}
+
+// Set up an AsyncWriter with a buffer of two lines whose background goroutine
+// is stuck writing the first line
+func saturatedAsyncWriter(policy DropPolicy) (*AsyncWriter, *gatedWriter) {
+	gw := newGatedWriter()
+	aw := NewAsyncWriter(gw, 2, policy)
+	aw.Write([]byte("0"))
+	<-gw.started
+	aw.Write([]byte("1"))
+	aw.Write([]byte("2"))
+	return aw, gw
+}
+
+// Tests - Async Writer ////////////////////////////////////////////////////////
+
+////
+// AsyncWriter - Lines logged through the writer are delivered in order
+// 1) Configure an AsyncWriter in front of a MemoryWriter
+// 2) Log some lines and flush
+//  -> All lines delivered in order
+// 3) Close the writer
+//  -> Further writes fail
+////
+func Test_AlogAsync_Basic(t *testing.T) {
+
+	// Configure
+	mw := NewMemoryWriter()
+	aw := NewAsyncWriter(mw, 16, BlockWhenFull)
+	SetWriter(aw)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Log and flush
+	Log("TEST", INFO, "One")
+	Log("TEST", INFO, "Two")
+	Log("TEST", INFO, "Three")
+	assert.Nil(t, Flush())
+	assert.True(t, VerifyLogs(mw.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "One"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Two"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Three"},
+	}))
+
+	// Close
+	assert.Nil(t, aw.Close())
+	assert.Nil(t, aw.Close())
+	_, err := aw.Write([]byte("late"))
+	assert.NotNil(t, err)
+	assert.Equal(t, uint64(0), aw.DroppedCount())
+}
+
+////
+// AsyncWriter - DropNewest discards the line that does not fit
+// 1) Saturate the buffer and write one more line
+//  -> Write returns immediately and the new line is dropped
+////
+func Test_AlogAsync_DropNewest(t *testing.T) {
+	aw, gw := saturatedAsyncWriter(DropNewest)
+	aw.Write([]byte("3"))
+	assert.Equal(t, uint64(1), aw.DroppedCount())
+	close(gw.release)
+	assert.Nil(t, aw.Close())
+	assert.Equal(t, "0 1 2", strings.Join(gw.Lines(), " "))
+}
+
+////
+// AsyncWriter - DropOldest discards the oldest buffered line
+// 1) Saturate the buffer and write two more lines
+//  -> Writes return immediately and the oldest queued lines are dropped
+////
+func Test_AlogAsync_DropOldest(t *testing.T) {
+	aw, gw := saturatedAsyncWriter(DropOldest)
+	aw.Write([]byte("3"))
+	aw.Write([]byte("4"))
+	assert.Equal(t, uint64(2), aw.DroppedCount())
+	close(gw.release)
+	assert.Nil(t, aw.Close())
+	assert.Equal(t, "0 3 4", strings.Join(gw.Lines(), " "))
+}
+
+////
+// AsyncWriter - BlockWhenFull blocks the caller until there is room
+// 1) Saturate the buffer and write one more line in another goroutine
+//  -> Write does not return while the buffer is full
+// 2) Release the wrapped writer
+//  -> Write returns and nothing is dropped
+////
+func Test_AlogAsync_BlockWhenFull(t *testing.T) {
+	aw, gw := saturatedAsyncWriter(BlockWhenFull)
+	returned := make(chan struct{})
+	go func() {
+		aw.Write([]byte("3"))
+		close(returned)
+	}()
+	select {
+	case <-returned:
+		t.Fatal("Write returned while the buffer was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gw.release)
+	<-returned
+	assert.Nil(t, aw.Close())
+	assert.Equal(t, uint64(0), aw.DroppedCount())
+	assert.Equal(t, "0 1 2 3", strings.Join(gw.Lines(), " "))
+}
@@ -0,0 +1,103 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"time"
+)
+
+//-- Explicit Timestamps -------------------------------------------------------
+
+// LogAt - Log a message with an explicit timestamp in place of the current
+// time. This is intended for backfilling or replaying historical events, where
+// the time the event happened differs from the time it is logged.
+func LogAt(channel LogChannel, ts time.Time, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		Timestamp: ts.UTC(),
+	})
+}
+
+// LogMapAt - LogMap with an explicit timestamp
+func LogMapAt(channel LogChannel, ts time.Time, level LogLevel, mapData map[string]interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		MapData:   mapData,
+		Timestamp: ts.UTC(),
+	})
+}
+
+// LogWithMapAt - LogWithMap with an explicit timestamp
+func LogWithMapAt(channel LogChannel, ts time.Time, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		MapData:   mapData,
+		Timestamp: ts.UTC(),
+	})
+}
+
+// LogAt - LogAt for a LogChannel instance
+func (ch *channelLogImpl) LogAt(ts time.Time, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	e := ch.entry(level, nil, format, v)
+	e.Timestamp = ts.UTC()
+	ch.cfg.log(e)
+}
+
+// LogMapAt - LogMapAt for a LogChannel instance
+func (ch *channelLogImpl) LogMapAt(ts time.Time, level LogLevel, mapData map[string]interface{}) {
+	testHelper()()
+	e := ch.entry(level, mapData, "", nil)
+	e.Timestamp = ts.UTC()
+	ch.cfg.log(e)
+}
+
+// LogWithMapAt - LogWithMapAt for a LogChannel instance
+func (ch *channelLogImpl) LogWithMapAt(ts time.Time, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+	testHelper()()
+	e := ch.entry(level, mapData, format, v)
+	e.Timestamp = ts.UTC()
+	ch.cfg.log(e)
+}
+
+// LogAt - No-op
+func (nopChannelLog) LogAt(ts time.Time, level LogLevel, format string, v ...interface{}) {}
+
+// LogMapAt - No-op
+func (nopChannelLog) LogMapAt(ts time.Time, level LogLevel, mapData map[string]interface{}) {}
+
+// LogWithMapAt - No-op
+func (nopChannelLog) LogWithMapAt(ts time.Time, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+}
@@ -0,0 +1,100 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"strings"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Explicit Timestamps /////////////////////////////////////////////////
+
+////
+// LogAt - Override the entry timestamp
+// 1) Log with each package and channel variant using JSON
+//  -> Each entry has the explicit timestamp, converted to UTC
+//  -> Map data kept
+// 2) Log with each variant using the Std formatter
+//  -> Each line starts with the explicit timestamp
+// 3) Log normally
+//  -> Current time used
+////
+func Test_AlogAt_Timestamp(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	ts := time.Date(2019, 6, 7, 8, 9, 10, 0, time.FixedZone("EST", -5*60*60))
+	expTs := time.Date(2019, 6, 7, 13, 9, 10, 0, time.UTC)
+	m := map[string]interface{}{"key": "val"}
+	ch := UseChannel("TEST")
+	logAll := func() {
+		LogAt("TEST", ts, INFO, "Hello %s", "world")
+		LogMapAt("TEST", ts, INFO, m)
+		LogWithMapAt("TEST", ts, INFO, m, "With map")
+		ch.LogAt(ts, INFO, "Hello %s", "world")
+		ch.LogMapAt(ts, INFO, m)
+		ch.LogWithMapAt(ts, INFO, m, "With map")
+		LogAt("TEST", ts, DEBUG, "Not enabled")
+	}
+
+	// JSON
+	logAll()
+	entries := w.Entries()
+	if assert.Equal(t, 6, len(entries)) {
+		for _, e := range entries {
+			assert.Equal(t, expTs, e.Timestamp)
+		}
+		assert.Equal(t, "Hello world", entries[0].Format)
+		assert.Equal(t, m, entries[1].MapData)
+		assert.Equal(t, m, entries[5].MapData)
+	}
+
+	// Std
+	w.Reset()
+	UseStdLogFormatter()
+	logAll()
+	lines := w.Lines()
+	assert.Equal(t, 8, len(lines))
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, "2019/06/07 13:09:10 [TEST :INFO]"), line)
+	}
+
+	// Current time
+	w.Reset()
+	UseJSONLogFormatter()
+	Log("TEST", INFO, "Now")
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.WithinDuration(t, time.Now().UTC(), entries[0].Timestamp, time.Minute)
+	}
+}
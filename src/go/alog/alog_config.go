@@ -0,0 +1,223 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+//-- File Configuration --------------------------------------------------------
+
+// FileConfig - The document read by ConfigureFromFile. The fields mirror the
+// command line flags, except that the filters are given as a map from channel
+// to level. A level prefixed with "=" enables only that exact level, as in the
+// CHAN:=level filter syntax.
+type FileConfig struct {
+	DefaultLevel     string            `json:"default_level"`
+	Filters          map[string]string `json:"filters"`
+	ChannelHeaderLen int               `json:"chan_header_len"`
+	EnableGID        bool              `json:"enable_gid"`
+	EnableFuncSig    bool              `json:"function_signature"`
+	DisableIndent    bool              `json:"disable_indent"`
+	ServiceName      string            `json:"service_name"`
+	OutputJSON       bool              `json:"output_json"`
+}
+
+// ConfigureFromFile - Configure the global alog setup from a JSON document at
+// the given path. See FileConfig for the fields. The whole document is checked
+// before anything is applied, so a bad file leaves the current configuration
+// untouched. Unset fields take the same defaults as the command line flags.
+// Writers are not changed. The change is logged as a config_change event with
+// the source "file".
+func ConfigureFromFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return err
+	}
+	var c FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); nil != err {
+		return fmt.Errorf("Invalid config file [%s]: %v", path, err)
+	}
+
+	// Parse default level
+	dfltLvl := INFO
+	if len(c.DefaultLevel) > 0 {
+		lvl, err := LevelFromString(c.DefaultLevel)
+		if nil != err {
+			return fmt.Errorf("Invalid default level in [%s]: %v", path, err)
+		}
+		dfltLvl = lvl
+	}
+
+	// Parse the filters in a stable order so the error for a bad file is
+	// always the same
+	channels := make([]string, 0, len(c.Filters))
+	for k := range c.Filters {
+		channels = append(channels, k)
+	}
+	sort.Strings(channels)
+	cmap := ChannelMap{}
+	exact := map[LogChannel]bool{}
+	for _, k := range channels {
+		lvlStr := c.Filters[k]
+		isExact := strings.HasPrefix(lvlStr, "=")
+		if isExact {
+			lvlStr = lvlStr[1:]
+		}
+		lvl, err := LevelFromString(lvlStr)
+		if nil != err {
+			return fmt.Errorf("Invalid level for channel [%s] in [%s]: %v", k, path, err)
+		}
+		cmap[LogChannel(k)] = lvl
+		if isExact {
+			exact[LogChannel(k)] = true
+		}
+	}
+
+	// Apply
+	before := PrintConfigMap()
+	configExact(dfltLvl, cmap, exact)
+	if c.ChannelHeaderLen > 0 {
+		SetMaxChannelLen(c.ChannelHeaderLen)
+	} else {
+		SetMaxChannelLen(5)
+	}
+	if c.EnableGID {
+		EnableGID()
+	} else {
+		DisableGID()
+	}
+	if c.EnableFuncSig {
+		EnableFullFuncSig()
+	} else {
+		DisableFullFuncSig()
+	}
+	if c.DisableIndent {
+		DisableIndent()
+	} else {
+		EnableIndent()
+	}
+	SetServiceName(c.ServiceName)
+	if c.OutputJSON {
+		UseJSONLogFormatter()
+	} else {
+		UseStdLogFormatter()
+	}
+	logConfigChange("file", before)
+	return nil
+}
+
+//-- Reload on Signal ----------------------------------------------------------
+
+// State of the goroutine that reloads the config file on SIGHUP
+var configWatch struct {
+	mutex sync.Mutex
+	path  string
+	sigs  chan os.Signal
+	done  chan struct{}
+}
+
+// WatchConfigSignal - Reload the configuration from the file at path with
+// ConfigureFromFile each time the process receives SIGHUP. If the file is
+// missing or bad, the error is logged on the ALOG channel and the previous
+// configuration is kept. Only one file is watched at a time: calling this
+// again replaces the watched path.
+func WatchConfigSignal(path string) {
+	configWatch.mutex.Lock()
+	defer configWatch.mutex.Unlock()
+	stopWatchingConfigSignal()
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	configWatch.path = path
+	configWatch.sigs = sigs
+	configWatch.done = done
+	signal.Notify(sigs, syscall.SIGHUP)
+	go func() {
+		defer close(done)
+		for range sigs {
+			reloadConfig(path)
+		}
+	}()
+}
+
+// StopWatchingConfigSignal - Stop reloading the configuration on SIGHUP and
+// wait for the reload goroutine to exit. This is a no-op if WatchConfigSignal
+// is not active.
+func StopWatchingConfigSignal() {
+	configWatch.mutex.Lock()
+	stopWatchingConfigSignal()
+	configWatch.mutex.Unlock()
+}
+
+// ReloadConfig - Reload the configuration from the file given to
+// WatchConfigSignal, exactly as a SIGHUP would. This allows the reload to be
+// triggered without sending a signal, e.g. in tests or from an admin endpoint.
+func ReloadConfig() error {
+	configWatch.mutex.Lock()
+	path := configWatch.path
+	configWatch.mutex.Unlock()
+	if len(path) == 0 {
+		return errors.New("Not watching a config file")
+	}
+	return reloadConfig(path)
+}
+
+// Reload the config file, logging the error if it can't be applied
+func reloadConfig(path string) error {
+	err := ConfigureFromFile(path)
+	if nil != err {
+		Log("ALOG", ERROR, "Failed to reload config, keeping the previous config: %v", err)
+	}
+	return err
+}
+
+// Stop the reload goroutine if it is running
+//
+// NOTE: Must be called with the config watch mutex held
+////
+func stopWatchingConfigSignal() {
+	if nil == configWatch.sigs {
+		return
+	}
+	signal.Stop(configWatch.sigs)
+	close(configWatch.sigs)
+	<-configWatch.done
+	configWatch.path = ""
+	configWatch.sigs = nil
+	configWatch.done = nil
+}
@@ -0,0 +1,192 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Config //////////////////////////////////////////////////////////////
+
+// Write a config document to a file in a temp directory and return its path
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+////
+// ConfigureFromFile - Test configuring from a JSON file
+//
+// 1) Configure from a valid document
+//  -> Levels, exact channels and settings applied
+// 2) Configure from a minimal document
+//  -> Unset fields take the flag defaults
+//  -> config_change event logged with source file
+////
+func Test_AlogConfig_ConfigureFromFile(t *testing.T) {
+	defer ResetDefaults()
+	dir, err := ioutil.TempDir("", "alog_config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	w := NewMemoryWriter()
+	SetWriter(w)
+
+	// Full document
+	path := writeConfigFile(t, dir, "full.json", `{
+		"default_level": "warning",
+		"filters": {"TEST": "debug", "EXCT": "=info"},
+		"chan_header_len": 8,
+		"enable_gid": true,
+		"function_signature": true,
+		"disable_indent": true,
+		"service_name": "file_service"
+	}`)
+	assert.Nil(t, ConfigureFromFile(path))
+	assert.Equal(t, WARNING, GetDefaultLevel())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"TEST": DEBUG,
+		"EXCT": INFO,
+	}))
+	assert.Equal(t, map[LogChannel]bool{"EXCT": true}, GetExactChannels())
+	assert.Equal(t, 8, GetChannelHeaderLen())
+	assert.True(t, GIDEnabled())
+	assert.True(t, FuncSigEnabled())
+	assert.False(t, IndentEnabled())
+	assert.Equal(t, "file_service", GetServiceName())
+
+	// Minimal document
+	path = writeConfigFile(t, dir, "min.json", `{"filters": {"TEST": "debug2"}}`)
+	assert.Nil(t, ConfigureFromFile(path))
+	assert.Equal(t, INFO, GetDefaultLevel())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG2}))
+	assert.Equal(t, map[LogChannel]bool{}, GetExactChannels())
+	assert.Equal(t, 5, GetChannelHeaderLen())
+	assert.False(t, GIDEnabled())
+	assert.True(t, IndentEnabled())
+	assert.Equal(t, "", GetServiceName())
+	assert.Contains(t, strings.Join(w.Lines(), ""), "Configuration changed by file")
+}
+
+////
+// ConfigureFromFileInvalid - Test that bad files are rejected
+//
+// 1) Configure, then try a missing file, malformed JSON, an unknown field, a
+//    bad default level and a bad filter level
+//  -> Each returns an error
+//  -> Configuration unchanged
+////
+func Test_AlogConfig_ConfigureFromFileInvalid(t *testing.T) {
+	defer ResetDefaults()
+	dir, err := ioutil.TempDir("", "alog_config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	Config(DEBUG, ChannelMap{"KEEP": INFO})
+
+	assert.NotNil(t, ConfigureFromFile(filepath.Join(dir, "missing.json")))
+	for name, content := range map[string]string{
+		"malformed.json": `{"default_level": `,
+		"unknown.json":   `{"default_level": "info", "bogus": true}`,
+		"level.json":     `{"default_level": "loud"}`,
+		"filter.json":    `{"filters": {"TEST": "=loud"}}`,
+	} {
+		err := ConfigureFromFile(writeConfigFile(t, dir, name, content))
+		assert.NotNil(t, err, name)
+	}
+	assert.Equal(t, DEBUG, GetDefaultLevel())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"KEEP": INFO}))
+}
+
+////
+// WatchConfigSignal - Test reloading the config file
+//
+// 1) Reload without watching
+//  -> Error
+// 2) Watch a valid file, rewrite it and reload
+//  -> New config applied
+// 3) Make the file malformed, then remove it, reloading each time
+//  -> Error logged on ALOG
+//  -> Previous config kept
+// 4) Restore the file and send SIGHUP
+//  -> New config applied
+// 5) Stop watching
+//  -> Reload returns an error
+////
+func Test_AlogConfig_WatchConfigSignal(t *testing.T) {
+	defer ResetAll()
+	dir, err := ioutil.TempDir("", "alog_config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	w := NewMemoryWriter()
+	SetWriter(w)
+
+	// Not watching
+	assert.NotNil(t, ReloadConfig())
+
+	// Watch and reload
+	path := writeConfigFile(t, dir, "alog.json", `{"default_level": "info"}`)
+	WatchConfigSignal(path)
+	writeConfigFile(t, dir, "alog.json", `{"default_level": "info", "filters": {"TEST": "debug"}}`)
+	assert.Nil(t, ReloadConfig())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG}))
+
+	// Bad and missing files
+	w.Reset()
+	writeConfigFile(t, dir, "alog.json", `{"default_level": `)
+	assert.NotNil(t, ReloadConfig())
+	assert.Nil(t, os.Remove(path))
+	assert.NotNil(t, ReloadConfig())
+	lines := w.Lines()
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], "Failed to reload config")
+		assert.Contains(t, lines[1], "Failed to reload config")
+	}
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG}))
+
+	// SIGHUP
+	writeConfigFile(t, dir, "alog.json", `{"default_level": "info", "filters": {"TEST": "debug3"}}`)
+	proc, err := os.FindProcess(os.Getpid())
+	assert.Nil(t, err)
+	if nil == proc.Signal(syscall.SIGHUP) {
+		assert.Eventually(t, func() bool {
+			return GetChannelMap()["TEST"] == DEBUG3
+		}, time.Second, time.Millisecond)
+	}
+
+	// Stop
+	StopWatchingConfigSignal()
+	assert.NotNil(t, ReloadConfig())
+	StopWatchingConfigSignal()
+}
@@ -0,0 +1,166 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//-- ConsoleFormatter Implementation -------------------------------------------
+
+// ANSI escape codes used to colorize the level
+const (
+	consoleColorReset   = "\x1b[0m"
+	consoleColorRed     = "\x1b[31m"
+	consoleColorGreen   = "\x1b[32m"
+	consoleColorYellow  = "\x1b[33m"
+	consoleColorMagenta = "\x1b[35m"
+	consoleColorCyan    = "\x1b[36m"
+)
+
+// ConsoleFormatter - LogFormatter instance for local development that renders
+// each entry as a single line of the form
+//
+//	15:04:05 INFO CHANL message key=value key=value
+//
+// Unlike the StdLogFormatter, map data is rendered as key=value pairs in key
+// order at the end of the message line rather than on lines of their own.
+// Values containing spaces, quotes or equals signs are quoted. If Color is set,
+// the level is colorized with ANSI escape codes.
+type ConsoleFormatter struct {
+	Color bool
+}
+
+// FormatEntry - Format an entry as a single console line
+func (p ConsoleFormatter) FormatEntry(e LogEntry) []string {
+	buf := getBuffer()
+	p.FormatEntryTo(buf, e)
+	out := splitLines(buf.Bytes())
+	putBuffer(buf)
+	return out
+}
+
+// FormatEntryTo - Format an entry as a single console line directly into a
+// buffer
+func (p ConsoleFormatter) FormatEntryTo(buf *bytes.Buffer, e LogEntry) {
+	cfg := e.config()
+
+	// Time and level
+	buf.WriteString(e.Timestamp.Format("15:04:05"))
+	buf.WriteByte(' ')
+	if color := consoleLevelColor(e.Level); p.Color && len(color) > 0 {
+		buf.WriteString(color)
+		buf.WriteString(levelToHeaderString(e.Level))
+		buf.WriteString(consoleColorReset)
+	} else {
+		buf.WriteString(levelToHeaderString(e.Level))
+	}
+
+	// Channel, padded to the header length so that messages line up
+	buf.WriteByte(' ')
+	writeChannelHeader(buf, string(e.Channel), cfg.channelHeaderLen)
+	buf.WriteByte(' ')
+
+	// Indentation, component and message
+	for i := 0; i < e.NIndent; i++ {
+		buf.WriteString(cfg.indent)
+	}
+	if len(e.Component) > 0 {
+		buf.WriteByte('(')
+		buf.WriteString(e.Component)
+		buf.WriteString(") ")
+	}
+	msg := e.message()
+	buf.WriteString(msg)
+
+	// Map data in key order
+	keys := make([]string, 0, len(e.MapData))
+	for k := range e.MapData {
+		if !e.inFormat(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i > 0 || len(msg) > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(k)
+		buf.WriteByte('=')
+		buf.WriteString(consoleValue(cfg.renderMapValue(e.MapData[k])))
+	}
+	buf.WriteByte('\n')
+}
+
+// Get the color for a level, or an empty string for no color
+func consoleLevelColor(level LogLevel) string {
+	switch {
+	case level <= ERROR:
+		return consoleColorRed
+	case level == WARNING:
+		return consoleColorYellow
+	case level == INFO:
+		return consoleColorGreen
+	case level == TRACE:
+		return consoleColorCyan
+	case level <= DEBUG4:
+		return consoleColorMagenta
+	}
+	return ""
+}
+
+// Quote a rendered map value if it would be ambiguous as a key=value pair
+func consoleValue(v string) string {
+	if len(v) == 0 || strings.ContainsAny(v, " \t\n\"=") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// Determine whether to colorize output to a writer. Color is used for
+// terminals unless the NO_COLOR environment variable is set.
+func useColor(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return nil == err && info.Mode()&os.ModeCharDevice != 0
+}
+
+// UseConsoleFormatter - Set the formatter to a ConsoleFormatter. The level is
+// colorized if the configured writer is a terminal. To force color on or off,
+// use SetFormatter(ConsoleFormatter{Color: ...}) instead.
+func UseConsoleFormatter() {
+	defaultLogger.UseConsoleFormatter()
+}
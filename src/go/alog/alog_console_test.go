@@ -0,0 +1,114 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Console Formatter ///////////////////////////////////////////////////
+
+////
+// ConsoleFormatter - Render map data as trailing key=value pairs
+// 1) Log a message with map data without color
+//  -> Single line with the time, level, padded channel and message
+//  -> Map data in key order, with ambiguous values quoted
+// 2) Log a LogValue entry and an indented entry with a component
+//  -> Named value not repeated, indentation and component shown
+////
+func Test_AlogConsole_Fields(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 15, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return ts })
+	w := NewMemoryWriter()
+	SetWriter(w)
+	SetFormatter(ConsoleFormatter{})
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	assert.Equal(t, "console", PrintConfigMap()["formatter"])
+
+	// Map data
+	LogWithMap("TEST", INFO, map[string]interface{}{
+		"zeta":  1,
+		"alpha": "two words",
+		"mid":   true,
+		"empty": "",
+	}, "Hello %s", "world")
+	assert.Equal(t, []string{
+		"15:04:05 INFO TEST  Hello world alpha=\"two words\" empty=\"\" mid=true zeta=1\n",
+	}, w.Lines())
+
+	// LogValue, indentation and component
+	w.Reset()
+	LogValue("TEST", WARNING, "count", 42)
+	func() {
+		defer LogScope("TEST", INFO, "scope").Close()
+		UseChannel("TEST").WithComponent("comp").Log(ERROR, "inner")
+	}()
+	assert.Equal(t, []string{
+		"15:04:05 WARN TEST  count = 42\n",
+		"15:04:05 INFO TEST  Start: scope\n",
+		"15:04:05 ERRR TEST    (comp) inner\n",
+		"15:04:05 INFO TEST  End: scope\n",
+	}, w.Lines())
+}
+
+////
+// ConsoleFormatter - Colorize the level when color is forced
+// 1) Log at several levels with color forced
+//  -> Each level wrapped in its color and a reset
+// 2) Use UseConsoleFormatter with a non-terminal writer
+//  -> No color
+////
+func Test_AlogConsole_Color(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 15, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return ts })
+	w := NewMemoryWriter()
+	SetWriter(w)
+	SetFormatter(ConsoleFormatter{Color: true})
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+
+	Log("TEST", ERROR, "bad")
+	Log("TEST", WARNING, "careful")
+	LogMap("TEST", INFO, map[string]interface{}{"key": "val"})
+	Log("TEST", DEBUG, "detail")
+	assert.Equal(t, []string{
+		"15:04:05 \x1b[31mERRR\x1b[0m TEST  bad\n",
+		"15:04:05 \x1b[33mWARN\x1b[0m TEST  careful\n",
+		"15:04:05 \x1b[32mINFO\x1b[0m TEST  key=val\n",
+		"15:04:05 \x1b[35mDBUG\x1b[0m TEST  detail\n",
+	}, w.Lines())
+
+	// Auto detection
+	w.Reset()
+	UseConsoleFormatter()
+	Log("TEST", INFO, "plain")
+	assert.Equal(t, []string{"15:04:05 INFO TEST  plain\n"}, w.Lines())
+}
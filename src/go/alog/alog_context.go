@@ -0,0 +1,146 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//-- Error Context -------------------------------------------------------------
+
+// Ring buffers of recently suppressed entries per GID
+type contextBuffer struct {
+	mutex sync.Mutex
+	size  int
+	rings map[uint64][]LogEntry
+}
+
+// Add an entry to the ring for a goroutine, dropping the oldest entry if the
+// ring is full
+func (b *contextBuffer) record(gid uint64, e LogEntry) {
+	b.mutex.Lock()
+	ring := b.rings[gid]
+	if len(ring) < b.size {
+		ring = append(ring, e)
+	} else {
+		copy(ring, ring[1:])
+		ring[len(ring)-1] = e
+	}
+	b.rings[gid] = ring
+	b.mutex.Unlock()
+}
+
+// Remove and return the buffered entries for a goroutine, oldest first
+func (b *contextBuffer) take(gid uint64) []LogEntry {
+	b.mutex.Lock()
+	ring := b.rings[gid]
+	delete(b.rings, gid)
+	b.mutex.Unlock()
+	return ring
+}
+
+// Keep an entry that was suppressed so that it can be replayed if an error
+// follows on the same goroutine. Only entries less severe than INFO are kept,
+// and never those on a muted channel or one excluded by the allowlist. Lazy
+// values are resolved and the message is rendered immediately so that later
+// changes to the arguments are not reflected in the replayed line.
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) bufferContext(e LogEntry) {
+	if nil == cfg.errorContext || e.Level <= INFO || cfg.isSilenced(e.Channel) {
+		return
+	}
+	e.logger = cfg
+	e.NIndent = cfg.getIndentCount()
+	e.Timestamp = cfg.now()
+	e.Servicename = cfg.serviceName
+	e.Subsystem = cfg.channelGroups[e.Channel]
+	cfg.setScope(&e)
+	resolveLazyValues(&e)
+	if len(e.Expansion) > 0 {
+		e.Format = strings.ReplaceAll(fmt.Sprintf(e.Format, e.Expansion...), "%", "%%")
+		e.Expansion = nil
+	}
+	cfg.errorContext.record(getGID(), e)
+}
+
+// Write out the entries buffered on the current goroutine if the given entry
+// is an error. The hooks run on each buffered entry before it is written, so
+// replayed entries are scrubbed and dropped like any other. The first write
+// error is returned.
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) replayContext(e LogEntry) error {
+	if nil == cfg.errorContext || e.Level > ERROR {
+		return nil
+	}
+	var outErr error
+	for _, c := range cfg.errorContext.take(getGID()) {
+		if !runHooks(&c) {
+			continue
+		}
+		countEmitted(c.Level)
+		if err := cfg.writeEntry(c); nil != err && nil == outErr {
+			outErr = err
+		}
+	}
+	return outErr
+}
+
+// Get the number of entries kept per goroutine (0 when disabled)
+func (cfg *alogger) errorContextSize() int {
+	if nil == cfg.errorContext {
+		return 0
+	}
+	return cfg.errorContext.size
+}
+
+// EnableErrorContext - Keep the most recent bufferSize entries below INFO
+// (TRACE and the DEBUG levels) that were suppressed on each goroutine. When an
+// ERROR or FATAL entry is logged on the same goroutine, the buffered entries
+// are passed through the hooks and written ahead of it with their original
+// timestamps, giving the context that led up to the error without paying for
+// verbose output the rest of the time. A bufferSize of zero or less disables
+// it.
+//
+// NOTE: Buffers are kept until an error is logged on the goroutine, so a
+//  goroutine that exits without an error leaves its last bufferSize entries
+//  behind until the next call to EnableErrorContext or DisableErrorContext.
+////
+func EnableErrorContext(bufferSize int) {
+	defaultLogger.EnableErrorContext(bufferSize)
+}
+
+// DisableErrorContext - Stop buffering suppressed entries and drop any that
+// are buffered
+func DisableErrorContext() {
+	EnableErrorContext(0)
+}
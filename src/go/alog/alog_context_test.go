@@ -0,0 +1,233 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Error Context ///////////////////////////////////////////////////////
+
+////
+// EnableErrorContext - Replay suppressed entries ahead of an error
+// 1) Log DEBUG lines at INFO with error context enabled
+//  -> Nothing written for the DEBUG lines
+// 2) Log an ERROR
+//  -> The buffered DEBUG lines are written before the error, oldest first,
+//     with messages rendered at the time of the DEBUG call
+// 3) Log another ERROR
+//  -> Only the error since the buffer was drained
+////
+func Test_AlogContext_Replay(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	EnableErrorContext(10)
+	defer ResetDefaults()
+
+	// Suppressed
+	vals := []int{1}
+	Log("TEST", DEBUG, "value %v", vals)
+	vals[0] = 2
+	Log("TEST", DEBUG2, "100%% done")
+	Log("TEST", INFO, "info")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "info"},
+	}))
+
+	// Error
+	Log("TEST", ERROR, "failed")
+	Log("TEST", ERROR, "failed again")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "info"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "value [1]"},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "100% done"},
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "failed"},
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "failed again"},
+	}))
+}
+
+////
+// EnableErrorContext - Ring buffer size and goroutine isolation
+// 1) Log more DEBUG lines than the buffer holds, then an ERROR
+//  -> Only the most recent lines are replayed
+// 2) Log DEBUG lines on another goroutine, then an ERROR on this one
+//  -> The other goroutine's lines are not replayed
+// 3) Disable and log DEBUG then ERROR
+//  -> Only the error
+////
+func Test_AlogContext_SizeAndGoroutines(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	EnableErrorContext(2)
+	defer ResetDefaults()
+	assert.Equal(t, 2, PrintConfigMap()["error_context"])
+
+	// Overflow
+	Log("TEST", DEBUG, "one")
+	Log("TEST", DEBUG, "two")
+	Log("TEST", TRACE, "three")
+	Log("TEST", ERROR, "boom")
+	formats := func() []string {
+		out := []string{}
+		for _, e := range w.Entries() {
+			out = append(out, e.Format)
+		}
+		w.Reset()
+		return out
+	}
+	assert.Equal(t, []string{"two", "three", "boom"}, formats())
+
+	// Other goroutine
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		Log("TEST", DEBUG, "elsewhere")
+	}()
+	wg.Wait()
+	Log("TEST", ERROR, "boom")
+	assert.Equal(t, []string{"boom"}, formats())
+
+	// Disabled
+	DisableErrorContext()
+	assert.Equal(t, 0, PrintConfigMap()["error_context"])
+	Log("TEST", DEBUG, "dropped")
+	Log("TEST", ERROR, "boom")
+	assert.Equal(t, []string{"boom"}, formats())
+}
+
+////
+// EnableErrorContext - Hooks run on replayed entries
+// 1) Add a hook that scrubs passwords and drops lines marked as noise
+// 2) Log DEBUG lines with a password and a noise marker, then an ERROR
+//  -> The password is scrubbed in the replayed line
+//  -> The dropped line is not replayed
+////
+func Test_AlogContext_Hooks(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	EnableErrorContext(10)
+	defer ResetAll()
+	AddHook(func(e *LogEntry) bool {
+		msg := fmt.Sprintf(e.Format, e.Expansion...)
+		if strings.Contains(msg, "noise") {
+			return false
+		}
+		e.Format = strings.ReplaceAll(msg, "hunter2", "***")
+		e.Expansion = nil
+		return true
+	})
+
+	// Suppressed, then an error
+	Log("TEST", DEBUG, "password=%s", "hunter2")
+	Log("TEST", DEBUG, "noise")
+	Log("TEST", ERROR, "boom")
+	formats := []string{}
+	for _, e := range w.Entries() {
+		formats = append(formats, e.Format)
+	}
+	assert.Equal(t, []string{"password=***", "boom"}, formats)
+}
+
+////
+// EnableErrorContext - Silenced channels are not buffered
+// 1) Mute a channel and log DEBUG lines on it and on another channel, then an
+//    ERROR
+//  -> Only the other channel's line is replayed
+// 2) Allow only one channel and log DEBUG lines on it and on another channel,
+//    then an ERROR
+//  -> Only the allowed channel's lines are replayed
+////
+func Test_AlogContext_Silenced(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	EnableErrorContext(10)
+	defer ResetDefaults()
+	formats := func() []string {
+		out := []string{}
+		for _, e := range w.Entries() {
+			out = append(out, e.Format)
+		}
+		w.Reset()
+		return out
+	}
+
+	// Muted
+	MuteChannel("NOISY")
+	Log("NOISY", DEBUG, "muted")
+	Log("MAIN", DEBUG, "context")
+	Log("MAIN", ERROR, "boom")
+	assert.Equal(t, []string{"context", "boom"}, formats())
+	UnmuteChannel("NOISY")
+
+	// Allowlist
+	AllowOnlyChannels("MAIN")
+	Log("OTHER", DEBUG, "excluded")
+	Log("MAIN", DEBUG, "context")
+	Log("MAIN", ERROR, "boom")
+	assert.Equal(t, []string{"context", "boom"}, formats())
+}
+
+////
+// EnableErrorContext - Lazy format arguments
+// 1) Log a DEBUG line with a LazyFunc argument, then an ERROR
+//  -> The replayed line has the lazy value resolved
+////
+func Test_AlogContext_Lazy(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	EnableErrorContext(10)
+	defer ResetDefaults()
+
+	Log("TEST", DEBUG, "value %v", LazyFunc(func() interface{} { return 42 }))
+	Log("TEST", ERROR, "boom")
+	if entries := w.Entries(); assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, "value 42", entries[0].Format)
+	}
+}
@@ -0,0 +1,161 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//-- Dedup ---------------------------------------------------------------------
+
+// Key under which repeated messages are tracked
+type dedupKey struct {
+	channel LogChannel
+	level   LogLevel
+}
+
+// The last message seen for a channel and level, and the number of times it
+// has repeated since it was written
+type dedupState struct {
+	message string
+	count   uint64
+	timer   *time.Timer
+}
+
+// Cache of the last message per channel and level used to coalesce repeats.
+// It has its own lock since it is updated from inside the logger's read lock.
+type dedupCache struct {
+	mutex  sync.Mutex
+	cfg    *alogger
+	window time.Duration
+	states map[dedupKey]*dedupState
+	closed bool
+}
+
+// Create the summary entry for a message that repeated count times
+func dedupSummary(key dedupKey, message string, count uint64) *LogEntry {
+	return &LogEntry{
+		Channel:    key.channel,
+		Level:      key.level,
+		Format:     "%s ...repeated %d times",
+		Expansion:  []interface{}{message, count},
+		MapData:    map[string]interface{}{"repeat_count": count},
+		formatKeys: []string{"repeat_count"},
+		noDedup:    true,
+	}
+}
+
+// Check an entry against the cache. Returns whether the entry should be
+// written and, if the message on its channel and level changed while the last
+// one had repeats, the summary entry to write before it.
+//
+// NOTE: This is safe to call on a nil cache, which keeps every entry
+////
+func (d *dedupCache) check(e *LogEntry) (bool, *LogEntry) {
+	if nil == d || e.noDedup {
+		return true, nil
+	}
+	resolveLazyValues(e)
+	key := dedupKey{channel: e.Channel, level: e.Level}
+	message := fmt.Sprintf(e.Format, e.Expansion...)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var summary *LogEntry
+	if state, ok := d.states[key]; ok {
+		if state.message == message {
+			state.count++
+			return false, nil
+		}
+		state.timer.Stop()
+		if state.count > 0 {
+			summary = dedupSummary(key, state.message, state.count)
+		}
+	}
+	state := &dedupState{message: message}
+	state.timer = time.AfterFunc(d.window, func() { d.expire(key, state) })
+	d.states[key] = state
+	return true, summary
+}
+
+// Close the window for a message, logging its summary if it repeated
+func (d *dedupCache) expire(key dedupKey, state *dedupState) {
+	d.mutex.Lock()
+	if d.closed || d.states[key] != state {
+		d.mutex.Unlock()
+		return
+	}
+	delete(d.states, key)
+	d.mutex.Unlock()
+	if state.count > 0 {
+		d.cfg.log(*dedupSummary(key, state.message, state.count))
+	}
+}
+
+// Stop all timers and get the summaries of the messages that have repeated.
+// The cache keeps no entries after this.
+func (d *dedupCache) close() []*LogEntry {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.closed = true
+	summaries := []*LogEntry{}
+	for key, state := range d.states {
+		state.timer.Stop()
+		if state.count > 0 {
+			summaries = append(summaries, dedupSummary(key, state.message, state.count))
+		}
+	}
+	d.states = nil
+	return summaries
+}
+
+// EnableDedup - Coalesce repeated messages. When the same formatted message is
+// logged again on the same channel and level within window of its first
+// occurrence, it is not written. Instead, once the window closes, or as soon
+// as a different message is logged on that channel and level, a single
+// "<message> ...repeated N times" entry is written with the count as
+// repeat_count in the map data. Calling this again replaces the window and
+// flushes any pending summaries. Dedup is off by default.
+func EnableDedup(window time.Duration) {
+	defaultLogger.EnableDedup(window)
+}
+
+// DisableDedup - Stop coalescing repeated messages, writing the summaries of
+// any messages that have repeated in their current window
+func DisableDedup() {
+	defaultLogger.DisableDedup()
+}
+
+// Close a dedup cache and log its pending summaries
+func flushDedup(d *dedupCache) {
+	if nil == d {
+		return
+	}
+	for _, summary := range d.close() {
+		d.cfg.log(*summary)
+	}
+}
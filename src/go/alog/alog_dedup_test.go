@@ -0,0 +1,134 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Dedup ///////////////////////////////////////////////////////////////
+
+////
+// Window - Test coalescing a spammed message
+//
+// 1) Enable dedup and log the same error many times
+//  -> Only the first is written
+// 2) Wait for the window to close
+//  -> A single summary with the repeat count
+// 3) Log the message again
+//  -> Written immediately since the window closed
+////
+func Test_AlogDedup_Window(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	EnableDedup(50 * time.Millisecond)
+
+	for i := 0; i < 1000; i++ {
+		Log("DEPS", ERROR, "Connection to %s refused", "db")
+	}
+	assert.Len(t, w.Lines(), 1)
+	assert.Eventually(t, func() bool { return len(w.Lines()) == 2 }, time.Second, time.Millisecond)
+	entries := w.Entries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "Connection to db refused", entries[0].Format)
+		assert.Equal(t, "Connection to db refused ...repeated 999 times", entries[1].Format)
+		assert.Equal(t, ERROR, entries[1].Level)
+		assert.Equal(t, LogChannel("DEPS"), entries[1].Channel)
+		assert.Equal(t, "999", fmt.Sprint(entries[1].MapData["repeat_count"]))
+	}
+
+	// Again after the window
+	Log("DEPS", ERROR, "Connection to %s refused", "db")
+	assert.Len(t, w.Lines(), 3)
+}
+
+////
+// MessageChange - Test the summary written when the message changes
+//
+// 1) Log a message three times, then a different one on the same channel and
+//    level, then one on another level
+//  -> First message, its summary, then the new messages
+// 2) Disable dedup
+//  -> Nothing pending written for messages without repeats
+//  -> Repeats written again
+////
+func Test_AlogDedup_MessageChange(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	EnableDedup(time.Hour)
+
+	Log("TEST", WARNING, "flap")
+	Log("TEST", WARNING, "flap")
+	Log("TEST", WARNING, "flap")
+	Log("TEST", WARNING, "steady")
+	Log("TEST", INFO, "steady")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "WARN", body: "flap"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "flap ...repeated 2 times"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "steady"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "steady"},
+	}))
+
+	// Disable
+	entries = entries[:0]
+	DisableDedup()
+	assert.Empty(t, entries)
+	Log("TEST", WARNING, "steady")
+	Log("TEST", WARNING, "steady")
+	assert.Len(t, entries, 2)
+}
+
+////
+// Disable - Test flushing pending summaries
+//
+// 1) Log a message twice with a long window and disable dedup
+//  -> Summary written immediately
+////
+func Test_AlogDedup_Disable(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	EnableDedup(time.Hour)
+
+	Log("TEST", ERROR, "oops")
+	Log("TEST", ERROR, "oops")
+	DisableDedup()
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "oops"},
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "oops ...repeated 1 times"},
+	}))
+}
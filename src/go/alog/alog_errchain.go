@@ -0,0 +1,79 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"errors"
+)
+
+//-- Error Chains --------------------------------------------------------------
+
+// Map data key holding the messages of the wrapped errors in an entry
+const errorChainKey = "error_chain"
+
+// Get the messages of each error in the arguments followed by the errors it
+// wraps, outermost first
+func errorChain(v []interface{}) []string {
+	var chain []string
+	for _, arg := range v {
+		for err, ok := arg.(error); ok && nil != err; err = errors.Unwrap(err) {
+			chain = append(chain, err.Error())
+		}
+	}
+	return chain
+}
+
+// Add the error chain of the entry's format arguments to its map data. The map
+// is copied so that the caller's map is not modified, and an error_chain key
+// set by the caller is kept.
+func addErrorChain(e *LogEntry) {
+	chain := errorChain(e.Expansion)
+	if len(chain) == 0 {
+		return
+	}
+	if _, ok := e.MapData[errorChainKey]; ok {
+		return
+	}
+	mapData := make(map[string]interface{}, len(e.MapData)+1)
+	for k, v := range e.MapData {
+		mapData[k] = v
+	}
+	mapData[errorChainKey] = chain
+	e.MapData = mapData
+}
+
+// EnableErrorChainCapture - Enable capturing the chain of wrapped errors for
+// every error passed as a format argument. The messages of the error and each
+// error it wraps (see errors.Unwrap) are added to the map data of the entry as
+// error_chain, outermost first. This is only done for entries that are
+// written.
+func EnableErrorChainCapture() {
+	defaultLogger.EnableErrorChainCapture()
+}
+
+// DisableErrorChainCapture - Disable capturing the chain of wrapped errors
+func DisableErrorChainCapture() {
+	defaultLogger.DisableErrorChainCapture()
+}
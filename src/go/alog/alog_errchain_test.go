@@ -0,0 +1,131 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Error Chains ////////////////////////////////////////////////////////
+
+////
+// ErrorChain - Capture the chain of wrapped errors in format arguments
+// 1) Log a nested wrapped error without capture enabled
+//  -> No error_chain
+// 2) Enable capture and log the same error with JSON
+//  -> error_chain lists the messages outermost first
+// 3) Log two errors, a plain error and no errors
+//  -> Chains are concatenated in argument order, no entry without errors
+// 4) Log with an error_chain key in the map data
+//  -> The caller's value is kept and the caller's map is not modified
+// 5) Log with the Std formatter
+//  -> The chain is rendered as a map data line
+////
+func Test_AlogErrorChain_Capture(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	root := errors.New("connection refused")
+	mid := fmt.Errorf("dial db: %w", root)
+	top := fmt.Errorf("load user 42: %w", mid)
+
+	// Disabled
+	Log("TEST", INFO, "Failed: %v", top)
+	if entries := w.Entries(); assert.Len(t, entries, 1) {
+		assert.NotContains(t, entries[0].MapData, "error_chain")
+	}
+
+	// Nested chain
+	w.Reset()
+	EnableErrorChainCapture()
+	Log("TEST", INFO, "Failed: %v", top)
+	if entries := w.Entries(); assert.Len(t, entries, 1) {
+		assert.Equal(t, []interface{}{
+			"load user 42: dial db: connection refused",
+			"dial db: connection refused",
+			"connection refused",
+		}, entries[0].MapData["error_chain"])
+		assert.Equal(t, "Failed: load user 42: dial db: connection refused", entries[0].Format)
+	}
+
+	// Several errors, a plain error and a nil error
+	w.Reset()
+	var nilErr error
+	Log("TEST", INFO, "%v %d %v %v", mid, 3, errors.New("plain"), nilErr)
+	Log("TEST", INFO, "No errors %d", 1)
+	if entries := w.Entries(); assert.Len(t, entries, 2) {
+		assert.Equal(t, []interface{}{
+			"dial db: connection refused",
+			"connection refused",
+			"plain",
+		}, entries[0].MapData["error_chain"])
+		assert.Nil(t, entries[1].MapData)
+	}
+
+	// Existing key
+	w.Reset()
+	mapData := map[string]interface{}{"error_chain": "custom", "user": 42}
+	LogWithMap("TEST", INFO, mapData, "Failed: %v", top)
+	ch := UseChannel("TEST").WithFields(map[string]interface{}{"user": 7})
+	ch.Log(INFO, "Failed: %v", mid)
+	if entries := w.Entries(); assert.Len(t, entries, 2) {
+		assert.Equal(t, "custom", entries[0].MapData["error_chain"])
+		assert.Equal(t, []interface{}{
+			"dial db: connection refused",
+			"connection refused",
+		}, entries[1].MapData["error_chain"])
+		assert.Equal(t, "7", fmt.Sprint(entries[1].MapData["user"]))
+	}
+	assert.Equal(t, map[string]interface{}{"error_chain": "custom", "user": 42}, mapData)
+
+	// Std formatter
+	w.Reset()
+	UseStdLogFormatter()
+	Log("TEST", INFO, "Failed: %v", mid)
+	lines := w.Lines()
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasSuffix(lines[0], "Failed: dial db: connection refused\n"))
+		assert.Contains(t, lines[1], "error_chain: ")
+		assert.Contains(t, lines[1], "connection refused")
+	}
+
+	// Disable
+	w.Reset()
+	DisableErrorChainCapture()
+	Log("TEST", INFO, "Failed: %v", mid)
+	assert.Len(t, w.Lines(), 1)
+}
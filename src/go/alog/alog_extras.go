@@ -25,13 +25,17 @@
 package alog
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,9 +44,12 @@ import (
 
 //-- General Helpers -----------------------------------------------------------
 
-// LevelFromString - Parse an alog LogLevel from a string representation
+// LevelFromString - Parse an alog LogLevel from a string representation. The
+// match is case-insensitive and also accepts the 4-character header form of
+// each level (e.g. "WARN" or "DBG2"). Invalid input is reported only through
+// the returned error.
 func LevelFromString(s string) (LogLevel, error) {
-	switch s {
+	switch strings.ToLower(s) {
 	case "off":
 		return OFF, nil
 	case "fatal":
@@ -66,37 +73,111 @@ func LevelFromString(s string) (LogLevel, error) {
 	case "debug4":
 		return DEBUG4, nil
 	default:
-		msg := fmt.Sprintf("Invalid log level [%s]", s)
-		Log("MAIN", WARNING, msg)
-		return ERROR, errors.New(msg)
+		for lvl := FATAL; lvl <= DEBUG4; lvl++ {
+			if strings.EqualFold(s, levelToHeaderString(lvl)) {
+				return lvl, nil
+			}
+		}
+		return ERROR, fmt.Errorf("Invalid log level [%s]", s)
+	}
+}
+
+// ConfigChannelStr - Parse a level string with LevelFromString and set it for
+// a specific channel. If the string is not a valid level, the error is
+// returned and the configuration is unchanged.
+func ConfigChannelStr(channel LogChannel, levelStr string) error {
+	lvl, err := LevelFromString(levelStr)
+	if nil != err {
+		return err
+	}
+	ConfigChannel(channel, lvl)
+	return nil
+}
+
+// ConfigDefaultLevelStr - Parse a level string with LevelFromString and set it
+// as the default level. If the string is not a valid level, the error is
+// returned and the configuration is unchanged.
+func ConfigDefaultLevelStr(levelStr string) error {
+	lvl, err := LevelFromString(levelStr)
+	if nil != err {
+		return err
 	}
+	ConfigDefaultLevel(lvl)
+	return nil
 }
 
-// ParseChannelFilter - Parse a per-channel filter map from a string
+// ParseChannelFilter - Parse a per-channel filter map from a string. Exact
+// entries (see ParseChannelFilterExact) are accepted, but only their level is
+// returned.
 func ParseChannelFilter(s string) (ChannelMap, error) {
+	cmap, _, err := ParseChannelFilterExact(s)
+	return cmap, err
+}
+
+// ParseChannelFilterExact - Parse a per-channel filter map from a string along
+// with the set of channels that enable only their exact level. An entry of the
+// form "CH:warning" enables warning and everything more severe on CH, while
+// "CH:=warning" enables only warning. Nothing is logged for a bad entry; the
+// last one found is returned as the error.
+func ParseChannelFilterExact(s string) (ChannelMap, map[LogChannel]bool, error) {
 	cmap := ChannelMap{}
+	exact := map[LogChannel]bool{}
 	var errOut error
 	for _, entry := range strings.Split(s, ",") {
 		if len(entry) > 0 {
 			parts := strings.Split(entry, ":")
 			if len(parts) != 2 {
 				errOut = fmt.Errorf("Bad channel config found [%s]", entry)
-				Log("MAIN", ERROR, errOut.Error())
 			} else {
 				k := LogChannel(string(parts[0]))
-				if v, err := LevelFromString(string(parts[1])); nil != err {
+				lvlStr := parts[1]
+				isExact := strings.HasPrefix(lvlStr, "=")
+				if isExact {
+					lvlStr = lvlStr[1:]
+				}
+				if v, err := LevelFromString(lvlStr); nil != err {
 					errOut = fmt.Errorf("Bad level specified: %s", parts[1])
-					Log("MAIN", ERROR, errOut.Error())
 				} else {
 					cmap[k] = v
+					if isExact {
+						exact[k] = true
+					}
 				}
 			}
 		}
 	}
 	if nil != errOut {
-		return ChannelMap{}, errOut
+		return ChannelMap{}, map[LogChannel]bool{}, errOut
 	}
-	return cmap, nil
+	return cmap, exact, nil
+}
+
+// Apply a default level and channel map, marking the exact channels
+func configExact(defaultLevel LogLevel, cmap ChannelMap, exact map[LogChannel]bool) {
+	Config(defaultLevel, cmap)
+	for ch := range exact {
+		ConfigChannelExact(ch, cmap[ch])
+	}
+}
+
+// Regex matching the error verbs that fmt inserts for bad format strings, e.g.
+// "%!d(string=hi)", "%!s(MISSING)", "%!(EXTRA int=1)" or "%!(NOVERB)"
+var formatErrorRegex = regexp.MustCompile(`%!([a-zA-Z]?\([^)]*\)|[a-zA-Z]\(MISSING\))`)
+
+// CheckFormat - Check that a format string and its arguments format cleanly.
+// The format is run through fmt.Sprintf and an error listing each of the error
+// verbs fmt produced (wrong type, missing or extra arguments, ...) is returned
+// if there were any.
+//
+// NOTE: Since this inspects the formatted output, an argument whose own text
+//  contains an fmt error verb is also reported.
+////
+func CheckFormat(format string, v ...interface{}) error {
+	if problems := formatErrorRegex.FindAllString(fmt.Sprintf(format, v...), -1); len(problems) > 0 {
+		return fmt.Errorf("Bad format string [%s] for %d args: %s",
+			format, len(v), strings.Join(problems, ", "))
+	}
+	return nil
 }
 
 //-- Command Line Helpers ------------------------------------------------------
@@ -113,45 +194,52 @@ type FlagSet struct {
 	OutputJSON       *bool
 }
 
-// GetFlags - Get the configured set of command line flags for alog
+// GetFlags - Get the configured set of command line flags for alog, registered
+// on flag.CommandLine
 func GetFlags() FlagSet {
+	return GetFlagsFor(flag.CommandLine)
+}
+
+// GetFlagsFor - Get the configured set of command line flags for alog,
+// registered on the given flag.FlagSet rather than the global one
+func GetFlagsFor(fs *flag.FlagSet) FlagSet {
 	return FlagSet{
-		DefaultLevel: flag.String(
+		DefaultLevel: fs.String(
 			"log.default-level",
 			"info",
 			"Default log level"),
 
-		ChannelConfig: flag.String(
+		ChannelConfig: fs.String(
 			"log.filters",
 			"",
 			"Per-channel log level configuration"),
 
-		ChannelHeaderLen: flag.Int(
+		ChannelHeaderLen: fs.Int(
 			"log.chan-header-len",
 			5,
 			"Maximum length for log channel strings in the header"),
 
-		EnableGID: flag.Bool(
+		EnableGID: fs.Bool(
 			"log.goroutine-id",
 			false,
 			"Log the numerica ID of the goroutine in the header"),
 
-		EnableFuncSig: flag.Bool(
+		EnableFuncSig: fs.Bool(
 			"log.function-signature",
 			false,
 			"Log the full function signature for trace logging"),
 
-		DisableIndent: flag.Bool(
+		DisableIndent: fs.Bool(
 			"log.no-indent",
 			false,
 			"Disable indentation"),
 
-		ServiceName: flag.String(
+		ServiceName: fs.String(
 			"log.service-name",
 			"",
 			"Set a service name to display with each log line"),
 
-		OutputJSON: flag.Bool(
+		OutputJSON: fs.Bool(
 			"log.output-json",
 			false,
 			"Output log lines as structured JSON rather than plain text"),
@@ -160,6 +248,7 @@ func GetFlags() FlagSet {
 
 // ConfigureFromFlags - Configure the global alog setup from a FlagSet
 func ConfigureFromFlags(aFlags FlagSet) error {
+	before := PrintConfigMap()
 	ResetDefaults()
 	var errOut error
 
@@ -174,10 +263,12 @@ func ConfigureFromFlags(aFlags FlagSet) error {
 
 	// Parse channel filters
 	cmap := ChannelMap{}
-	if cm, err := ParseChannelFilter(*(aFlags.ChannelConfig)); nil != err {
+	exact := map[LogChannel]bool{}
+	if cm, ex, err := ParseChannelFilterExact(*(aFlags.ChannelConfig)); nil != err {
 		errOut = fmt.Errorf("Unable to parse channel map: %v", err)
 	} else {
 		cmap = cm
+		exact = ex
 	}
 
 	// Short-circuit if error parsing
@@ -186,7 +277,7 @@ func ConfigureFromFlags(aFlags FlagSet) error {
 	}
 
 	// Configure level and channels
-	Config(dfltLvl, cmap)
+	configExact(dfltLvl, cmap, exact)
 
 	// Max channel length
 	SetMaxChannelLen(*(aFlags.ChannelHeaderLen))
@@ -222,16 +313,122 @@ func ConfigureFromFlags(aFlags FlagSet) error {
 		UseStdLogFormatter()
 	}
 
+	logConfigChange("flags", before)
 	Log("MAIN", INFO, "Logging Configured!")
 	return errOut
 }
 
+//-- Config Change Events ------------------------------------------------------
+
+// Log a config_change event on the ALOG channel attributing the difference
+// between the given configuration (as given by PrintConfigMap) and the current
+// configuration to the source. Nothing is logged if nothing changed.
+func logConfigChange(source string, before map[string]interface{}) {
+	changes := map[string]interface{}{}
+	for k, v := range PrintConfigMap() {
+		if old := before[k]; !reflect.DeepEqual(old, v) {
+			changes[k] = map[string]interface{}{"from": old, "to": v}
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	LogWithMap("ALOG", INFO, map[string]interface{}{
+		"event":   "config_change",
+		"source":  source,
+		"changes": changes,
+	}, "Configuration changed by %s", source)
+}
+
 //-- Dynamic Server Logging ----------------------------------------------------
 
+// A single temporary override layered on top of the base configuration
+type dynamicOverride struct {
+	id           uint64
+	defaultLevel *LogLevel
+	channelMap   ChannelMap
+	exact        map[LogChannel]bool
+	timer        *time.Timer
+}
+
 // Struct to act as the global singleton for managing simultaneous dynamic logs
+//
+// Temporary configurations are kept as a stack of overrides on top of the base
+// configuration that was active when the first override was applied. The
+// effective configuration is computed by applying each override in the order
+// it was added, so a later override wins for any channel (or default level) it
+// sets. When an override times out, it is removed and the effective
+// configuration is recomputed from the base and the remaining overrides.
+////
 type dynamicLogLock struct {
-	mutex       sync.Mutex
-	timerActive bool
+	mutex     sync.Mutex
+	nextID    uint64
+	overrides []*dynamicOverride
+
+	// The configuration that the overrides are applied on top of
+	baseLevel      LogLevel
+	baseChannelMap ChannelMap
+	baseExact      map[LogChannel]bool
+}
+
+// Recompute the effective configuration from the base and active overrides
+//
+// NOTE: Must be called with the mutex held
+////
+func (l *dynamicLogLock) apply(ch ChannelLog, source string) {
+	level := l.baseLevel
+	cMap := ChannelMap{}
+	exact := map[LogChannel]bool{}
+	for chnl, lvl := range l.baseChannelMap {
+		cMap[chnl] = lvl
+		exact[chnl] = l.baseExact[chnl]
+	}
+	for _, o := range l.overrides {
+		if nil != o.defaultLevel {
+			level = *o.defaultLevel
+		}
+		for chnl, lvl := range o.channelMap {
+			cMap[chnl] = lvl
+			exact[chnl] = o.exact[chnl]
+		}
+	}
+	for chnl, isExact := range exact {
+		if !isExact {
+			delete(exact, chnl)
+		}
+	}
+	ch.Log(INFO, "Before adjustment:\n%s", PrintConfig())
+	before := PrintConfigMap()
+	configExact(level, cMap, exact)
+	ch.Log(INFO, "After adjustment:\n%s", PrintConfig())
+	logConfigChange(source, before)
+}
+
+// Remove the override with the given id if it is still active
+func (l *dynamicLogLock) expire(ch ChannelLog, id uint64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i, o := range l.overrides {
+		if o.id == id {
+			ch.Log(INFO, "Resetting logging after timed adjust")
+			l.overrides = append(l.overrides[:i], l.overrides[i+1:]...)
+			l.apply(ch, "dynamic_timeout")
+			return
+		}
+	}
+}
+
+// Stop and discard all overrides without applying any configuration
+func (l *dynamicLogLock) clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, o := range l.overrides {
+		o.timer.Stop()
+	}
+	l.overrides = nil
+	l.baseLevel = OFF
+	l.baseChannelMap = nil
+	l.baseExact = nil
 }
 
 // Global singleton instance of the dynamicLogLock
@@ -239,18 +436,36 @@ var stdDynamicLogLock = &dynamicLogLock{}
 
 // DynamicLogConfig - Configuration object for dynamic logging
 type DynamicLogConfig struct {
-	DefaultLevel string
-	Filters      string
-	Timeout      uint32
+	DefaultLevel string `json:"default_level"`
+	Filters      string `json:"filters"`
+	Timeout      uint32 `json:"timeout"`
 }
 
 // ConfigureDynamicLogging - Set up global logging for runtime-dynamic logging
 //
-// NOTE: Errors from this function may be the result of bad user input, or may
-//  be caused by attempting to call it when another temporary configuration is
-//  active. To determine the type of the error, look for the string 'USER:' at
-//  the beginning of the log message.
+// If a Timeout is given, the configuration is applied as a temporary override
+// on top of the current configuration and removed once the timeout expires.
+// Multiple temporary overrides may be active at once, each with its own
+// timeout. The overrides are applied in the order they were added, so a later
+// override wins for any channel or default level that it sets. Channels and
+// default levels that an override does not set are inherited from the
+// configuration beneath it.
+//
+// If no Timeout is given, the configuration replaces the base configuration.
+// Any active temporary overrides remain layered on top of it until they expire.
+//
+// Each change is logged as a config_change event with the source "dynamic".
+//
+// NOTE: Errors from this function are the result of bad user input and begin
+//  with the string 'USER:'.
+////
 func ConfigureDynamicLogging(c DynamicLogConfig) error {
+	return configureDynamicLogging(c, "dynamic")
+}
+
+// Implementation of ConfigureDynamicLogging that attributes the change to the
+// given source
+func configureDynamicLogging(c DynamicLogConfig, source string) error {
 	ch := UseChannel("DYLOG")
 	defer ch.FnLog("").Close()
 
@@ -258,14 +473,10 @@ func ConfigureDynamicLogging(c DynamicLogConfig) error {
 	stdDynamicLogLock.mutex.Lock()
 	defer stdDynamicLogLock.mutex.Unlock()
 
-	// If a timer is currently active, we can't reconfigure right now
-	if stdDynamicLogLock.timerActive {
-		return errors.New("Cannot perform multiple temporary dynamic logs at once")
-	}
-
 	// Parse params
-	level := GetDefaultLevel()
+	var level *LogLevel
 	cMap := ChannelMap{}
+	exact := map[LogChannel]bool{}
 	var timeout *time.Duration
 	{
 		if len(c.DefaultLevel) > 0 {
@@ -275,10 +486,10 @@ func ConfigureDynamicLogging(c DynamicLogConfig) error {
 				ch.Log(WARNING, errOut.Error())
 				return errOut
 			}
-			level = lvl
+			level = &lvl
 		}
 		if len(c.Filters) > 0 {
-			cm, err := ParseChannelFilter(c.Filters)
+			cm, ex, err := ParseChannelFilterExact(c.Filters)
 			if nil != err {
 				errOut := fmt.Errorf("USER: Failed to parse channel map: %v", err)
 				ch.Log(WARNING, errOut.Error())
@@ -287,6 +498,7 @@ func ConfigureDynamicLogging(c DynamicLogConfig) error {
 			for chnl, lvl := range cm {
 				cMap[chnl] = lvl
 			}
+			exact = ex
 		}
 		if c.Timeout > 0 {
 			secs := time.Duration(c.Timeout) * time.Second
@@ -294,35 +506,69 @@ func ConfigureDynamicLogging(c DynamicLogConfig) error {
 		}
 	}
 
-	// Make the adjustment
-	currentLevel := GetDefaultLevel()
-	currentCMap := GetChannelMap()
-	ch.Log(INFO, "Before adjustment:\n%s", PrintConfig())
-	Config(level, cMap)
-	ch.Log(INFO, "After adjustment:\n%s", PrintConfig())
+	// With no active overrides, the current configuration is the base
+	if len(stdDynamicLogLock.overrides) == 0 {
+		stdDynamicLogLock.baseLevel = GetDefaultLevel()
+		stdDynamicLogLock.baseChannelMap = ChannelMap{}
+		for chnl, lvl := range GetChannelMap() {
+			stdDynamicLogLock.baseChannelMap[chnl] = lvl
+		}
+		stdDynamicLogLock.baseExact = GetExactChannels()
+	}
 
-	// If timeout given, set up the timeout function
-	if nil != timeout {
-		ch.Log(INFO, "Setting up adjustment to time out in %v", *timeout)
-		stdDynamicLogLock.timerActive = true
-		go func(lvl LogLevel, cm ChannelMap, dt time.Duration) {
-			// Sleep for the desired amount of time
-			time.Sleep(dt)
+	// Without a timeout, replace the base configuration
+	if nil == timeout {
+		if nil != level {
+			stdDynamicLogLock.baseLevel = *level
+		}
+		stdDynamicLogLock.baseChannelMap = cMap
+		stdDynamicLogLock.baseExact = exact
+		stdDynamicLogLock.apply(ch, source)
+		return nil
+	}
 
-			// Reconfigure back to previous configuration
-			ch.Log(INFO, "Resetting logging after timed adjust")
-			ch.Log(INFO, "Before adjustment:\n%s", PrintConfig())
-			Config(lvl, cm)
-			ch.Log(INFO, "After adjustment:\n%s", PrintConfig())
+	// Otherwise, push a new override and set up its timeout
+	ch.Log(INFO, "Setting up adjustment to time out in %v", *timeout)
+	stdDynamicLogLock.nextID++
+	id := stdDynamicLogLock.nextID
+	stdDynamicLogLock.overrides = append(stdDynamicLogLock.overrides, &dynamicOverride{
+		id:           id,
+		defaultLevel: level,
+		channelMap:   cMap,
+		exact:        exact,
+		timer: time.AfterFunc(*timeout, func() {
+			stdDynamicLogLock.expire(ch, id)
+		}),
+	})
+	stdDynamicLogLock.apply(ch, source)
+	return nil
+}
 
-			// Unblock future requests
-			stdDynamicLogLock.mutex.Lock()
-			defer stdDynamicLogLock.mutex.Unlock()
-			stdDynamicLogLock.timerActive = false
+// CancelDynamicLogging - Immediately remove all active temporary overrides
+// made with ConfigureDynamicLogging rather than waiting for their timeouts,
+// reverting to the base configuration. An error is returned if no temporary
+// configuration is active.
+func CancelDynamicLogging() error {
+	return cancelDynamicLogging("dynamic")
+}
 
-		}(currentLevel, currentCMap, *timeout)
-	}
+// Implementation of CancelDynamicLogging that attributes the change to the
+// given source
+func cancelDynamicLogging(source string) error {
+	ch := UseChannel("DYLOG")
+	defer ch.FnLog("").Close()
 
+	stdDynamicLogLock.mutex.Lock()
+	defer stdDynamicLogLock.mutex.Unlock()
+	if len(stdDynamicLogLock.overrides) == 0 {
+		return errors.New("No temporary dynamic log configuration is active")
+	}
+	ch.Log(INFO, "Cancelling timed adjust")
+	for _, o := range stdDynamicLogLock.overrides {
+		o.timer.Stop()
+	}
+	stdDynamicLogLock.overrides = nil
+	stdDynamicLogLock.apply(ch, source)
 	return nil
 }
 
@@ -332,21 +578,71 @@ func ConfigureDynamicLogging(c DynamicLogConfig) error {
 // This handler supports the following query params:
 //
 // * default_level=xxx - Set the default log level
-// * filters=AAA:bbb,CCC:ddd - Set the per-channel log level filters
+// * filters=AAA:bbb,CCC:ddd - Set the per-channel log level filters. Use
+//    AAA:=bbb to enable only level bbb on AAA
 // * timeout=X - Set a time at which the dynamic configuration should revert to
 //    the current configuration
+// * show=true - Report the current configuration without modifying it
+// * format=json - With show, report the full configuration as JSON
+// * cancel=true - Revert an active temporary configuration immediately
+//
+// If no params are given, the current configuration is reported in the
+// response body (as with show=true).
+//
+// POST and PATCH requests with a Content-Type of application/json may instead
+// send a JSON body matching DynamicLogConfig (e.g. {"default_level": "info",
+// "filters": "AAA:debug", "timeout": 30}).
+//
+// Responds with 400 for malformed input and 409 when asked to cancel while no
+// temporary dynamic configuration is active. Changes are logged as
+// config_change events with the source "dynamic_http".
 ////
 func DynamicHandler(w http.ResponseWriter, r *http.Request) {
 	ch := UseChannel("DYLOG")
 	defer ch.FnLog("").Close()
 
-	// Parse params
-	r.ParseForm()
-
-	// Parse params
 	config := DynamicLogConfig{}
-	{
+	if isJSONRequest(r) {
+
+		// Parse JSON body
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); nil != err {
+			ch.Log(DEBUG, "Got malformed JSON config: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Malformed JSON config: %v\n", err)))
+			return
+		}
+	} else {
+
+		// Parse params
 		r.ParseForm()
+
+		// If no params or show requested, report the current config
+		jsonFormat := r.Form.Get("format") == "json"
+		if len(r.Form) == 0 || r.Form.Get("show") == "true" || (jsonFormat && len(r.Form) == 1) {
+			if jsonFormat {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(PrintConfigJSON() + "\n"))
+			} else {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(PrintConfig() + "\n"))
+			}
+			return
+		}
+
+		// If cancel requested, revert the active temporary config
+		if r.Form.Get("cancel") == "true" {
+			if err := cancelDynamicLogging("dynamic_http"); nil != err {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(err.Error() + "\n"))
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+			return
+		}
+
 		for param, vals := range r.Form {
 			if len(vals) > 0 {
 				switch param {
@@ -364,19 +660,33 @@ func DynamicHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Do the dynamic configuration
-	if err := ConfigureDynamicLogging(config); nil != err {
+	if err := configureDynamicLogging(config, "dynamic_http"); nil != err {
 		ch.Log(DEBUG, "Got error while trying to configure dynamic loging: %v", err)
-		w.WriteHeader(http.StatusConflict)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error() + "\n"))
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
 	return
 }
 
+// Determine whether a request to the DynamicHandler carries a JSON body
+func isJSONRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return nil == err && mediaType == "application/json"
+}
+
 //-- JSON to plain text --------------------------------------------------------
 
 // JSONToLogEntry - Convert a structured JSON log line to its corresponding
-// LogEntry object
+// LogEntry object. If a JSON field namespace is set, the map data nested under
+// it is parsed back into MapData. The numeric level is used if present,
+// otherwise the level is parsed from level_str. A non-numeric "level" value,
+// e.g. from a line written before the numeric level was added, is kept in
+// MapData.
 func JSONToLogEntry(jsString string) (*LogEntry, error) {
 
 	// Unmarshal to a generic map, using the Number type to decode numbers
@@ -388,15 +698,26 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 	}
 
 	// Check required entries
-	for _, k := range []string{"channel", "level_str", "timestamp", "num_indent"} {
+	for _, k := range []string{"channel", "timestamp", "num_indent"} {
 		if _, ok := entryMap[k]; !ok {
 			return nil, fmt.Errorf("Missing required field '%s'", k)
 		}
 	}
+	_, hasLevel := entryMap["level"].(json.Number)
+	if _, ok := entryMap["level_str"]; !ok && !hasLevel {
+		return nil, fmt.Errorf("Missing required field '%s'", "level_str")
+	}
 
 	// Create a log entry and fill it
 	le := LogEntry{}
+	ns := GetJSONFieldNamespace()
 	var outErr error
+	addMapData := func(k string, v interface{}) {
+		if nil == le.MapData {
+			le.MapData = map[string]interface{}{}
+		}
+		le.MapData[k] = v
+	}
 	for k, v := range entryMap {
 
 		switch k {
@@ -408,9 +729,22 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 			} else {
 				le.Channel = LogChannel(strVal)
 			}
+		case "level":
+
+			// numeric level, or map data if not a number
+			if numVal, ok := v.(json.Number); !ok {
+				addMapData(k, v)
+			} else if intVal, err := numVal.Int64(); nil != err || intVal < int64(OFF) || intVal > int64(DEBUG4) {
+				outErr = fmt.Errorf("Bad level found: %s", numVal.String())
+			} else {
+				le.Level = LogLevel(intVal)
+			}
 		case "level_str":
 
-			// level
+			// level, unless the numeric level is present
+			if hasLevel {
+				break
+			}
 			if strVal, ok := v.(string); !ok {
 				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
 			} else if lvl, err := LevelFromString(strVal); nil != err {
@@ -432,6 +766,8 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 			if strVal, ok := v.(string); !ok {
 				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
 				outErr = fmt.Errorf("Bad type for '%s'", k)
+			} else if ts, err := time.Parse("2006/01/02 15:04:05 "+jsonOffsetLayout, strVal); nil == err {
+				le.Timestamp = ts
 			} else if ts, err := time.Parse("2006/01/02 15:04:05", strVal); nil != err {
 			} else {
 				le.Timestamp = ts
@@ -454,6 +790,85 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 			} else {
 				le.Servicename = strVal
 			}
+		case "labels":
+
+			// labels
+			if mapVal, ok := v.(map[string]interface{}); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.Labels = map[string]string{}
+				for lk, lv := range mapVal {
+					le.Labels[lk] = fmt.Sprintf("%v", lv)
+				}
+			}
+		case "component":
+
+			// component
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.Component = strVal
+			}
+		case "scope_id":
+
+			// scope_id
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.ScopeID = strVal
+			}
+		case "request_id":
+
+			// request_id
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.RequestID = strVal
+			}
+		case "sequence":
+
+			// sequence
+			if numVal, ok := v.(json.Number); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else if intVal, err := strconv.ParseUint(numVal.String(), 10, 64); nil != err {
+				outErr = fmt.Errorf("Wrong number type for '%s' - %s", k, numVal.String())
+			} else {
+				le.Sequence = intVal
+			}
+		case "trace_id":
+
+			// trace_id
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.TraceID = strVal
+			}
+		case "span_id":
+
+			// span_id
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.SpanID = strVal
+			}
+		case "subsystem":
+
+			// subsystem
+			if strVal, ok := v.(string); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else {
+				le.Subsystem = strVal
+			}
+		case "scope_seq":
+
+			// scope_seq
+			if numVal, ok := v.(json.Number); !ok {
+				outErr = fmt.Errorf("Bad type for '%s' - %v", k, reflect.TypeOf(v))
+			} else if intVal, err := strconv.ParseUint(numVal.String(), 10, 64); nil != err {
+				outErr = fmt.Errorf("Wrong number type for '%s' - %s", k, numVal.String())
+			} else {
+				le.ScopeSeq = intVal
+			}
 		case "thread_id":
 
 			// Check as string (from c++ ALog)
@@ -475,11 +890,14 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 			}
 		default:
 
-			// map data
-			if nil == le.MapData {
-				le.MapData = map[string]interface{}{}
+			// map data, which may be nested under the field namespace
+			if nested, ok := v.(map[string]interface{}); ok && len(ns) > 0 && k == ns {
+				for nk, nv := range nested {
+					addMapData(nk, nv)
+				}
+			} else {
+				addMapData(k, v)
 			}
-			le.MapData[k] = v
 		}
 
 		// If error, short-circuit
@@ -491,8 +909,125 @@ func JSONToLogEntry(jsString string) (*LogEntry, error) {
 	return &le, nil
 }
 
+// Regex used to parse a single line produced by the StdLogFormatter:
+//
+// 2017/04/14 19:32:15 <test_service> [SRVUT:INFO:1]     Serving on port 54321
+//
+// - "^([0-9]+/[0-9]{2}/[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2})" - timestamp
+// - "(?: <([^>=]*)>)?" - optional service name
+// - "(?: <([^>]*=[^>]*)>)?" - optional labels
+// - " \\[([^:\\]]*):([A-Z0-9]{4})" - channel and level in the header
+// - "(?::([0-9]+))?" - optional goroutine ID
+// - "(?: seq=([0-9]+))?" - optional sequence number
+// - "(?: scope=([^ \\]]*))?" - optional scope id
+// - "(?: trace=([^ \\]]*) span=([^ \\]]*))?\\]" - optional trace and span ids
+// - " (.*)$" - indentation and message
+var plainTextLineRegex = regexp.MustCompile(
+	`^([0-9]+/[0-9]{2}/[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2})(?: <([^>=]*)>)?(?: <([^>]*=[^>]*)>)? \[([^:\]]*):([A-Z0-9]{4})(?::([0-9]+))?(?: seq=([0-9]+))?(?: scope=([^ \]]*))?(?: trace=([^ \]]*) span=([^ \]]*))?\] (.*)$`)
+
+// Parse the 4-character header form of a level
+func levelFromHeaderString(s string) (LogLevel, error) {
+	for lvl := FATAL; lvl <= DEBUG4; lvl++ {
+		if levelToHeaderString(lvl) == s {
+			return lvl, nil
+		}
+	}
+	return OFF, fmt.Errorf("Invalid header level [%s]", s)
+}
+
+// PlainTextToLogEntry - Convert a single line produced by the StdLogFormatter
+// to its corresponding LogEntry object. The indentation is counted using the
+// currently configured indent string. Since the plain text format does not
+// distinguish the component, map data or format expansion from the message,
+// everything after the indentation is returned as the Format.
+func PlainTextToLogEntry(line string) (*LogEntry, error) {
+
+	// Parse the line
+	m := plainTextLineRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if nil == m {
+		return nil, fmt.Errorf("Line does not match the standard log format")
+	}
+	le := LogEntry{
+		Servicename: m[2],
+		Channel:     LogChannel(strings.TrimRight(m[4], " ")),
+	}
+
+	// labels
+	if len(m[3]) > 0 {
+		le.Labels = map[string]string{}
+		for _, kv := range strings.Split(m[3], ",") {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) == 2 {
+				le.Labels[parts[0]] = parts[1]
+			}
+		}
+	}
+
+	// timestamp
+	if ts, err := time.Parse("2006/01/02 15:04:05", m[1]); nil != err {
+		return nil, fmt.Errorf("Bad timestamp [%s]", m[1])
+	} else {
+		le.Timestamp = ts
+	}
+
+	// level
+	if lvl, err := levelFromHeaderString(m[5]); nil != err {
+		return nil, err
+	} else {
+		le.Level = lvl
+	}
+
+	// goroutine ID
+	if len(m[6]) > 0 {
+		if gid, err := strconv.ParseUint(m[6], 10, 64); nil != err {
+			return nil, fmt.Errorf("Couldn't parse goroutine ID [%s]", m[6])
+		} else {
+			le.GoroutineID = &gid
+		}
+	}
+
+	// sequence number
+	if len(m[7]) > 0 {
+		if seq, err := strconv.ParseUint(m[7], 10, 64); nil != err {
+			return nil, fmt.Errorf("Couldn't parse sequence number [%s]", m[7])
+		} else {
+			le.Sequence = seq
+		}
+	}
+
+	// scope id
+	le.ScopeID = m[8]
+
+	// trace and span ids
+	le.TraceID = m[9]
+	le.SpanID = m[10]
+
+	// indentation and message
+	body := m[11]
+	if indent := GetIndentString(); len(indent) > 0 {
+		for strings.HasPrefix(body, indent) {
+			body = body[len(indent):]
+			le.NIndent++
+		}
+	}
+	le.Format = body
+
+	return &le, nil
+}
+
+// ParseLine - Convert a single log line of either format to its corresponding
+// LogEntry object. Lines starting with '{' are parsed as JSON and all others
+// are parsed as the standard plain text format.
+func ParseLine(line string) (*LogEntry, error) {
+	if strings.HasPrefix(strings.TrimLeft(line, " \t"), "{") {
+		return JSONToLogEntry(line)
+	}
+	return PlainTextToLogEntry(line)
+}
+
 // JSONToPlainText - Convert a structured JSON log line to its corresponding
-// plain text representation
+// plain text representation. The indentation is rebuilt from num_indent with
+// the current indent string (see SetIndentString).
 func JSONToPlainText(jsString string) ([]string, error) {
 
 	if le, err := JSONToLogEntry(jsString); nil != err {
@@ -504,3 +1039,34 @@ func JSONToPlainText(jsString string) ([]string, error) {
 		return formatter.FormatEntry(*le), nil
 	}
 }
+
+// ConvertJSONStream - Convert each structured JSON log line read from r to its
+// plain text representation and write it to w. A line that cannot be
+// converted is passed to onError, if set, and skipped rather than stopping
+// the conversion. Blank lines are skipped and the last line does not need a
+// trailing newline, so truncated files and mixed content can be converted.
+// The error returned is the first error reading from r or writing to w.
+func ConvertJSONStream(r io.Reader, w io.Writer, onError func(line string, err error)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(strings.TrimSpace(line)) > 0 {
+			if outlines, err := JSONToPlainText(line); nil != err {
+				if nil != onError {
+					onError(strings.TrimRight(line, "\r\n"), err)
+				}
+			} else {
+				for _, outline := range outlines {
+					if _, err := io.WriteString(w, outline); nil != err {
+						return err
+					}
+				}
+			}
+		}
+		if io.EOF == readErr {
+			return nil
+		} else if nil != readErr {
+			return readErr
+		}
+	}
+}
@@ -26,8 +26,15 @@ package alog
 
 import (
 	// Standard
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -42,11 +49,11 @@ import (
 // LevelFromString
 // 1) Test each valid level string
 //  -> Valid level value and no error
-// 2) Capital letters
-//  -> ERROR level with error returned
-// 3) Bad name
-//  -> ERROR level with error returned
-// 4) Header str representation
+// 2) Capital and mixed case letters
+//  -> Valid level value and no error
+// 3) Header str representation in any case
+//  -> Valid level value and no error
+// 4) Bad name
 //  -> ERROR level with error returned
 ////
 func Test_AlogExtras_LevelFromString(t *testing.T) {
@@ -113,24 +120,70 @@ func Test_AlogExtras_LevelFromString(t *testing.T) {
 		assert.Equal(t, lvl, DEBUG4)
 	}
 
-	// Invalid levels
-	{
-		lvl, err := LevelFromString("OFF")
-		assert.NotEqual(t, err, nil)
-		assert.Equal(t, lvl, ERROR)
+	// Mixed case
+	for str, exp := range map[string]LogLevel{
+		"OFF":     OFF,
+		"Info":    INFO,
+		"WARNING": WARNING,
+		"Debug3":  DEBUG3,
+	} {
+		lvl, err := LevelFromString(str)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, lvl, exp, str)
 	}
-	{
-		lvl, err := LevelFromString("foobar")
-		assert.NotEqual(t, err, nil)
-		assert.Equal(t, lvl, ERROR)
+
+	// Header strings
+	for str, exp := range map[string]LogLevel{
+		"FATL": FATAL,
+		"ERRR": ERROR,
+		"WARN": WARNING,
+		"INFO": INFO,
+		"TRCE": TRACE,
+		"DBUG": DEBUG,
+		"DBG1": DEBUG1,
+		"DBG2": DEBUG2,
+		"DBG3": DEBUG3,
+		"DBG4": DEBUG4,
+		"dbg2": DEBUG2,
+		"Warn": WARNING,
+	} {
+		lvl, err := LevelFromString(str)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, lvl, exp, str)
 	}
-	{
-		lvl, err := LevelFromString("DBG4")
+
+	// Invalid levels
+	for _, str := range []string{"foobar", "UNKN", "debug5", ""} {
+		lvl, err := LevelFromString(str)
 		assert.NotEqual(t, err, nil)
 		assert.Equal(t, lvl, ERROR)
 	}
 }
 
+////
+// ConfigLevelStr - Test configuring levels from strings
+// 1) Set a channel and the default level from valid strings
+//  -> Levels applied
+// 2) Set both from invalid strings
+//  -> LevelFromString's error returned
+//  -> Levels unchanged
+////
+func Test_AlogExtras_ConfigLevelStr(t *testing.T) {
+	defer ResetDefaults()
+
+	// Valid
+	assert.Nil(t, ConfigChannelStr("TEST", "debug2"))
+	assert.Nil(t, ConfigDefaultLevelStr("WARN"))
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG2}))
+	assert.Equal(t, WARNING, GetDefaultLevel())
+
+	// Invalid
+	assert.EqualError(t, ConfigChannelStr("TEST", "loud"), "Invalid log level [loud]")
+	assert.EqualError(t, ConfigDefaultLevelStr(""), "Invalid log level []")
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG2}))
+	assert.Equal(t, WARNING, GetDefaultLevel())
+}
+
 ////
 // ParseChannelFilter
 // 1) Valid filter spec
@@ -188,6 +241,109 @@ func Test_AlogExtras_ParseChannelFilter(t *testing.T) {
 	}
 }
 
+////
+// ParseNoLogging - Test that the parsing helpers don't log bad input
+//
+// 1) Parse a bad level and bad filter specs with everything enabled
+//  -> Errors returned with the usual messages
+//  -> Nothing logged
+////
+func Test_AlogExtras_ParseNoLogging(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(DEBUG4)
+	defer ResetDefaults()
+
+	_, err := LevelFromString("bogus")
+	assert.EqualError(t, err, "Invalid log level [bogus]")
+	_, err = ParseChannelFilter("MAIN;debug")
+	assert.EqualError(t, err, "Bad channel config found [MAIN;debug]")
+	_, _, err = ParseChannelFilterExact("MAIN:=bogus")
+	assert.EqualError(t, err, "Bad level specified: =bogus")
+	assert.Empty(t, w.Lines())
+}
+
+////
+// ParseChannelFilterExact
+// 1) Mix of threshold and exact entries
+//  -> Levels for all entries, exact set for the "=" entries only
+// 2) Parse the same with ParseChannelFilter
+//  -> Levels only
+// 3) Exact marker with a bad level
+//  -> Parse fails with error
+////
+func Test_AlogExtras_ParseChannelFilterExact(t *testing.T) {
+
+	// Set up logging
+	Config(TRACE, ChannelMap{})
+	defer ResetDefaults()
+
+	// Mixed
+	m, exact, err := ParseChannelFilterExact("MAIN:debug,TEST:=warning,DB:=DBG2")
+	assert.Nil(t, err)
+	assert.True(t, ValidateChannelMap(m, ChannelMap{
+		"MAIN": DEBUG,
+		"TEST": WARNING,
+		"DB":   DEBUG2,
+	}))
+	assert.Equal(t, map[LogChannel]bool{"TEST": true, "DB": true}, exact)
+
+	// Levels only
+	m, err = ParseChannelFilter("MAIN:debug,TEST:=warning")
+	assert.Nil(t, err)
+	assert.True(t, ValidateChannelMap(m, ChannelMap{"MAIN": DEBUG, "TEST": WARNING}))
+
+	// Bad level
+	m, exact, err = ParseChannelFilterExact("TEST:=bogus")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(m))
+	assert.Equal(t, 0, len(exact))
+}
+
+////
+// CheckFormat
+// 1) Matching format and args
+//  -> No error
+// 2) Wrong type for a verb
+//  -> Error naming the bad verb
+// 3) Missing and extra args
+//  -> Error for each
+// 4) Escaped percent
+//  -> No error
+////
+func Test_AlogExtras_CheckFormat(t *testing.T) {
+
+	// Matching
+	assert.Nil(t, CheckFormat("Hello %s, you are %d", "you", 42))
+	assert.Nil(t, CheckFormat("No verbs"))
+	assert.Nil(t, CheckFormat("%v and %+v", []int{1}, struct{ A int }{1}))
+
+	// Wrong type. The formats are held in variables so that vet does not flag
+	// the deliberate mistakes.
+	format := "Number %d"
+	err := CheckFormat(format, "not a number")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "%!d(string=not a number)")
+	}
+
+	// Missing and extra
+	format = "%s and %s"
+	err = CheckFormat(format, "one")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "%!s(MISSING)")
+	}
+	format = "Just %s"
+	err = CheckFormat(format, "one", 2)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "%!(EXTRA int=2)")
+	}
+	format = "Trailing %"
+	assert.NotNil(t, CheckFormat(format))
+
+	// Escaped percent
+	assert.Nil(t, CheckFormat("100%% done"))
+}
+
 // Tests - Command Line Flags //////////////////////////////////////////////////
 
 ////
@@ -237,6 +393,41 @@ func Test_AlogExtras_ConfigureFromFlags(t *testing.T) {
 	assert.False(t, FuncSigEnabled())
 }
 
+////
+// GetFlagsFor
+// 1) Register the flags on a local FlagSet and parse a custom arg slice
+//  -> Parsed values available through the returned FlagSet
+//  -> Nothing registered on flag.CommandLine
+// 2) Run configuration
+//  -> Configuration matches the parsed args
+////
+func Test_AlogExtras_GetFlagsFor(t *testing.T) {
+	defer ResetDefaults()
+
+	// Register and parse
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	appFlag := fs.String("app.name", "", "An application flag")
+	logFlags := GetFlagsFor(fs)
+	assert.Nil(t, fs.Parse([]string{
+		"-app.name", "myapp",
+		"-log.default-level", "warning",
+		"-log.filters", "TEST:debug",
+		"-log.service-name", "local_service",
+		"-log.goroutine-id",
+	}))
+	assert.Equal(t, "myapp", *appFlag)
+	assert.Equal(t, "warning", *logFlags.DefaultLevel)
+	assert.Equal(t, 5, *logFlags.ChannelHeaderLen)
+	assert.Nil(t, flag.CommandLine.Lookup("log.default-level"))
+
+	// Configure
+	assert.Nil(t, ConfigureFromFlags(logFlags))
+	assert.Equal(t, WARNING, GetDefaultLevel())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG}))
+	assert.Equal(t, "local_service", GetServiceName())
+	assert.True(t, GIDEnabled())
+}
+
 // Tests - Dynamic Config //////////////////////////////////////////////////////
 
 ////
@@ -274,7 +465,7 @@ func Test_AlogExtras_ConfigureDynamicLogging_Permanent(t *testing.T) {
 // 3) Use ConfigureDynamicLogging
 // 4) Validate configuration
 // 5) Use ConfigureDynamicLogging before timeout
-//  -> error
+//  -> no error, applied on top of the first
 // 6) Wait for timeout
 // 7) Validate original configuration
 // 8) Use ConfigureDynamicLogging again without timeout
@@ -308,8 +499,8 @@ func Test_AlogExtras_ConfigureDynamicLogging_Temporary(t *testing.T) {
 		"DEEP": DEBUG4,
 	}))
 
-	// Try second dynamic config and make sure error
-	assert.NotEqual(t, ConfigureDynamicLogging(cfg), nil)
+	// Apply a second dynamic config on top of the first
+	assert.Equal(t, ConfigureDynamicLogging(cfg), nil)
 
 	// Wait for timeout
 	time.Sleep((time.Duration(timeout) + 1) * time.Second)
@@ -323,6 +514,38 @@ func Test_AlogExtras_ConfigureDynamicLogging_Temporary(t *testing.T) {
 	assert.Equal(t, ConfigureDynamicLogging(cfg), nil)
 }
 
+////
+// ConfigureDynamicLogging - Exact channels
+// 1) Configure an exact channel directly
+// 2) Apply a temporary override that makes another channel exact and the
+//    original channel a threshold
+//  -> Exact set follows the override
+// 3) Cancel the override
+//  -> Original exact channel restored
+////
+func Test_AlogExtras_ConfigureDynamicLogging_Exact(t *testing.T) {
+
+	// Configure directly
+	Config(INFO, ChannelMap{})
+	ConfigChannelExact("TEST", WARNING)
+	defer ResetAll()
+
+	// Override
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		Filters: "TEST:warning,OTHER:=debug",
+		Timeout: 60,
+	}))
+	assert.Equal(t, map[LogChannel]bool{"OTHER": true}, GetExactChannels())
+	assert.True(t, IsEnabled("TEST", ERROR))
+	assert.False(t, IsEnabled("OTHER", INFO))
+
+	// Cancel
+	assert.Nil(t, CancelDynamicLogging())
+	assert.Equal(t, map[LogChannel]bool{"TEST": true}, GetExactChannels())
+	assert.False(t, IsEnabled("TEST", ERROR))
+	assert.True(t, IsEnabled("TEST", WARNING))
+}
+
 ////
 // DynamicHandler
 // 1) Fake up an http.ResponseWriter and http.Request
@@ -363,6 +586,328 @@ func Test_AlogExtras_DynamicHandler(t *testing.T) {
 	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{}))
 }
 
+////
+// Config Change Events - Attribute configuration changes to their source
+// 1) Change the default level with the DynamicHandler
+//  -> config_change event on ALOG with source dynamic_http and the diff
+// 2) Change a channel with ConfigureDynamicLogging
+//  -> config_change event with source dynamic
+// 3) Apply the same configuration again
+//  -> No config_change event
+////
+func Test_AlogExtras_ConfigChangeEvents(t *testing.T) {
+
+	// Set up logging
+	Config(DEBUG, ChannelMap{})
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+
+	// Find the config_change events that have been logged
+	changeEvents := func() []LogEntry {
+		out := []LogEntry{}
+		for _, e := range w.Entries() {
+			if e.Channel == "ALOG" && e.MapData["event"] == "config_change" {
+				out = append(out, e)
+			}
+		}
+		w.Reset()
+		return out
+	}
+
+	// Change with the handler
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(
+		"GET",
+		"http://localhost:54321?default_level=info",
+		strings.NewReader(""),
+	)
+	DynamicHandler(writer, request)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	events := changeEvents()
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, "dynamic_http", events[0].MapData["source"])
+		assert.Equal(t, map[string]interface{}{
+			"default_level": map[string]interface{}{"from": "debug", "to": "info"},
+		}, events[0].MapData["changes"])
+	}
+
+	// Change directly
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{Filters: "TEST:debug"}))
+	events = changeEvents()
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, "dynamic", events[0].MapData["source"])
+		changes, ok := events[0].MapData["changes"].(map[string]interface{})
+		assert.True(t, ok)
+		assert.Contains(t, changes, "channel_map")
+		assert.NotContains(t, changes, "default_level")
+	}
+
+	// No change
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{Filters: "TEST:debug"}))
+	assert.Equal(t, 0, len(changeEvents()))
+}
+
+////
+// ConfigureDynamicLogging - Stacked
+// 1) Configure directly
+// 2) Apply override A with a longer timeout setting the default level and DB
+// 3) Apply override B with a shorter timeout setting API and DB
+//  -> B wins for DB, A's default level is kept, base channels kept
+// 4) Wait for B to time out
+//  -> A's configuration remains on top of the base
+// 5) Wait for A to time out
+//  -> Base configuration restored
+////
+func Test_AlogExtras_ConfigureDynamicLogging_Stacked(t *testing.T) {
+
+	// Configure directly
+	Config(INFO, ChannelMap{"BASE": WARNING})
+	defer ResetDefaults()
+
+	// Apply A
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		DefaultLevel: "debug",
+		Filters:      "DB:debug",
+		Timeout:      2,
+	}))
+
+	// Apply B
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		Filters: "API:debug,DB:debug2",
+		Timeout: 1,
+	}))
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"BASE": WARNING,
+		"DB":   DEBUG2,
+		"API":  DEBUG,
+	}))
+
+	// Wait for B to expire
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"BASE": WARNING,
+		"DB":   DEBUG,
+	}))
+
+	// Wait for A to expire
+	time.Sleep(1 * time.Second)
+	assert.Equal(t, GetDefaultLevel(), INFO)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"BASE": WARNING}))
+}
+
+////
+// ConfigureDynamicLogging - Permanent with override active
+// 1) Configure directly
+// 2) Apply a temporary override
+// 3) Apply a permanent configuration
+//  -> Override stays on top of the new base
+// 4) Cancel the override
+//  -> New base configuration active
+////
+func Test_AlogExtras_ConfigureDynamicLogging_PermanentUnderOverride(t *testing.T) {
+
+	// Configure directly
+	Config(INFO, ChannelMap{})
+	defer ResetDefaults()
+
+	// Temporary override
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		Filters: "DB:debug",
+		Timeout: 3600,
+	}))
+
+	// Permanent change
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		DefaultLevel: "warning",
+		Filters:      "API:info",
+	}))
+	assert.Equal(t, GetDefaultLevel(), WARNING)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"DB":  DEBUG,
+		"API": INFO,
+	}))
+
+	// Cancel the override
+	assert.Nil(t, CancelDynamicLogging())
+	assert.Equal(t, GetDefaultLevel(), WARNING)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"API": INFO}))
+}
+
+////
+// CancelDynamicLogging
+// 1) Configure directly
+// 2) Use ConfigureDynamicLogging with a long timeout
+// 3) Cancel the temporary config
+//  -> Original configuration restored immediately
+// 4) Cancel again
+//  -> error
+// 5) Use ConfigureDynamicLogging again with a timeout
+//  -> no error
+// 6) Cancel via the DynamicHandler
+//  -> 200 and original configuration restored
+// 7) Cancel via the DynamicHandler with nothing active
+//  -> 409
+////
+func Test_AlogExtras_CancelDynamicLogging(t *testing.T) {
+
+	// Configure directly
+	Config(TRACE, ChannelMap{"TEST": INFO})
+	defer ResetDefaults()
+
+	// Configure with a long timeout
+	cfg := DynamicLogConfig{
+		DefaultLevel: "debug",
+		Filters:      "TEST:debug4",
+		Timeout:      3600,
+	}
+	assert.Nil(t, ConfigureDynamicLogging(cfg))
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+
+	// Cancel and make sure it's reverted
+	assert.Nil(t, CancelDynamicLogging())
+	assert.Equal(t, GetDefaultLevel(), TRACE)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": INFO}))
+
+	// Cancel again
+	assert.NotNil(t, CancelDynamicLogging())
+
+	// Configure again and cancel via the handler
+	assert.Nil(t, ConfigureDynamicLogging(cfg))
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "http://localhost:54321/logging?cancel=true", strings.NewReader(""))
+	DynamicHandler(writer, request)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, GetDefaultLevel(), TRACE)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": INFO}))
+
+	// Cancel via the handler with nothing active
+	writer = httptest.NewRecorder()
+	request = httptest.NewRequest("GET", "http://localhost:54321/logging?cancel=true", strings.NewReader(""))
+	DynamicHandler(writer, request)
+	assert.Equal(t, http.StatusConflict, writer.Code)
+}
+
+////
+// DynamicHandler - Show
+// 1) Configure logging directly
+// 2) Invoke DynamicHandler with no params
+//  -> 200 with current config in the body
+// 3) Invoke DynamicHandler with show=true
+//  -> 200 with current config in the body
+// 4) Invoke DynamicHandler with format=json
+//  -> 200 with the full config as JSON in the body
+//  -> config unchanged
+////
+func Test_AlogExtras_DynamicHandlerShow(t *testing.T) {
+
+	// Set up logging
+	Config(INFO, ChannelMap{"TEST": DEBUG})
+	defer ResetDefaults()
+
+	// No params
+	{
+		writer := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "http://localhost:54321/logging", strings.NewReader(""))
+		DynamicHandler(writer, request)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Contains(t, writer.Body.String(), "Default Level: INFO")
+		assert.Contains(t, writer.Body.String(), "TEST: debug")
+	}
+
+	// show=true
+	{
+		writer := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "http://localhost:54321/logging?show=true&default_level=debug4", strings.NewReader(""))
+		DynamicHandler(writer, request)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Contains(t, writer.Body.String(), "Default Level: INFO")
+		assert.Contains(t, writer.Body.String(), "TEST: debug")
+	}
+
+	// format=json
+	{
+		writer := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "http://localhost:54321/logging?format=json", strings.NewReader(""))
+		DynamicHandler(writer, request)
+		assert.Equal(t, http.StatusOK, writer.Code)
+		assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+		cfg := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(writer.Body.Bytes(), &cfg))
+		assert.Equal(t, "info", cfg["default_level"])
+		assert.Equal(t, map[string]interface{}{"TEST": "debug"}, cfg["channel_map"])
+	}
+
+	// Make sure nothing changed
+	assert.Equal(t, GetDefaultLevel(), INFO)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{"TEST": DEBUG}))
+}
+
+////
+// DynamicHandler - JSON
+// 1) Invoke DynamicHandler with a JSON POST body
+//  -> 200 and configuration applied
+// 2) Invoke DynamicHandler with a JSON PATCH body while the timer is active
+//  -> 200 and applied on top of the first
+// 3) Wait for timeout
+//  -> Original configuration restored
+// 4) Invoke DynamicHandler with a malformed JSON body
+//  -> 400
+// 5) Invoke DynamicHandler with a bad level in a JSON body
+//  -> 400
+////
+func Test_AlogExtras_DynamicHandlerJSON(t *testing.T) {
+
+	// Set up logging
+	Config(DEBUG, ChannelMap{})
+	defer ResetDefaults()
+
+	makeRequest := func(method, body string) *httptest.ResponseRecorder {
+		writer := httptest.NewRecorder()
+		request := httptest.NewRequest(method, "http://localhost:54321/logging", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+		DynamicHandler(writer, request)
+		return writer
+	}
+
+	// Valid POST with special characters in the filter string
+	timeout := uint32(1)
+	writer := makeRequest("POST", fmt.Sprintf(
+		`{"default_level": "info", "filters": "TEST:debug,DE&EP:debug4", "timeout": %d}`, timeout))
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, GetDefaultLevel(), INFO)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"TEST":  DEBUG,
+		"DE&EP": DEBUG4,
+	}))
+
+	// Stack on top of the active override
+	writer = makeRequest("PATCH", fmt.Sprintf(`{"default_level": "debug1", "timeout": %d}`, timeout))
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, GetDefaultLevel(), DEBUG1)
+
+	// Wait for timeout
+	time.Sleep((time.Duration(timeout) + 1) * time.Second)
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{}))
+
+	// Malformed JSON
+	writer = makeRequest("POST", `{"default_level": `)
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+	assert.Contains(t, writer.Body.String(), "Malformed JSON")
+	writer = makeRequest("POST", `{"default_levl": "info"}`)
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+
+	// Bad level
+	writer = makeRequest("PATCH", `{"default_level": "foobar"}`)
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+	assert.Equal(t, GetDefaultLevel(), DEBUG)
+}
+
 ////
 // ConfigureDynamicLogging - Bad DefaultLevel
 // 1) Set up a config object with a bad default level string
@@ -422,3 +967,189 @@ func Test_AlogExtras_ConfigureDynamicLogging_BadChannelMap(t *testing.T) {
 	assert.Equal(t, GetDefaultLevel(), DEBUG)
 	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{}))
 }
+
+// Tests - Line Parsing ////////////////////////////////////////////////////////
+
+////
+// ParseLine - Auto-detect the format of a line
+// 1) Log a line using the std formatter with service name, GID and indent
+// 2) Log a line using the JSON formatter
+// 3) Parse each with ParseLine
+//  -> Both parse to entries with the logged fields
+// 4) Parse a line that is neither format
+//  -> error
+////
+func Test_AlogExtras_ParseLine(t *testing.T) {
+
+	// Log a std line
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	Config(DEBUG4, ChannelMap{})
+	SetServiceName("svc")
+	EnableGID()
+	defer ResetDefaults()
+	Indent()
+	Log("STDCH", WARNING, "Hello %s", "std")
+	Deindent()
+
+	// Log a JSON line
+	DisableGID()
+	SetServiceName("")
+	UseJSONLogFormatter()
+	Log("JSCH", DEBUG2, "Hello json")
+	if !assert.Equal(t, 2, len(entries)) {
+		return
+	}
+
+	// Parse the std line
+	le, err := ParseLine(entries[0])
+	assert.Nil(t, err)
+	if assert.NotNil(t, le) {
+		assert.Equal(t, LogChannel("STDCH"), le.Channel)
+		assert.Equal(t, WARNING, le.Level)
+		assert.Equal(t, "Hello std", le.Format)
+		assert.Equal(t, 1, le.NIndent)
+		assert.Equal(t, "svc", le.Servicename)
+		assert.NotNil(t, le.GoroutineID)
+		assert.False(t, le.Timestamp.IsZero())
+	}
+
+	// Parse the JSON line
+	le, err = ParseLine(entries[1])
+	assert.Nil(t, err)
+	if assert.NotNil(t, le) {
+		assert.Equal(t, LogChannel("JSCH"), le.Channel)
+		assert.Equal(t, DEBUG2, le.Level)
+		assert.Equal(t, "Hello json", le.Format)
+		assert.Equal(t, 0, le.NIndent)
+		assert.Nil(t, le.GoroutineID)
+	}
+
+	// Parse garbage
+	_, err = ParseLine("this is not a log line")
+	assert.NotNil(t, err)
+	_, err = ParseLine("{not json")
+	assert.NotNil(t, err)
+}
+
+////
+// PlainTextHeaderFields - Round-trip the optional header fields
+// 1) Log lines inside a scope with GID, sequence numbers, scope correlation and
+//    trace ids enabled using the std formatter
+// 2) Parse each with PlainTextToLogEntry
+//  -> The scope id, sequence number, goroutine id and trace ids are parsed
+//  -> The Start and End lines share the scope id of the inner line
+////
+func Test_AlogExtras_PlainTextHeaderFields(t *testing.T) {
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	EnableGID()
+	EnableSequence()
+	EnableScopeCorrelation()
+	SetTraceExtractor(fakeExtractor)
+	defer ResetDefaults()
+
+	ctx := context.WithValue(context.Background(), fakeSpanKey{}, fakeSpan{"4bf92f35", "00f067aa"})
+	func() {
+		defer LogScope("TEST", INFO, "scope").Close()
+		UseChannel("TEST").Ctx(ctx).Log(INFO, "traced")
+	}()
+	if !assert.Equal(t, 3, len(entries)) {
+		return
+	}
+	parsed := []*LogEntry{}
+	for _, entry := range entries {
+		le, err := PlainTextToLogEntry(entry)
+		if !assert.Nil(t, err, entry) {
+			return
+		}
+		parsed = append(parsed, le)
+		assert.NotEqual(t, "", le.ScopeID)
+		assert.NotEqual(t, uint64(0), le.Sequence)
+		assert.NotNil(t, le.GoroutineID)
+	}
+	assert.Equal(t, "Start: scope", parsed[0].Format)
+	assert.Equal(t, "traced", parsed[1].Format)
+	assert.Equal(t, 1, parsed[1].NIndent)
+	assert.Equal(t, "4bf92f35", parsed[1].TraceID)
+	assert.Equal(t, "00f067aa", parsed[1].SpanID)
+	assert.Equal(t, "End: scope", parsed[2].Format)
+	assert.Equal(t, parsed[0].ScopeID, parsed[1].ScopeID)
+	assert.Equal(t, parsed[0].ScopeID, parsed[2].ScopeID)
+	assert.True(t, parsed[0].Sequence < parsed[1].Sequence)
+}
+
+////
+// ConvertJSONStream - Convert a stream of JSON lines to plain text
+// 1) Write a file of JSON lines with a bad line and a blank line in the middle
+//    and a final line without a trailing newline
+// 2) Convert the file with ConvertJSONStream
+//  -> Every good line converted, including the last
+//  -> The bad line is passed to onError and conversion continues
+// 3) Convert without an error callback
+//  -> Bad lines are skipped silently
+// 4) Convert to a writer that fails
+//  -> The write error is returned
+////
+func Test_AlogExtras_ConvertJSONStream(t *testing.T) {
+
+	// Log the JSON lines
+	entries := []string{}
+	ConfigJSONLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	Log("TEST", INFO, "first")
+	Indent()
+	Log("TEST", WARNING, "second")
+	Deindent()
+	Log("TEST", ERROR, "last")
+	if !assert.Equal(t, 3, len(entries)) {
+		return
+	}
+
+	// Write the file
+	dir, err := ioutil.TempDir("", "alog_convert")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.json")
+	content := entries[0] + "{\"channel\": \"TEST\", truncated\n\n" + entries[1] + strings.TrimSuffix(entries[2], "\n")
+	if !assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644)) {
+		return
+	}
+
+	// Convert
+	f, err := os.Open(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer f.Close()
+	out := &strings.Builder{}
+	badLines := []string{}
+	err = ConvertJSONStream(f, out, func(line string, err error) {
+		assert.NotNil(t, err)
+		badLines = append(badLines, line)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"{\"channel\": \"TEST\", truncated"}, badLines)
+	lines := strings.SplitAfter(out.String(), "\n")
+	lines = lines[:len(lines)-1]
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "first"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "second", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "last"},
+	}))
+
+	// No callback
+	out.Reset()
+	assert.Nil(t, ConvertJSONStream(strings.NewReader(content), out, nil))
+	assert.Equal(t, 3, strings.Count(out.String(), "\n"))
+
+	// Failing writer
+	err = ConvertJSONStream(strings.NewReader(content), failingWriter{}, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "disk full", err.Error())
+	}
+}
@@ -0,0 +1,138 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+//-- JSON Fields ---------------------------------------------------------------
+
+// Keys used by the JSONLogFormatter for the standard fields of an entry. Map
+// data keys that collide with these are renamed with reservedKeyPrefix.
+var reservedJSONKeys = map[string]bool{
+	"channel":      true,
+	"level":        true,
+	"level_str":    true,
+	"message":      true,
+	"timestamp":    true,
+	"num_indent":   true,
+	"service_name": true,
+	"labels":       true,
+	"component":    true,
+	"scope_id":     true,
+	"scope_seq":    true,
+	"request_id":   true,
+	"sequence":     true,
+	"trace_id":     true,
+	"span_id":      true,
+	"subsystem":    true,
+	"thread_id":    true,
+}
+
+// Prefix added to map data keys that collide with a standard field
+const reservedKeyPrefix = "field_"
+
+// The namespace is read by the formatters without locking
+var jsonFieldNamespace atomic.Value
+
+// Map data keys that have been renamed. Each key is warned about once, and the
+// warnings are logged after the entry that contained it has been written since
+// the formatter runs inside the logger's lock.
+var reservedKeyWarnings = struct {
+	mutex    sync.Mutex
+	warned   map[string]bool
+	pending  []string
+	nPending uint32
+}{warned: map[string]bool{}}
+
+// SetJSONFieldNamespace - Nest the map data of each entry under the given key
+// in JSON output rather than merging it into the top level. This keeps custom
+// fields from colliding with the standard fields. An empty key restores the
+// default, where colliding map data keys (e.g. "message" or "channel") are
+// renamed with a "field_" prefix and a warning is logged the first time each
+// one is seen. The namespace is shared by all loggers. An error is returned if
+// the key is itself one of the standard fields.
+func SetJSONFieldNamespace(key string) error {
+	if reservedJSONKeys[key] {
+		return fmt.Errorf("Field namespace [%s] collides with a standard field", key)
+	}
+	jsonFieldNamespace.Store(key)
+	return nil
+}
+
+// GetJSONFieldNamespace - Get the key that map data is nested under in JSON
+// output (empty if map data is merged into the top level)
+func GetJSONFieldNamespace() string {
+	ns, _ := jsonFieldNamespace.Load().(string)
+	return ns
+}
+
+// Record that a map data key was renamed
+func noteReservedKey(key string) {
+	reservedKeyWarnings.mutex.Lock()
+	if !reservedKeyWarnings.warned[key] {
+		reservedKeyWarnings.warned[key] = true
+		reservedKeyWarnings.pending = append(reservedKeyWarnings.pending, key)
+		atomic.StoreUint32(&reservedKeyWarnings.nPending, 1)
+	}
+	reservedKeyWarnings.mutex.Unlock()
+}
+
+// Log a warning for each map data key renamed since the last call
+//
+// NOTE: This must be called outside of the logger's lock
+////
+func (cfg *alogger) reportReservedKeys() {
+	if 0 == atomic.LoadUint32(&reservedKeyWarnings.nPending) {
+		return
+	}
+	reservedKeyWarnings.mutex.Lock()
+	keys := reservedKeyWarnings.pending
+	reservedKeyWarnings.pending = nil
+	atomic.StoreUint32(&reservedKeyWarnings.nPending, 0)
+	reservedKeyWarnings.mutex.Unlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		cfg.log(LogEntry{
+			Channel:   "ALOG",
+			Level:     WARNING,
+			Format:    "Map data key [%s] collides with a standard JSON field and was logged as [%s]",
+			Expansion: []interface{}{key, reservedKeyPrefix + key},
+		})
+	}
+}
+
+// Forget which keys have been warned about
+func clearReservedKeyWarnings() {
+	reservedKeyWarnings.mutex.Lock()
+	reservedKeyWarnings.warned = map[string]bool{}
+	reservedKeyWarnings.pending = nil
+	atomic.StoreUint32(&reservedKeyWarnings.nPending, 0)
+	reservedKeyWarnings.mutex.Unlock()
+}
@@ -0,0 +1,144 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - JSON Fields /////////////////////////////////////////////////////////
+
+////
+// Reserved keys - Map data keys that collide with standard fields
+// 1) Log map data with "message" and "channel" keys using JSON
+//  -> Standard fields are intact
+//  -> Colliding keys are logged with the field_ prefix
+//  -> One warning per colliding key on the ALOG channel
+// 2) Log the same keys again
+//  -> Keys are still renamed but no further warnings
+////
+func Test_AlogFields_ReservedKeys(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+
+	// First collision
+	LogWithMap("TEST", INFO, map[string]interface{}{
+		"message": "user message",
+		"channel": "user channel",
+		"other":   1,
+	}, "The real message")
+	entries := w.Entries()
+	if assert.Equal(t, 3, len(entries)) {
+		assert.Equal(t, "The real message", entries[0].Format)
+		assert.Equal(t, LogChannel("TEST"), entries[0].Channel)
+		assert.Equal(t, "user message", entries[0].MapData["field_message"])
+		assert.Equal(t, "user channel", entries[0].MapData["field_channel"])
+		assert.Contains(t, entries[0].MapData, "other")
+		assert.NotContains(t, entries[0].MapData, "message")
+
+		assert.Equal(t, LogChannel("ALOG"), entries[1].Channel)
+		assert.Equal(t, WARNING, entries[1].Level)
+		assert.Contains(t, entries[1].Format, "[channel]")
+		assert.Contains(t, entries[2].Format, "[message]")
+	}
+
+	// Second collision
+	w.Reset()
+	LogMap("TEST", INFO, map[string]interface{}{"message": "again"})
+	entries = w.Entries()
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "again", entries[0].MapData["field_message"])
+	}
+}
+
+////
+// SetJSONFieldNamespace - Nest map data under a key
+// 1) Set the namespace to a standard field name
+//  -> Error and no change
+// 2) Set the namespace to "fields" and log map data including a reserved key
+//  -> Map data nested under "fields" with the original keys
+//  -> No warnings logged
+//  -> Entries parse with the nested map data
+// 3) Log without map data
+//  -> No "fields" key
+// 4) Clear the namespace
+//  -> Map data merged into the top level again
+////
+func Test_AlogFields_Namespace(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+
+	// Reserved
+	assert.NotNil(t, SetJSONFieldNamespace("message"))
+	assert.Equal(t, "", GetJSONFieldNamespace())
+
+	// Namespaced
+	assert.Nil(t, SetJSONFieldNamespace("fields"))
+	assert.Equal(t, "fields", GetJSONFieldNamespace())
+	UseChannel("TEST").WithFields(map[string]interface{}{"user": "bob"}).
+		LogWithMap(INFO, map[string]interface{}{"message": "user message"}, "Hello")
+	Log("TEST", INFO, "No map data")
+	lines := w.Lines()
+	if assert.Equal(t, 2, len(lines)) {
+		raw := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &raw))
+		assert.Equal(t, "Hello", raw["message"])
+		assert.Equal(t, map[string]interface{}{
+			"user":    "bob",
+			"message": "user message",
+		}, raw["fields"])
+		assert.NotContains(t, raw, "user")
+		assert.False(t, strings.Contains(lines[1], `"fields"`))
+	}
+	entries := w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, map[string]interface{}{
+			"user":    "bob",
+			"message": "user message",
+		}, entries[0].MapData)
+	}
+
+	// Cleared
+	w.Reset()
+	assert.Nil(t, SetJSONFieldNamespace(""))
+	LogMap("TEST", INFO, map[string]interface{}{"user": "bob"})
+	assert.Contains(t, w.Lines()[0], `"user":"bob"`)
+}
@@ -0,0 +1,227 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//-- Date Rotation -------------------------------------------------------------
+
+// Layout of the date used to name rotated files
+const dateFileLayout = "2006-01-02"
+
+// A log file in a directory that is rotated to a new file named <date>.log
+// whenever a line for a new date is written. If gzip is set, the file is named
+// <date>.log.gz and holds a gzip stream that is finished on each rotation.
+//
+// NOTE: This is not safe for concurrent use. The owning writer must serialize
+//  access.
+////
+type dateFile struct {
+	dir  string
+	date string
+	file *os.File
+	gzip bool
+	gz   *GzipWriter
+}
+
+// Write a line for the given time, rotating to the file for its date first if
+// needed
+func (f *dateFile) write(ts time.Time, p []byte) (int, error) {
+	if date := ts.Format(dateFileLayout); date != f.date || nil == f.file {
+		if err := f.rotate(date); nil != err {
+			return 0, err
+		}
+	}
+	if nil != f.gz {
+		return f.gz.Write(p)
+	}
+	return f.file.Write(p)
+}
+
+// Close the current file and open the one for the given date, creating the
+// directory if needed
+func (f *dateFile) rotate(date string) error {
+	if err := f.close(); nil != err {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0755); nil != err {
+		return err
+	}
+	name := date + ".log"
+	if f.gzip {
+		name += ".gz"
+	}
+	file, err := os.OpenFile(
+		filepath.Join(f.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if nil != err {
+		return err
+	}
+	f.file = file
+	f.date = date
+	if f.gzip {
+		f.gz = WrapGzipWriter(file)
+	}
+	return nil
+}
+
+// Flush the compressed data of the current file if there is one
+func (f *dateFile) flush() error {
+	if nil == f.gz {
+		return nil
+	}
+	return f.gz.Flush()
+}
+
+// Close the current file if there is one, finishing its gzip stream first
+func (f *dateFile) close() error {
+	if nil == f.file {
+		return nil
+	}
+	var err error
+	if nil != f.gz {
+		err = f.gz.Close()
+		f.gz = nil
+	}
+	if cErr := f.file.Close(); nil == err {
+		err = cErr
+	}
+	f.file = nil
+	return err
+}
+
+//-- Channel Date Writer -------------------------------------------------------
+
+// ChannelDateWriter - EntryWriter implementation that routes each channel to
+// its own directory and each day to its own file, as dir/<channel>/<date>.log.
+// Directories are created as needed and the date (YYYY-MM-DD) is taken from
+// the entry's timestamp, which is in UTC, so files rotate at UTC midnight.
+//
+// Lines written without an entry (through Write) go to dir/<date>.log. All
+// methods are safe to call from multiple goroutines.
+type ChannelDateWriter struct {
+	mutex sync.Mutex
+	dir   string
+	gzip  bool
+	base  *dateFile
+	files map[LogChannel]*dateFile
+	now   func() time.Time
+}
+
+// NewChannelDateWriter - Create a ChannelDateWriter writing below dir
+func NewChannelDateWriter(dir string) *ChannelDateWriter {
+	return &ChannelDateWriter{
+		dir:   dir,
+		base:  &dateFile{dir: dir},
+		files: map[LogChannel]*dateFile{},
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Name of the directory used for a channel. Path separators are replaced so
+// that a channel can not escape the base directory.
+func channelDirName(channel LogChannel) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(string(channel))
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
+
+// EnableGzip - Compress the files, named <date>.log.gz. Each file holds its own
+// gzip stream that is finished when the file is rotated or closed, so every
+// rotated file is valid gzip on its own. Reopening a file after Close appends
+// a new stream, which gzip readers decompress as one. Files that are already
+// open stay uncompressed until they rotate.
+func (w *ChannelDateWriter) EnableGzip() {
+	w.mutex.Lock()
+	w.gzip = true
+	w.base.gzip = true
+	for _, f := range w.files {
+		f.gzip = true
+	}
+	w.mutex.Unlock()
+}
+
+// Get the file for a channel, creating it if needed
+func (w *ChannelDateWriter) fileFor(channel LogChannel) *dateFile {
+	f, ok := w.files[channel]
+	if !ok {
+		f = &dateFile{dir: filepath.Join(w.dir, channelDirName(channel)), gzip: w.gzip}
+		w.files[channel] = f
+	}
+	return f
+}
+
+// WriteEntry - Write a line to the file for the entry's channel and date
+func (w *ChannelDateWriter) WriteEntry(e *LogEntry, p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = w.now()
+	}
+	return w.fileFor(e.Channel).write(ts, p)
+}
+
+// Write - Write a line that is not associated with an entry to the file for
+// the current date directly in the base directory
+func (w *ChannelDateWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.base.write(w.now(), p)
+}
+
+// Flush - Write the pending compressed data of all open files when gzip is
+// enabled. Without gzip this does nothing since writes are not buffered.
+func (w *ChannelDateWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	outErr := w.base.flush()
+	for _, f := range w.files {
+		if err := f.flush(); nil != err && nil == outErr {
+			outErr = err
+		}
+	}
+	return outErr
+}
+
+// Close - Close all open files. Writing again reopens them.
+func (w *ChannelDateWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	outErr := w.base.close()
+	for _, f := range w.files {
+		if err := f.close(); nil != err && nil == outErr {
+			outErr = err
+		}
+	}
+	return outErr
+}
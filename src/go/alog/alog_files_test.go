@@ -0,0 +1,185 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Channel Date Writer /////////////////////////////////////////////////
+
+// Read a file in a test, returning the empty string if it does not exist
+func readTestFile(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if nil != err {
+		return ""
+	}
+	return string(b)
+}
+
+////
+// NewChannelDateWriter - Routing by channel and date across midnight
+// 1) Write entries for two channels just before and after midnight
+//  -> Four files, one per channel and date, each with its own lines
+// 2) Write a line without an entry
+//  -> Written to the file for the current date in the base directory
+// 3) Write an entry for a channel containing a path separator
+//  -> Kept inside the base directory
+////
+func Test_AlogFiles_ChannelDateMidnight(t *testing.T) {
+	dir := t.TempDir()
+	w := NewChannelDateWriter(dir)
+	w.now = func() time.Time { return time.Date(2021, 3, 2, 8, 0, 0, 0, time.UTC) }
+	defer w.Close()
+
+	before := time.Date(2021, 3, 1, 23, 59, 59, 0, time.UTC)
+	after := time.Date(2021, 3, 2, 0, 0, 1, 0, time.UTC)
+	for _, e := range []LogEntry{
+		{Channel: "AUDIT", Timestamp: before},
+		{Channel: "DEBUG", Timestamp: before},
+		{Channel: "AUDIT", Timestamp: before},
+		{Channel: "AUDIT", Timestamp: after},
+		{Channel: "DEBUG", Timestamp: after},
+	} {
+		line := string(e.Channel) + " " + e.Timestamp.Format(time.RFC3339) + "\n"
+		n, err := w.WriteEntry(&e, []byte(line))
+		assert.Nil(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	assert.Equal(t,
+		"AUDIT 2021-03-01T23:59:59Z\nAUDIT 2021-03-01T23:59:59Z\n",
+		readTestFile(filepath.Join(dir, "AUDIT", "2021-03-01.log")))
+	assert.Equal(t,
+		"AUDIT 2021-03-02T00:00:01Z\n",
+		readTestFile(filepath.Join(dir, "AUDIT", "2021-03-02.log")))
+	assert.Equal(t,
+		"DEBUG 2021-03-01T23:59:59Z\n",
+		readTestFile(filepath.Join(dir, "DEBUG", "2021-03-01.log")))
+	assert.Equal(t,
+		"DEBUG 2021-03-02T00:00:01Z\n",
+		readTestFile(filepath.Join(dir, "DEBUG", "2021-03-02.log")))
+
+	// No entry
+	w.Write([]byte("plain\n"))
+	assert.Equal(t, "plain\n", readTestFile(filepath.Join(dir, "2021-03-02.log")))
+
+	// Path separators
+	w.WriteEntry(&LogEntry{Channel: "../up", Timestamp: after}, []byte("up\n"))
+	assert.Equal(t, "up\n", readTestFile(filepath.Join(dir, ".._up", "2021-03-02.log")))
+}
+
+////
+// NewChannelDateWriter - Use as the writer for the package logger
+// 1) Log single and multi-line entries on two channels from several
+//    goroutines
+//  -> Each channel's lines in its own file for today
+// 2) Close the writer and log again
+//  -> The file is reopened and appended to
+////
+func Test_AlogFiles_ChannelDateLogging(t *testing.T) {
+	dir := t.TempDir()
+	w := NewChannelDateWriter(dir)
+	defer w.Close()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	wg := sync.WaitGroup{}
+	for _, ch := range []LogChannel{"ONE", "TWO"} {
+		wg.Add(1)
+		go func(ch LogChannel) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				Log(ch, INFO, "line %d", i)
+			}
+		}(ch)
+	}
+	wg.Wait()
+	Log("ONE", INFO, "first\nsecond")
+
+	today := time.Now().UTC().Format("2006-01-02") + ".log"
+	one := readTestFile(filepath.Join(dir, "ONE", today))
+	assert.Equal(t, 12, strings.Count(one, "\n"))
+	assert.Contains(t, one, "] second\n")
+	assert.NotContains(t, one, "[TWO")
+	assert.Equal(t, 10, strings.Count(readTestFile(filepath.Join(dir, "TWO", today)), "\n"))
+
+	// Reopen
+	assert.Nil(t, w.Close())
+	Log("TWO", INFO, "after close")
+	assert.Equal(t, 11, strings.Count(readTestFile(filepath.Join(dir, "TWO", today)), "\n"))
+}
+
+////
+// NewChannelDateWriter - Gzip compressed files
+// 1) Enable gzip and write entries across midnight
+//  -> The rotated file is complete gzip before Close
+// 2) Flush
+//  -> The current file can be decompressed
+// 3) Close, write again and close
+//  -> The reopened file decompresses to both lines
+////
+func Test_AlogFiles_ChannelDateGzip(t *testing.T) {
+	dir := t.TempDir()
+	w := NewChannelDateWriter(dir)
+	w.EnableGzip()
+	defer w.Close()
+
+	before := time.Date(2021, 3, 1, 23, 59, 59, 0, time.UTC)
+	after := time.Date(2021, 3, 2, 0, 0, 1, 0, time.UTC)
+	w.WriteEntry(&LogEntry{Channel: "AUDIT", Timestamp: before}, []byte("before\n"))
+	w.WriteEntry(&LogEntry{Channel: "AUDIT", Timestamp: after}, []byte("after\n"))
+	assert.Equal(t, "before\n",
+		gunzipTest(t, []byte(readTestFile(filepath.Join(dir, "AUDIT", "2021-03-01.log.gz")))))
+
+	// Flush
+	assert.Nil(t, w.Flush())
+	f, err := os.Open(filepath.Join(dir, "AUDIT", "2021-03-02.log.gz"))
+	assert.Nil(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	partial := make([]byte, 64)
+	n, _ := r.Read(partial)
+	assert.Equal(t, "after\n", string(partial[:n]))
+
+	// Reopen
+	assert.Nil(t, w.Close())
+	w.WriteEntry(&LogEntry{Channel: "AUDIT", Timestamp: after}, []byte("again\n"))
+	assert.Nil(t, w.Close())
+	assert.Equal(t, "after\nagain\n",
+		gunzipTest(t, []byte(readTestFile(filepath.Join(dir, "AUDIT", "2021-03-02.log.gz")))))
+}
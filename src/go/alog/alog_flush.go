@@ -0,0 +1,93 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"sync"
+	"time"
+)
+
+//-- Periodic Flush ------------------------------------------------------------
+
+// State of the background goroutine that flushes the writers on an interval
+var periodicFlush struct {
+	mutex sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// StartPeriodicFlush - Start a goroutine that calls Flush every d so that
+// lines held by a buffered writer reach their destination within a bounded
+// time, even on an idle service. Only one such goroutine runs at a time:
+// calling this again stops the running one and starts a new one with the new
+// interval. A d of zero or less only stops the running goroutine. Errors from
+// the writers are ignored; call Flush directly to see them.
+func StartPeriodicFlush(d time.Duration) {
+	periodicFlush.mutex.Lock()
+	defer periodicFlush.mutex.Unlock()
+	stopPeriodicFlush()
+	if d <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	periodicFlush.stop = stop
+	periodicFlush.done = done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				Flush()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopPeriodicFlush - Stop the goroutine started by StartPeriodicFlush and
+// wait for it to exit. This is a no-op if it is not running.
+func StopPeriodicFlush() {
+	periodicFlush.mutex.Lock()
+	stopPeriodicFlush()
+	periodicFlush.mutex.Unlock()
+}
+
+// Stop the periodic flush goroutine if it is running
+//
+// NOTE: Must be called with the periodic flush mutex held
+////
+func stopPeriodicFlush() {
+	if nil == periodicFlush.stop {
+		return
+	}
+	close(periodicFlush.stop)
+	<-periodicFlush.done
+	periodicFlush.stop = nil
+	periodicFlush.done = nil
+}
@@ -0,0 +1,105 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Periodic Flush //////////////////////////////////////////////////////
+
+// Writer that counts calls to Flush
+type flushCountWriter struct {
+	nFlushed int32
+}
+
+func (w *flushCountWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *flushCountWriter) Flush() error {
+	atomic.AddInt32(&w.nFlushed, 1)
+	return nil
+}
+
+////
+// StartPeriodicFlush - Flush the writer on an interval
+// 1) Start the periodic flush with a short interval, twice
+//  -> Writer flushed repeatedly
+// 2) Stop the periodic flush, twice
+//  -> No further flushes
+////
+func Test_AlogFlush_Periodic(t *testing.T) {
+	w := &flushCountWriter{}
+	SetWriter(w)
+	defer ResetAll()
+
+	// Start
+	StartPeriodicFlush(time.Hour)
+	StartPeriodicFlush(5 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&w.nFlushed) >= 3
+	}, time.Second, time.Millisecond)
+
+	// Stop
+	StopPeriodicFlush()
+	StopPeriodicFlush()
+	n := atomic.LoadInt32(&w.nFlushed)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadInt32(&w.nFlushed))
+}
+
+////
+// StartPeriodicFlush - Non-positive intervals
+// 1) Start the periodic flush, then start it again with a zero interval
+//  -> No panic, running flush stopped
+// 2) Start it with a negative interval
+//  -> No panic, nothing started
+////
+func Test_AlogFlush_NonPositive(t *testing.T) {
+	w := &flushCountWriter{}
+	SetWriter(w)
+	defer ResetAll()
+
+	// Zero
+	StartPeriodicFlush(time.Hour)
+	assert.NotPanics(t, func() { StartPeriodicFlush(0) })
+	periodicFlush.mutex.Lock()
+	assert.Nil(t, periodicFlush.stop)
+	periodicFlush.mutex.Unlock()
+
+	// Negative
+	assert.NotPanics(t, func() { StartPeriodicFlush(-time.Second) })
+	periodicFlush.mutex.Lock()
+	assert.Nil(t, periodicFlush.stop)
+	periodicFlush.mutex.Unlock()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&w.nFlushed))
+}
@@ -0,0 +1,122 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+//-- Function Results ----------------------------------------------------------
+
+// Create a FnLog scope that reports the error stored in *result when it is
+// closed. As with fnLogImpl, depth is the caller depth of the function whose
+// name is logged.
+func (cfg *alogger) fnLogResultImpl(depth int, e LogEntry, result *error) ScopedLogger {
+	testHelper()()
+	scope := cfg.fnLogImpl(depth+1, e)
+	if impl, ok := scope.(*scopedLoggerImpl); ok {
+		impl.result = result
+	}
+	return scope
+}
+
+// Add the result of a function to its End entry, as "-> ok" or "-> error: ..."
+// after the message and, for an error, as error in the map data
+func addScopeResult(end *LogEntry, err error) {
+	if nil == err {
+		end.Format += " -> ok"
+		return
+	}
+	end.Format += " -> error: %s"
+	end.Expansion = append(append([]interface{}{}, end.Expansion...), err.Error())
+	mapData := make(map[string]interface{}, len(end.MapData)+1)
+	for k, v := range end.MapData {
+		mapData[k] = v
+	}
+	mapData["error"] = err.Error()
+	end.MapData = mapData
+	end.formatKeys = append(append([]string{}, end.formatKeys...), "error")
+}
+
+// FnLogR - Like FnLog, but the End line also reports the error that result
+// points to when the scope is closed. Since a deferred call can only see the
+// return values of a function if they are named, this is used with a named
+// error return:
+//
+//	func foo() (err error) {
+//	  defer alog.FnLogR(ch, &err, "").Close()
+//	  ...
+//	}
+//
+// This is always logged to the TRACE level.
+func FnLogR(channel LogChannel, result *error, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return std.fnLogResultImpl(2, LogEntry{
+		Channel:   channel,
+		Level:     TRACE,
+		Format:    format,
+		Expansion: v,
+	}, result)
+}
+
+// TraceFn - Run fn inside a FnLog scope named by the calling function and
+// return its error. The End line reports the error, so unlike FnLogR this does
+// not require a named return value:
+//
+//	func foo() error {
+//	  return alog.TraceFn(ch, func() error {
+//	    ...
+//	  })
+//	}
+//
+// This is always logged to the TRACE level.
+func TraceFn(channel LogChannel, fn func() error) error {
+	testHelper()()
+	var err error
+	defer std.fnLogResultImpl(2, LogEntry{Channel: channel, Level: TRACE}, &err).Close()
+	err = fn()
+	return err
+}
+
+// FnLogR - FnLogR for a LogChannel instance
+func (ch *channelLogImpl) FnLogR(result *error, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return ch.cfg.fnLogResultImpl(2, ch.entry(TRACE, nil, format, v), result)
+}
+
+// TraceFn - TraceFn for a LogChannel instance
+func (ch *channelLogImpl) TraceFn(fn func() error) error {
+	testHelper()()
+	var err error
+	defer ch.cfg.fnLogResultImpl(2, ch.entry(TRACE, nil, "", nil), &err).Close()
+	err = fn()
+	return err
+}
+
+// FnLogR - Returns a ScopedLogger that does nothing
+func (nopChannelLog) FnLogR(result *error, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+
+// TraceFn - Runs fn without logging
+func (nopChannelLog) TraceFn(fn func() error) error {
+	return fn()
+}
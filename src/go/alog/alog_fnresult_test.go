@@ -0,0 +1,153 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"errors"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Function Results ////////////////////////////////////////////////////
+
+var errFnResult = errors.New("boom")
+
+func fnResultNamed(ch ChannelLogger, fail bool) (err error) {
+	defer ch.FnLogR(&err, "fail=%v", fail).Close()
+	if fail {
+		return errFnResult
+	}
+	return nil
+}
+
+func fnResultPackage(fail bool) (err error) {
+	defer FnLogR("TEST", &err, "").Close()
+	if fail {
+		err = errFnResult
+	}
+	return
+}
+
+func fnResultTraced(ch ChannelLogger, fail bool) error {
+	return ch.TraceFn(func() error {
+		ch.Log(INFO, "Inside")
+		if fail {
+			return errFnResult
+		}
+		return nil
+	})
+}
+
+func fnResultTracedPackage() error {
+	return TraceFn("TEST", func() error { return errFnResult })
+}
+
+////
+// FnLogR - Report the returned error on the End line
+// 1) Return nil and an error from a function using ch.FnLogR
+//  -> End line ends with "-> ok" or "-> error: boom"
+// 2) Do the same with the package-level FnLogR
+//  -> Same result with the name of the calling function
+// 3) Log with JSON
+//  -> error only in the map data of a failed End entry
+////
+func Test_AlogFnResult_FnLogR(t *testing.T) {
+	ConfigDefaultLevel(TRACE)
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ch := UseChannel("TEST")
+
+	assert.Nil(t, fnResultNamed(ch, false))
+	assert.Equal(t, errFnResult, fnResultNamed(ch, true))
+	assert.Nil(t, fnResultPackage(false))
+	assert.Equal(t, errFnResult, fnResultPackage(true))
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultNamed(fail=false)"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultNamed(fail=false) -> ok"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultNamed(fail=true)"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultNamed(fail=true) -> error: boom"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultPackage()"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultPackage() -> ok"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultPackage()"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultPackage() -> error: boom"},
+	}))
+
+	// JSON
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	fnResultNamed(ch, false)
+	fnResultNamed(ch, true)
+	logged := w.Entries()
+	if assert.Len(t, logged, 4) {
+		assert.NotContains(t, logged[1].MapData, "error")
+		assert.Equal(t, "boom", logged[3].MapData["error"])
+		assert.Equal(t, "End: fnResultNamed(fail=true) -> error: boom", logged[3].Format)
+	}
+}
+
+////
+// TraceFn - Run a closure inside a FnLog scope
+// 1) Trace a closure that succeeds and one that fails through a ChannelLog
+//  -> Start/End named by the enclosing function, indented body, result on End
+// 2) Trace with the package-level TraceFn
+//  -> The error is returned and reported
+// 3) Trace through a no-op ChannelLog
+//  -> The closure still runs and its error is returned
+////
+func Test_AlogFnResult_TraceFn(t *testing.T) {
+	ConfigDefaultLevel(TRACE)
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ch := UseChannel("TEST")
+
+	assert.Nil(t, fnResultTraced(ch, false))
+	assert.Equal(t, errFnResult, fnResultTraced(ch, true))
+	assert.Equal(t, errFnResult, fnResultTracedPackage())
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultTraced()"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Inside", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultTraced() -> ok"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultTraced()"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Inside", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultTraced() -> error: boom"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: fnResultTracedPackage()"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: fnResultTracedPackage() -> error: boom"},
+	}))
+
+	// No-op
+	ran := false
+	err := NopChannelLog().TraceFn(func() error {
+		ran = true
+		return errFnResult
+	})
+	assert.True(t, ran)
+	assert.Equal(t, errFnResult, err)
+}
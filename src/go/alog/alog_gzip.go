@@ -0,0 +1,91 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+//-- Gzip Writer ---------------------------------------------------------------
+
+// GzipWriter - io.Writer implementation that gzip-compresses everything written
+// to it before passing it to the wrapped writer. All methods are safe to call
+// from multiple goroutines.
+//
+// NOTE: The compressor holds on to recent input until it has enough to emit a
+//  block, so the tail of the log only reaches the wrapped writer on Flush (or
+//  Close). Use Flush, StartPeriodicFlush or the package Flush function to keep
+//  the output current. The stream is only valid gzip once Close is called.
+////
+type GzipWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	gz     *gzip.Writer
+}
+
+// NewGzipWriter - Create a writer that writes a gzip stream to w and can be
+// passed to SetWriter. The writer is a *GzipWriter, so Flush and Close are
+// available through a type assertion. Use WrapGzipWriter to get the concrete
+// type directly.
+func NewGzipWriter(w io.Writer) io.Writer {
+	return WrapGzipWriter(w)
+}
+
+// WrapGzipWriter - Create a GzipWriter that writes a gzip stream to w
+func WrapGzipWriter(w io.Writer) *GzipWriter {
+	return &GzipWriter{writer: w, gz: gzip.NewWriter(w)}
+}
+
+// Write - Compress a line into the stream
+func (w *GzipWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.gz.Write(p)
+}
+
+// Flush - Write all pending compressed data to the wrapped writer and flush
+// it if it supports it. The output so far can then be decompressed, though it
+// is not a complete gzip stream until Close.
+func (w *GzipWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.gz.Flush(); nil != err {
+		return err
+	}
+	return flushWriter(w.writer)
+}
+
+// Close - Finish the gzip stream and flush the wrapped writer. The wrapped
+// writer is not closed. Writing after Close returns an error.
+func (w *GzipWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.gz.Close(); nil != err {
+		return err
+	}
+	return flushWriter(w.writer)
+}
@@ -0,0 +1,99 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Gzip Writer /////////////////////////////////////////////////////////
+
+// Decompress a gzip stream in a test
+func gunzipTest(t *testing.T, data []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	out, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	return string(out)
+}
+
+////
+// GzipWriter - Test compressing log lines
+//
+// 1) Log a few lines through a GzipWriter and Flush
+//  -> Lines can be decompressed before Close
+// 2) Log another line and Close
+//  -> Complete stream holds all lines
+//  -> Writing after Close fails
+// 3) Create a writer with NewGzipWriter
+//  -> Writer is a *GzipWriter
+////
+func Test_AlogGzip_Writer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := WrapGzipWriter(buf)
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Flush
+	Log("TEST", INFO, "one")
+	Log("TEST", INFO, "two")
+	Log("TEST", INFO, "three")
+	assert.Nil(t, Flush())
+	r, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	partial := make([]byte, 4096)
+	n, _ := r.Read(partial)
+	lines := strings.Split(strings.TrimSuffix(string(partial[:n]), "\n"), "\n")
+	if assert.Len(t, lines, 3) {
+		for i, msg := range []string{"one", "two", "three"} {
+			e, err := JSONToLogEntry(lines[i])
+			assert.Nil(t, err)
+			assert.Equal(t, msg, e.Format)
+		}
+	}
+
+	// Close
+	Log("TEST", INFO, "four")
+	assert.Nil(t, w.Close())
+	assert.Equal(t, 4, strings.Count(gunzipTest(t, buf.Bytes()), "\n"))
+	_, err = w.Write([]byte("late\n"))
+	assert.NotNil(t, err)
+
+	// Interface constructor
+	_, ok := NewGzipWriter(&bytes.Buffer{}).(*GzipWriter)
+	assert.True(t, ok)
+}
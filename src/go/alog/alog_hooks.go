@@ -0,0 +1,128 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+//-- Hooks ---------------------------------------------------------------------
+
+// Hook - Function called with each entry that passes level filtering, before it
+// is formatted. The hook may modify the entry (e.g. to scrub e.Format or
+// e.MapData) and returns false to drop the entry entirely.
+//
+// Hooks run after the level and max indent depth checks and after the runtime
+// fields (timestamp, service name, labels, scope) are filled in, so a hook may
+// also overwrite those. They run before the entry is counted in the stats and
+// before it is formatted, so dropped entries are not counted as emitted and
+// the message redactors see the message as left by the hooks, unless
+// RedactionHook is registered to redact earlier. Any sampling of entries
+// should be done by a hook registered at the position where it should apply.
+//
+// NOTE: Hooks are called inside the logger's lock, so a hook must not log or
+//  change the logging configuration
+////
+type Hook func(e *LogEntry) bool
+
+// HookHandle - Handle identifying a registered hook, used to remove it
+type HookHandle uint64
+
+// A single registered hook
+type registeredHook struct {
+	handle HookHandle
+	fn     Hook
+}
+
+// The registered hooks are held as an immutable slice that is replaced on
+// registration so that the log path can read it without locking
+var (
+	hooks          atomic.Value
+	hooksLock      sync.Mutex
+	nextHookHandle HookHandle
+)
+
+// AddHook - Register a hook to run after all hooks that are already registered
+func AddHook(fn Hook) HookHandle {
+	return AddHookAt(-1, fn)
+}
+
+// AddHookAt - Register a hook at the given position in the hook pipeline, so
+// that it runs before the hook currently at that index. An index that is
+// negative or past the end appends the hook. The returned handle identifies
+// the hook for RemoveHook.
+func AddHookAt(index int, fn Hook) HookHandle {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	current, _ := hooks.Load().([]registeredHook)
+	if index < 0 || index > len(current) {
+		index = len(current)
+	}
+	nextHookHandle++
+	updated := make([]registeredHook, 0, len(current)+1)
+	updated = append(updated, current[:index]...)
+	updated = append(updated, registeredHook{handle: nextHookHandle, fn: fn})
+	updated = append(updated, current[index:]...)
+	hooks.Store(updated)
+	return nextHookHandle
+}
+
+// RemoveHook - Remove a registered hook. The remaining hooks keep their order.
+// Returns false if the handle does not identify a registered hook.
+func RemoveHook(handle HookHandle) bool {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	current, _ := hooks.Load().([]registeredHook)
+	for i, h := range current {
+		if h.handle == handle {
+			updated := make([]registeredHook, 0, len(current)-1)
+			updated = append(updated, current[:i]...)
+			updated = append(updated, current[i+1:]...)
+			hooks.Store(updated)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearHooks - Remove all registered hooks
+func ClearHooks() {
+	hooksLock.Lock()
+	hooks.Store([]registeredHook{})
+	hooksLock.Unlock()
+}
+
+// Run the registered hooks in order on an entry, stopping at the first hook
+// that drops it. Returns false if the entry was dropped.
+func runHooks(e *LogEntry) bool {
+	current, _ := hooks.Load().([]registeredHook)
+	for _, h := range current {
+		if !h.fn(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,229 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"regexp"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Hooks ///////////////////////////////////////////////////////////////
+
+////
+// AddHookAt - Control the order of the hook pipeline
+// 1) Add hooks A and C, then insert B at index 1 and Z at index 0
+//  -> Hooks run in the order Z, A, B, C
+// 2) Add a hook with an index past the end
+//  -> Hook appended
+// 3) Log a disabled entry
+//  -> No hooks run
+////
+func Test_AlogHooks_Order(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetWriter(NewMemoryWriter())
+	defer ResetAll()
+
+	order := []string{}
+	hook := func(name string) Hook {
+		return func(e *LogEntry) bool {
+			order = append(order, name)
+			return true
+		}
+	}
+	AddHook(hook("A"))
+	AddHook(hook("C"))
+	AddHookAt(1, hook("B"))
+	AddHookAt(0, hook("Z"))
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, []string{"Z", "A", "B", "C"}, order)
+
+	// Past the end
+	order = []string{}
+	AddHookAt(10, hook("D"))
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, []string{"Z", "A", "B", "C", "D"}, order)
+
+	// Disabled
+	order = []string{}
+	Log("TEST", DEBUG, "Hello")
+	assert.Equal(t, 0, len(order))
+}
+
+////
+// RemoveHook - Remove hooks by handle
+// 1) Add three hooks and remove the middle one
+//  -> Remaining hooks run in their original order
+// 2) Remove the same handle again
+//  -> Returns false
+// 3) Clear the hooks
+//  -> No hooks run
+////
+func Test_AlogHooks_Remove(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetWriter(NewMemoryWriter())
+	defer ResetAll()
+
+	order := []string{}
+	hook := func(name string) Hook {
+		return func(e *LogEntry) bool {
+			order = append(order, name)
+			return true
+		}
+	}
+	AddHook(hook("A"))
+	h := AddHook(hook("B"))
+	AddHook(hook("C"))
+	assert.True(t, RemoveHook(h))
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, []string{"A", "C"}, order)
+
+	// Remove again
+	assert.False(t, RemoveHook(h))
+
+	// Clear
+	order = []string{}
+	ClearHooks()
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, 0, len(order))
+}
+
+////
+// Hooks - Redact before shipping
+// 1) Add a hook that ships the message, then insert a redacting hook before it
+//  -> Shipped message is redacted
+//  -> Written line is redacted
+////
+func Test_AlogHooks_RedactBeforeShip(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	defer ResetAll()
+
+	shipped := []string{}
+	AddHook(func(e *LogEntry) bool {
+		shipped = append(shipped, e.Format)
+		return true
+	})
+	AddHookAt(0, func(e *LogEntry) bool {
+		if e.Format == "token %s" {
+			e.Format = "token [REDACTED]"
+			e.Expansion = nil
+		}
+		return true
+	})
+	Log("TEST", INFO, "token %s", "secret")
+	assert.Equal(t, []string{"token [REDACTED]"}, shipped)
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "token [REDACTED]", entries[0].Format)
+	}
+}
+
+////
+// AddHook - Mutate entries before they are formatted
+// 1) Add a hook that scrubs an email from the message and map data and adds an
+//    enrichment field, then log with both formatters
+//  -> Scrubbed and enriched in the JSON output
+//  -> Scrubbed in the std output
+////
+func Test_AlogHooks_Mutate(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	defer ResetAll()
+
+	email := regexp.MustCompile(`[a-z]+@[a-z]+\.com`)
+	AddHook(func(e *LogEntry) bool {
+		e.Format = email.ReplaceAllString(fmt.Sprintf(e.Format, e.Expansion...), "<email>")
+		e.Expansion = nil
+		mapData := map[string]interface{}{"region": "eu"}
+		for k, v := range e.MapData {
+			if s, ok := v.(string); ok {
+				v = email.ReplaceAllString(s, "<email>")
+			}
+			mapData[k] = v
+		}
+		e.MapData = mapData
+		return true
+	})
+
+	// JSON
+	LogWithMap("TEST", INFO, map[string]interface{}{"user": "bob@example.com"},
+		"Signed up %s", "bob@example.com")
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "Signed up <email>", entries[0].Format)
+		assert.Equal(t, map[string]interface{}{
+			"user":   "<email>",
+			"region": "eu",
+		}, entries[0].MapData)
+	}
+
+	// Std
+	w.Reset()
+	UseStdLogFormatter()
+	Log("TEST", INFO, "Hello %s", "bob@example.com")
+	for _, line := range w.Lines() {
+		assert.NotContains(t, line, "bob@example.com")
+	}
+	assert.Equal(t, 2, len(w.Lines()))
+}
+
+////
+// AddHook - Drop entries
+// 1) Add a hook that drops entries on a noisy channel, followed by a hook
+//    that records what it sees
+//  -> Dropped entries are not written, counted or passed to later hooks
+//  -> Other entries are written
+////
+func Test_AlogHooks_Drop(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	w := NewMemoryWriter()
+	SetWriter(w)
+	defer ResetAll()
+	ResetStats()
+
+	AddHook(func(e *LogEntry) bool {
+		return e.Channel != "NOISY"
+	})
+	seen := []string{}
+	AddHook(func(e *LogEntry) bool {
+		seen = append(seen, string(e.Channel))
+		return true
+	})
+	Log("NOISY", INFO, "Chatter")
+	LogMap("NOISY", INFO, map[string]interface{}{"key": "val"})
+	Log("TEST", INFO, "Important")
+	assert.Equal(t, []string{"TEST"}, seen)
+	assert.True(t, VerifyLogs(w.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Important"},
+	}))
+	assert.Equal(t, uint64(1), GetStats().Emitted[INFO])
+}
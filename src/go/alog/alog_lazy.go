@@ -0,0 +1,147 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+// This file holds the lazily evaluated log functions. The closure passed to
+// each one is only invoked if the channel and level are enabled, and it is
+// always invoked outside of the logger's lock so that it is free to log itself.
+// It also holds lazy map data values, which are evaluated when the entry is
+// written.
+
+//-- Lazy Values ---------------------------------------------------------------
+
+// LazyValue - Interface for a map data value (or format argument) that is
+// expensive to compute. Value is only called if the entry is actually written
+// (or buffered by EnableErrorContext, or passed to RedactionHook), and only
+// once per entry no matter how many formatters and writers it goes to.
+//
+// NOTE: Value is called inside the logger's lock, so it must not log or change
+//  the logging configuration
+////
+type LazyValue interface {
+	Value() interface{}
+}
+
+// LazyFunc - LazyValue implementation backed by a function, e.g.
+// alog.LazyFunc(func() interface{} { return expensive() })
+type LazyFunc func() interface{}
+
+// Value - Call the function
+func (f LazyFunc) Value() interface{} {
+	return f()
+}
+
+// Replace the lazy values in an entry's map data and expansion with their
+// values. The caller's map and slice are copied rather than modified, and are
+// left in place if they hold no lazy values.
+func resolveLazyValues(e *LogEntry) {
+	for _, v := range e.MapData {
+		if _, ok := v.(LazyValue); ok {
+			resolved := make(map[string]interface{}, len(e.MapData))
+			for k, v := range e.MapData {
+				if lv, ok := v.(LazyValue); ok {
+					v = lv.Value()
+				}
+				resolved[k] = v
+			}
+			e.MapData = resolved
+			break
+		}
+	}
+	for _, v := range e.Expansion {
+		if _, ok := v.(LazyValue); ok {
+			resolved := make([]interface{}, len(e.Expansion))
+			for i, v := range e.Expansion {
+				if lv, ok := v.(LazyValue); ok {
+					v = lv.Value()
+				}
+				resolved[i] = v
+			}
+			e.Expansion = resolved
+			break
+		}
+	}
+}
+
+//-- Package Level Lazy Log Functions ------------------------------------------
+
+// LogFunc - Log the message returned by fn only if the channel and level are
+// enabled. The message is logged as-is and is not treated as a format string.
+func LogFunc(channel LogChannel, level LogLevel, fn func() string) {
+	testHelper()()
+	if !std.enabledOrSuppressed(channel, level) {
+		return
+	}
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    "%s",
+		Expansion: []interface{}{fn()},
+	})
+}
+
+// LogMapFunc - Log the map returned by fn only if the channel and level are
+// enabled
+func LogMapFunc(channel LogChannel, level LogLevel, fn func() map[string]interface{}) {
+	testHelper()()
+	if !std.enabledOrSuppressed(channel, level) {
+		return
+	}
+	std.log(LogEntry{
+		Channel: channel,
+		Level:   level,
+		MapData: fn(),
+	})
+}
+
+//-- Channel Log Lazy Log Functions --------------------------------------------
+
+// LogFunc - LogFunc for a LogChannel instance
+func (ch *channelLogImpl) LogFunc(level LogLevel, fn func() string) {
+	testHelper()()
+	if !ch.cfg.enabledOrSuppressed(ch.channel, level) {
+		return
+	}
+	ch.cfg.log(ch.entry(level, nil, "%s", []interface{}{fn()}))
+}
+
+// LogMapFunc - LogMapFunc for a LogChannel instance
+func (ch *channelLogImpl) LogMapFunc(level LogLevel, fn func() map[string]interface{}) {
+	testHelper()()
+	if !ch.cfg.enabledOrSuppressed(ch.channel, level) {
+		return
+	}
+	ch.cfg.log(ch.entry(level, fn(), "", nil))
+}
+
+//-- Nop Channel Log Lazy Log Functions ----------------------------------------
+
+// LogFunc - No-op, fn is never called
+func (nopChannelLog) LogFunc(level LogLevel, fn func() string) {
+}
+
+// LogMapFunc - No-op, fn is never called
+func (nopChannelLog) LogMapFunc(level LogLevel, fn func() map[string]interface{}) {
+}
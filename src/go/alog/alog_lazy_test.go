@@ -0,0 +1,200 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Lazy Log Functions //////////////////////////////////////////////////
+
+////
+// LogFunc - Closures only run when enabled
+// 1) Configure the default level to INFO
+// 2) Use LogFunc and LogMapFunc at a disabled level
+//  -> Closures not called, nothing logged
+// 3) Use LogFunc and LogMapFunc at an enabled level
+//  -> Closures called once each and their output logged
+////
+func Test_AlogLazy_Package(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Disabled
+	nCalls := 0
+	msgFn := func() string {
+		nCalls++
+		return "100% lazy"
+	}
+	mapFn := func() map[string]interface{} {
+		nCalls++
+		return map[string]interface{}{"key": "val"}
+	}
+	LogFunc("TEST", DEBUG, msgFn)
+	LogMapFunc("TEST", DEBUG, mapFn)
+	assert.Equal(t, 0, nCalls)
+	assert.Equal(t, 0, len(entries))
+
+	// Enabled
+	LogFunc("TEST", INFO, msgFn)
+	LogMapFunc("TEST", INFO, mapFn)
+	assert.Equal(t, 2, nCalls)
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "100% lazy"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "key: val"},
+	}))
+}
+
+////
+// LogFunc - Closures on a ChannelLog may log themselves
+// 1) Configure the channel to DEBUG and create a ChannelLog with fields
+// 2) Use LogFunc with a closure that itself logs
+//  -> No deadlock, inner line logged before the outer one, fields merged
+// 3) Use LogFunc on NopChannelLog
+//  -> Closure not called
+////
+func Test_AlogLazy_Channel(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	Config(OFF, ChannelMap{"TEST": DEBUG})
+	defer ResetDefaults()
+
+	// Closure that logs
+	ch := UseChannel("TEST").WithFields(map[string]interface{}{"f": 1})
+	ch.LogFunc(DEBUG, func() string {
+		Log("TEST", INFO, "Inner")
+		return "Outer"
+	})
+	ch.LogMapFunc(DEBUG, func() map[string]interface{} {
+		return map[string]interface{}{"g": 2}
+	})
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Inner"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "Outer"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "f: 1"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "f: 1"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "g: 2"},
+	}))
+
+	// Nop
+	called := false
+	NopChannelLog().LogFunc(INFO, func() string {
+		called = true
+		return ""
+	})
+	assert.False(t, called)
+}
+
+// Benchmarks - Lazy Log Functions /////////////////////////////////////////////
+
+// Deliberately expensive field computation
+func expensiveFields() map[string]interface{} {
+	m := map[string]interface{}{}
+	for i := 0; i < 16; i++ {
+		m[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	return m
+}
+
+func Benchmark_AlogLazy_DisabledLogMap(b *testing.B) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	for i := 0; i < b.N; i++ {
+		LogMap("BNCH", DEBUG, expensiveFields())
+	}
+}
+
+func Benchmark_AlogLazy_DisabledLogMapFunc(b *testing.B) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	for i := 0; i < b.N; i++ {
+		LogMapFunc("BNCH", DEBUG, expensiveFields)
+	}
+}
+
+func Benchmark_AlogLazy_EnabledLogMapFunc(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	for i := 0; i < b.N; i++ {
+		LogMapFunc("BNCH", INFO, expensiveFields)
+	}
+}
+
+////
+// LazyValue - Map data values only evaluated when written
+// 1) Log a lazy map value and format argument at a disabled level
+//  -> Value not evaluated
+// 2) Log them at an enabled level with dual output
+//  -> Each value evaluated once and rendered by both formatters
+//  -> Caller's map still holds the lazy value
+////
+func Test_AlogLazy_Value(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	defer DisableDualOutput()
+	stdW := NewMemoryWriter()
+	jsonW := NewMemoryWriter()
+	EnableDualOutput(stdW, jsonW)
+
+	nCalls := 0
+	lazy := LazyFunc(func() interface{} {
+		nCalls++
+		return "expensive"
+	})
+	mapData := map[string]interface{}{"big": lazy, "small": 1}
+
+	// Disabled
+	LogWithMap("TEST", DEBUG, mapData, "value %v", lazy)
+	LogValue("TEST", DEBUG, "big", lazy)
+	assert.Equal(t, 0, nCalls)
+
+	// Enabled
+	LogWithMap("TEST", INFO, mapData, "value %v", lazy)
+	assert.Equal(t, 2, nCalls)
+	assert.True(t, VerifyLogs(stdW.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "value expensive"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "big: expensive"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "small: 1"},
+	}))
+	if entries := jsonW.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "value expensive", entries[0].Format)
+		assert.Equal(t, "expensive", entries[0].MapData["big"])
+	}
+	_, stillLazy := mapData["big"].(LazyValue)
+	assert.True(t, stillLazy)
+}
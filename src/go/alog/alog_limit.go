@@ -0,0 +1,130 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"io"
+	"sync"
+)
+
+//-- Byte Limit Writer ---------------------------------------------------------
+
+// ByteLimitWriter - io.Writer implementation that counts the bytes written to
+// the wrapped writer and calls a function when a line would take the total
+// past a limit. This guards against logs filling a disk.
+//
+// By default lines keep being written after the limit is reached. With
+// SetDropAfterLimit, lines that would exceed the limit are dropped instead, so
+// the wrapped writer never receives more than the limit. All methods are safe
+// to call from multiple goroutines.
+//
+// NOTE: onLimit is called from Write, which runs inside the logger's lock. It
+//  must not log or change the logging configuration directly. To rotate by
+//  installing a new writer, do so from a separate goroutine.
+////
+type ByteLimitWriter struct {
+	mutex    sync.Mutex
+	writer   io.Writer
+	maxBytes int64
+	onLimit  func()
+	written  int64
+	dropped  uint64
+	reached  bool
+	drop     bool
+}
+
+// NewByteLimitWriter - Create a ByteLimitWriter that calls onLimit once when a
+// line would take the bytes written to w past maxBytes. onLimit may be nil.
+func NewByteLimitWriter(w io.Writer, maxBytes int64, onLimit func()) *ByteLimitWriter {
+	return &ByteLimitWriter{
+		writer:   w,
+		maxBytes: maxBytes,
+		onLimit:  onLimit,
+	}
+}
+
+// SetDropAfterLimit - Set whether lines that would take the total past the
+// limit are dropped rather than written
+func (w *ByteLimitWriter) SetDropAfterLimit(drop bool) {
+	w.mutex.Lock()
+	w.drop = drop
+	w.mutex.Unlock()
+}
+
+// Write - Write a single line, calling onLimit the first time the limit is
+// reached. Dropped lines are not reported as errors; use DroppedCount to find
+// out how many were lost.
+func (w *ByteLimitWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	over := w.written+int64(len(p)) > w.maxBytes
+	fire := over && !w.reached
+	if over {
+		w.reached = true
+	}
+	drop := over && w.drop
+	if drop {
+		w.dropped++
+	} else {
+		w.written += int64(len(p))
+	}
+	w.mutex.Unlock()
+
+	// Call the handler outside of the lock so that it is free to call Reset
+	if fire && nil != w.onLimit {
+		w.onLimit()
+	}
+	if drop {
+		return len(p), nil
+	}
+	return w.writer.Write(p)
+}
+
+// Written - Get the number of bytes written since creation or the last Reset
+func (w *ByteLimitWriter) Written() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.written
+}
+
+// DroppedCount - Get the number of lines dropped because of the limit
+func (w *ByteLimitWriter) DroppedCount() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.dropped
+}
+
+// Reset - Set the byte count back to zero and re-arm onLimit, e.g. after the
+// wrapped file has been rotated
+func (w *ByteLimitWriter) Reset() {
+	w.mutex.Lock()
+	w.written = 0
+	w.reached = false
+	w.mutex.Unlock()
+}
+
+// Flush - Flush the wrapped writer if it supports it
+func (w *ByteLimitWriter) Flush() error {
+	return flushWriter(w.writer)
+}
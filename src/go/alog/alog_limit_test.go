@@ -0,0 +1,120 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Byte Limit Writer ///////////////////////////////////////////////////
+
+////
+// ByteLimitWriter - Keep writing past the limit
+// 1) Write lines up to the limit
+//  -> All written, onLimit not called
+// 2) Write lines past the limit
+//  -> onLimit called once, lines still written
+// 3) Reset and write past the limit again
+//  -> onLimit called again
+////
+func Test_AlogLimit_KeepWriting(t *testing.T) {
+	mw := NewMemoryWriter()
+	nLimit := 0
+	w := NewByteLimitWriter(mw, 10, func() { nLimit++ })
+
+	// Up to the limit
+	w.Write([]byte("12345"))
+	w.Write([]byte("67890"))
+	assert.Equal(t, 0, nLimit)
+	assert.Equal(t, int64(10), w.Written())
+
+	// Past the limit
+	n, err := w.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	assert.Nil(t, err)
+	w.Write([]byte("def"))
+	assert.Equal(t, 1, nLimit)
+	assert.Equal(t, 4, len(mw.Lines()))
+	assert.Equal(t, int64(16), w.Written())
+	assert.Equal(t, uint64(0), w.DroppedCount())
+
+	// Reset
+	w.Reset()
+	assert.Equal(t, int64(0), w.Written())
+	w.Write([]byte("12345678901"))
+	assert.Equal(t, 2, nLimit)
+}
+
+////
+// ByteLimitWriter - Drop lines past the limit
+// 1) Configure a logger with a dropping ByteLimitWriter and log past the limit
+//  -> onLimit called once
+//  -> Lines that fit are written, later lines dropped and counted
+//  -> Total written never exceeds the limit
+// 2) Reset from onLimit and log past the limit again
+//  -> onLimit called again, only the line that hit the limit is dropped
+////
+func Test_AlogLimit_Drop(t *testing.T) {
+	mw := NewMemoryWriter()
+	SetWriter(mw)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Measure a single line and set the limit to fit two and a half of them
+	Log("TEST", INFO, "line0")
+	lineLen := int64(len(mw.Lines()[0]))
+	mw.Reset()
+	nLimit := 0
+	w := NewByteLimitWriter(mw, lineLen*5/2, func() { nLimit++ })
+	w.SetDropAfterLimit(true)
+	SetWriter(w)
+
+	// Past the limit
+	for i := 0; i < 5; i++ {
+		Log("TEST", INFO, "line%d", i)
+	}
+	assert.Equal(t, 1, nLimit)
+	assert.Equal(t, 2, len(mw.Lines()))
+	assert.Equal(t, uint64(3), w.DroppedCount())
+	assert.Equal(t, lineLen*2, w.Written())
+
+	// Reset from the handler
+	w.onLimit = func() {
+		nLimit++
+		w.Reset()
+	}
+	w.Reset()
+	mw.Reset()
+	for i := 0; i < 5; i++ {
+		Log("TEST", INFO, "line%d", i)
+	}
+	assert.Equal(t, 2, nLimit)
+	assert.Equal(t, 4, len(mw.Lines()))
+	assert.Equal(t, uint64(4), w.DroppedCount())
+}
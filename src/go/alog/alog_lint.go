@@ -0,0 +1,72 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"fmt"
+)
+
+//-- Config Lint ---------------------------------------------------------------
+
+// A single check run by LintConfig. It returns an empty string if the
+// configuration passes.
+//
+// NOTE: Checks are run inside the read lock
+////
+type lintCheck func(cfg *alogger) string
+
+// The checks run by LintConfig, in the order they are reported
+var lintChecks = []lintCheck{
+	lintDefaultTooVerbose,
+}
+
+// Flag a default level that is more verbose than every configured channel.
+// This usually means the default and the filters were swapped, and every
+// channel that is not listed will flood the output.
+func lintDefaultTooVerbose(cfg *alogger) string {
+	if len(cfg.channelMap) == 0 {
+		return ""
+	}
+	mostVerbose := OFF
+	for _, level := range cfg.channelMap {
+		if level >= cfg.defaultLevel {
+			return ""
+		}
+		if level > mostVerbose {
+			mostVerbose = level
+		}
+	}
+	return fmt.Sprintf(
+		"Default level [%s] is more verbose than every configured channel (most verbose is [%s]), so unlisted channels will log more than the listed ones",
+		LevelToHumanString(cfg.defaultLevel), LevelToHumanString(mostVerbose))
+}
+
+// LintConfig - Check the current configuration for likely mistakes and return
+// a warning for each one found. The checks are advisory: a configuration that
+// produces warnings is still valid and is not changed. An empty slice means no
+// problems were found.
+func LintConfig() []string {
+	return defaultLogger.LintConfig()
+}
@@ -0,0 +1,63 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Config Lint /////////////////////////////////////////////////////////
+
+////
+// LintConfig - Default level more verbose than every channel
+// 1) Configure debug4 as the default with all channels at info or quieter
+//  -> One warning naming both levels
+// 2) Configure sane setups: a quiet default with verbose channels, a channel
+//    matching the default, and no channel map at all
+//  -> No warnings
+////
+func Test_AlogLint_DefaultTooVerbose(t *testing.T) {
+	defer ResetDefaults()
+
+	// Suspicious
+	Config(DEBUG4, ChannelMap{"HTTP": INFO, "DB": WARNING, "OLD": OFF})
+	warnings := LintConfig()
+	if assert.Equal(t, 1, len(warnings)) {
+		assert.Contains(t, warnings[0], "[debug4]")
+		assert.Contains(t, warnings[0], "[info]")
+	}
+
+	// Sane
+	Config(INFO, ChannelMap{"HTTP": DEBUG, "DB": WARNING})
+	assert.Equal(t, []string{}, LintConfig())
+	Config(DEBUG, ChannelMap{"HTTP": DEBUG, "DB": WARNING})
+	assert.Equal(t, []string{}, LintConfig())
+	Config(DEBUG4, ChannelMap{})
+	assert.Equal(t, []string{}, LintConfig())
+}
@@ -0,0 +1,1145 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+//-- Logger Instances ----------------------------------------------------------
+
+// Logger - An independently configured logger. Each Logger has its own levels,
+// writer, formatter, indentation and scopes. The package-level functions
+// operate on the default Logger returned by DefaultLogger.
+//
+// NOTE: Some state is shared by all Loggers in the process: the stats
+//  counters, message redactors, hooks, the JSON field namespace, the goroutine
+//  id function (SetGIDFunc) and the exit function (SetExitFunc). Functions
+//  that only have a package-level form act on the default Logger. These are
+//  the dynamic and file configuration (ConfigureDynamicLogging, DynamicHandler,
+//  ConfigureFromFile, WatchConfigSignal, ConfigureFromFlags),
+//  StartPeriodicFlush and AsStdLogger. The helpers such as LogAt, LogCtx,
+//  LogFlag and the level shorthands are available per Logger through
+//  UseChannel.
+////
+type Logger struct {
+	cfg *alogger
+}
+
+// The Logger wrapping the package-level configuration
+var defaultLogger = &Logger{cfg: std}
+
+// NewLogger - Create a Logger with the package default configuration that is
+// independent of all other Loggers
+func NewLogger() *Logger {
+	return &Logger{cfg: new()}
+}
+
+// DefaultLogger - Get the Logger used by the package-level functions
+func DefaultLogger() *Logger {
+	return defaultLogger
+}
+
+//-- Logger Config Methods -----------------------------------------------------
+
+// Config - Set the default level and channel filter map
+func (l *Logger) Config(defaultLevel LogLevel, channelMap ChannelMap) {
+	l.cfg.mutex.Lock()
+	l.cfg.defaultLevel = defaultLevel
+	l.cfg.channelMap = channelMap
+	l.cfg.exactChannels = map[LogChannel]bool{}
+	l.cfg.mutex.Unlock()
+}
+
+// ConfigChannel - Set the level for a specific channel
+func (l *Logger) ConfigChannel(channel LogChannel, level LogLevel) {
+	l.cfg.mutex.Lock()
+	if nil == l.cfg.channelMap {
+		l.cfg.channelMap = ChannelMap{}
+	}
+	l.cfg.channelMap[channel] = level
+	delete(l.cfg.exactChannels, channel)
+	l.cfg.mutex.Unlock()
+}
+
+// ConfigChannelFor - Set the level for a specific channel for the duration d,
+// then restore the channel's previous level. See the package-level
+// ConfigChannelFor.
+func (l *Logger) ConfigChannelFor(channel LogChannel, level LogLevel, d time.Duration) {
+	l.cfg.configChannelFor(channel, level, d)
+}
+
+// MergeConfig - Set the levels for the given channels, leaving the default
+// level and all other channels unchanged
+func (l *Logger) MergeConfig(channelMap ChannelMap) {
+	l.cfg.mutex.Lock()
+	if nil == l.cfg.channelMap {
+		l.cfg.channelMap = ChannelMap{}
+	}
+	for ch, lvl := range channelMap {
+		l.cfg.channelMap[ch] = lvl
+		delete(l.cfg.exactChannels, ch)
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// ConfigChannelExact - Set a specific channel to enable only the given level
+func (l *Logger) ConfigChannelExact(channel LogChannel, level LogLevel) {
+	l.cfg.mutex.Lock()
+	if nil == l.cfg.channelMap {
+		l.cfg.channelMap = ChannelMap{}
+	}
+	l.cfg.channelMap[channel] = level
+	l.cfg.exactChannels[channel] = true
+	l.cfg.mutex.Unlock()
+}
+
+// MuteChannel - Silence a channel entirely, regardless of its configured level
+func (l *Logger) MuteChannel(channel LogChannel) {
+	l.cfg.mutex.Lock()
+	if nil == l.cfg.mutedChannels {
+		l.cfg.mutedChannels = map[LogChannel]bool{}
+	}
+	l.cfg.mutedChannels[channel] = true
+	l.cfg.mutex.Unlock()
+}
+
+// UnmuteChannel - Restore a muted channel to its configured level
+func (l *Logger) UnmuteChannel(channel LogChannel) {
+	l.cfg.mutex.Lock()
+	delete(l.cfg.mutedChannels, channel)
+	l.cfg.mutex.Unlock()
+}
+
+// AllowOnlyChannels - Log only the given channels. Calling this with no
+// channels turns the allowlist off.
+func (l *Logger) AllowOnlyChannels(channels ...LogChannel) {
+	l.cfg.mutex.Lock()
+	if len(channels) == 0 {
+		l.cfg.allowedChannels = nil
+	} else {
+		l.cfg.allowedChannels = map[LogChannel]bool{}
+		for _, ch := range channels {
+			l.cfg.allowedChannels[ch] = true
+		}
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// ConfigDefaultLevel - Set the level to use for channels not otherwise set
+func (l *Logger) ConfigDefaultLevel(level LogLevel) {
+	l.cfg.mutex.Lock()
+	l.cfg.defaultLevel = level
+	l.cfg.mutex.Unlock()
+}
+
+// ResetDefaults - Reset to the default configuration and cancel pending
+// ConfigChannelFor reverts
+func (l *Logger) ResetDefaults() {
+	l.cfg.clearChannelReverts()
+	l.cfg.mutex.Lock()
+	l.cfg.reset()
+	if l.cfg == std {
+		testHelperFunc.Store(nopTestHelper)
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// SetWriter - Set the io.Writer object to use
+func (l *Logger) SetWriter(w io.Writer) {
+	l.cfg.mutex.Lock()
+	l.cfg.writer = w
+	if tw, ok := w.(*TestingWriter); ok {
+		testHelperFunc.Store(tw.t.Helper)
+	} else if l.cfg == std {
+		testHelperFunc.Store(nopTestHelper)
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// SetFormatter - Set the LogFormatter instance to use
+func (l *Logger) SetFormatter(f LogFormatter) {
+	l.cfg.mutex.Lock()
+	l.cfg.formatter = f
+	l.cfg.mutex.Unlock()
+}
+
+// UseJSONLogFormatter - Set the formatter to print JSON output lines
+func (l *Logger) UseJSONLogFormatter() {
+	l.SetFormatter(JSONLogFormatter{})
+}
+
+// UseStdLogFormatter - Set the formatter to use the default StdLogFormatter
+func (l *Logger) UseStdLogFormatter() {
+	l.SetFormatter(StdLogFormatter{})
+}
+
+// UseNullFormatter - Set the formatter to a NullFormatter so that nothing is
+// written while the level configuration stays in place
+func (l *Logger) UseNullFormatter() {
+	l.SetFormatter(NullFormatter{})
+}
+
+// UseConsoleFormatter - Set the formatter to a ConsoleFormatter, colorized if
+// the configured writer is a terminal
+func (l *Logger) UseConsoleFormatter() {
+	l.cfg.mutex.RLock()
+	color := useColor(l.cfg.writer)
+	l.cfg.mutex.RUnlock()
+	l.SetFormatter(ConsoleFormatter{Color: color})
+}
+
+// UseMultiFormatter - Set the formatter to a MultiFormatter that writes the
+// lines of each of the given formatters in order
+func (l *Logger) UseMultiFormatter(formatters ...LogFormatter) {
+	l.SetFormatter(MultiFormatter{Formatters: formatters})
+}
+
+// SetServiceName - Set a service name to be logged
+func (l *Logger) SetServiceName(sn string) {
+	l.cfg.mutex.Lock()
+	l.cfg.serviceName = sn
+	l.cfg.mutex.Unlock()
+}
+
+// SetLabels - Set static labels to be logged with every entry. The labels are
+// copied. Pass nil to clear them.
+func (l *Logger) SetLabels(labels map[string]string) {
+	var copied map[string]string
+	if len(labels) > 0 {
+		copied = make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+	}
+	l.cfg.mutex.Lock()
+	l.cfg.labels = copied
+	l.cfg.mutex.Unlock()
+}
+
+// SetMaxChannelLen - Set the truncation length for channel headers
+func (l *Logger) SetMaxChannelLen(n int) {
+	l.cfg.mutex.Lock()
+	l.cfg.channelHeaderLen = n
+	l.cfg.mutex.Unlock()
+}
+
+// SetIndentString - Set the string used for each level of indentation (see the
+// package-level SetIndentString)
+func (l *Logger) SetIndentString(indent string) error {
+	if len(indent) == 0 || len(strings.Trim(indent, " \t")) > 0 {
+		return fmt.Errorf("Invalid indent string [%q]: must be non-empty spaces and tabs", indent)
+	}
+	l.cfg.mutex.Lock()
+	l.cfg.indent = indent
+	l.cfg.mutex.Unlock()
+	return nil
+}
+
+// EnableIndent - Enable indentation tracking
+func (l *Logger) EnableIndent() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableIndent = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableIndent - Disable indentation tracking
+func (l *Logger) DisableIndent() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableIndent = false
+	l.cfg.mutex.Unlock()
+}
+
+// EnableGID - Enable logging the goroutine-id for each message
+func (l *Logger) EnableGID() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableGID = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableGID - Disable logging the goroutine-id for each message
+func (l *Logger) DisableGID() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableGID = false
+	l.cfg.mutex.Unlock()
+}
+
+// Flush - Flush any output buffered by the configured writer
+func (l *Logger) Flush() error {
+	l.cfg.mutex.Lock()
+	defer l.cfg.mutex.Unlock()
+	return l.cfg.flush()
+}
+
+// GetDefaultLevel - Get the configured default level
+func (l *Logger) GetDefaultLevel() LogLevel {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.defaultLevel
+}
+
+// GetChannelMap - Get the configured channel map
+func (l *Logger) GetChannelMap() ChannelMap {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.channelMap
+}
+
+// GetFormatter - Get the configured formatter
+func (l *Logger) GetFormatter() LogFormatter {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.formatter
+}
+
+// IsJSONOutput - Get whether the configured formatter produces JSON
+func (l *Logger) IsJSONOutput() bool {
+	tagger, ok := l.GetFormatter().(FormatTagger)
+	return ok && tagger.FormatTag() == "json"
+}
+
+// GetExactChannels - Get the set of channels configured to enable only their
+// exact level
+func (l *Logger) GetExactChannels() map[LogChannel]bool {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	out := map[LogChannel]bool{}
+	for ch := range l.cfg.exactChannels {
+		out[ch] = true
+	}
+	return out
+}
+
+// SetChannelFormatter - Set the LogFormatter instance to use for a single
+// channel, overriding the global formatter.
+func (l *Logger) SetChannelFormatter(channel LogChannel, f LogFormatter) {
+	l.cfg.mutex.Lock()
+	if nil == f {
+		delete(l.cfg.channelFormatters, channel)
+	} else {
+		l.cfg.channelFormatters[channel] = f
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// SetChannelGroups - Assign channels to subsystems that are shown in the
+// header and added to JSON output
+func (l *Logger) SetChannelGroups(groups map[LogChannel]string) {
+	l.cfg.mutex.Lock()
+	l.cfg.channelGroups = map[LogChannel]string{}
+	for ch, group := range groups {
+		l.cfg.channelGroups[ch] = group
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// ValidateOutput - Check that the configured formatters produce the formats
+// required by the configured writers.
+func (l *Logger) ValidateOutput() error {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+
+	formatters := map[string]LogFormatter{"global formatter": l.cfg.formatter}
+	for ch, f := range l.cfg.channelFormatters {
+		formatters[fmt.Sprintf("formatter for channel [%s]", ch)] = f
+	}
+	writers := []io.Writer{l.cfg.writer}
+	if nil != l.cfg.levelWriters {
+		writers = []io.Writer{}
+		for _, w := range l.cfg.levelWriters {
+			writers = append(writers, w)
+		}
+	}
+
+	problems := []string{}
+	checked := map[string]bool{}
+	for _, w := range writers {
+		wt, ok := w.(FormatTagger)
+		if !ok {
+			continue
+		}
+		for name, f := range formatters {
+			ft, ok := f.(FormatTagger)
+			if !ok || ft.FormatTag() == wt.FormatTag() {
+				continue
+			}
+			problem := fmt.Sprintf("%s produces [%s] but writer %T requires [%s]",
+				name, ft.FormatTag(), w, wt.FormatTag())
+			if !checked[problem] {
+				checked[problem] = true
+				problems = append(problems, problem)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("Incompatible output configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// EnableScopeCorrelation - Enable tagging each entry logged inside a LogScope
+// or FnLog block with the id of the innermost scope on the same goroutine and
+// the entry's sequence number within that scope.
+func (l *Logger) EnableScopeCorrelation() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableScopeCorrelation = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableScopeCorrelation - Disable tagging entries with their scope
+func (l *Logger) DisableScopeCorrelation() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableScopeCorrelation = false
+	l.cfg.mutex.Unlock()
+}
+
+// EnableScopeTiming - Enable logging how long each LogScope or FnLog block
+// took.
+func (l *Logger) EnableScopeTiming() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableScopeTiming = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableScopeTiming - Disable logging the elapsed time of scopes
+func (l *Logger) DisableScopeTiming() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableScopeTiming = false
+	l.cfg.mutex.Unlock()
+}
+
+// EnableSequence - Enable tagging every entry with a sequence number that
+// increases monotonically across all channels and goroutines, starting at 1.
+func (l *Logger) EnableSequence() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableSequence = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableSequence - Disable tagging entries with a sequence number
+func (l *Logger) DisableSequence() {
+	l.cfg.mutex.Lock()
+	l.cfg.enableSequence = false
+	l.cfg.mutex.Unlock()
+}
+
+// EnableFullFuncSig - Enable logging fully qualified function signatures
+func (l *Logger) EnableFullFuncSig() {
+	l.cfg.mutex.Lock()
+	l.cfg.fullFuncSig = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableFullFuncSig - Disable logging fully qualified funciton signatures
+func (l *Logger) DisableFullFuncSig() {
+	l.cfg.mutex.Lock()
+	l.cfg.fullFuncSig = false
+	l.cfg.mutex.Unlock()
+}
+
+// SetMaxIndent - Set the maximum number of indents rendered in the header of
+// each std formatted line.
+func (l *Logger) SetMaxIndent(n int) {
+	l.cfg.mutex.Lock()
+	if n < 0 {
+		n = 0
+	}
+	l.cfg.maxIndent = n
+	l.cfg.mutex.Unlock()
+}
+
+// SetMapValueRenderer - Set the function used by the StdLogFormatter to render
+// each map data value
+func (l *Logger) SetMapValueRenderer(f func(interface{}) string) {
+	l.cfg.mutex.Lock()
+	l.cfg.mapValueRenderer = f
+	l.cfg.mutex.Unlock()
+}
+
+// ExpandSlices - Render slices and arrays in map data that have more than
+// threshold elements as a block with one element per line under the key when
+// using the StdLogFormatter.
+func (l *Logger) ExpandSlices(threshold int) {
+	l.cfg.mutex.Lock()
+	if threshold < 0 {
+		threshold = 0
+	}
+	l.cfg.expandSlices = threshold
+	l.cfg.mutex.Unlock()
+}
+
+// MaxIndentDepth - Suppress all entries, including the Start/End lines of
+// LogScope and FnLog, that would be logged with more than n indents.
+func (l *Logger) MaxIndentDepth(n int) {
+	l.cfg.mutex.Lock()
+	if n < 0 {
+		n = 0
+	}
+	l.cfg.maxIndentDepth = n
+	l.cfg.mutex.Unlock()
+}
+
+// SetLevelOffset - Shift the effective level of every channel by delta.
+func (l *Logger) SetLevelOffset(delta int) {
+	l.cfg.mutex.Lock()
+	l.cfg.levelOffset = delta
+	l.cfg.mutex.Unlock()
+}
+
+// EnableStdStreamSplit - Write entries at WARNING and more severe (WARNING,
+// ERROR and FATAL) to os.Stderr and all others to os.Stdout, using the
+// configured formatter for both.
+func (l *Logger) EnableStdStreamSplit() {
+	l.cfg.enableStreamSplit(os.Stderr, os.Stdout)
+}
+
+// DisableStdStreamSplit - Write all entries to the writer set with SetWriter
+// again
+func (l *Logger) DisableStdStreamSplit() {
+	l.cfg.mutex.Lock()
+	l.cfg.levelWriters = nil
+	l.cfg.mutex.Unlock()
+}
+
+// EnableDualOutput - Write every entry to two writers at once: formatted with
+// the StdLogFormatter to stdWriter and with the JSONLogFormatter to jsonWriter.
+func (l *Logger) EnableDualOutput(stdWriter, jsonWriter io.Writer) {
+	l.cfg.mutex.Lock()
+	l.cfg.outputs = []output{
+		{formatter: StdLogFormatter{}, writer: stdWriter},
+		{formatter: JSONLogFormatter{}, writer: jsonWriter},
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// DisableDualOutput - Write entries with the configured formatter and writer
+// again
+func (l *Logger) DisableDualOutput() {
+	l.cfg.mutex.Lock()
+	l.cfg.outputs = nil
+	l.cfg.mutex.Unlock()
+}
+
+// SetOutputTransform - Set a function that is applied to the bytes of each
+// formatted line just before it is written.
+func (l *Logger) SetOutputTransform(f func([]byte) []byte) {
+	l.cfg.mutex.Lock()
+	l.cfg.outputTransform = f
+	l.cfg.mutex.Unlock()
+}
+
+// SetFatalExitCode - Set the exit code used by Fatalf.
+func (l *Logger) SetFatalExitCode(code int) {
+	l.cfg.mutex.Lock()
+	l.cfg.fatalExitCode = code
+	l.cfg.mutex.Unlock()
+}
+
+// SetWriteErrorHandler - Set a function that is called when the writer returns
+// an error for an entry
+func (l *Logger) SetWriteErrorHandler(f func(err error, entry LogEntry)) {
+	l.cfg.mutex.Lock()
+	l.cfg.writeErrorHandler = f
+	l.cfg.mutex.Unlock()
+}
+
+// EnableErrorContext - Keep the most recent bufferSize entries below INFO
+// (TRACE and the DEBUG levels) that were suppressed on each goroutine.
+func (l *Logger) EnableErrorContext(bufferSize int) {
+	l.cfg.mutex.Lock()
+	if bufferSize > 0 {
+		l.cfg.errorContext = &contextBuffer{
+			size:  bufferSize,
+			rings: map[uint64][]LogEntry{},
+		}
+	} else {
+		l.cfg.errorContext = nil
+	}
+	l.cfg.mutex.Unlock()
+}
+
+// EnableDedup - Coalesce repeated messages.
+func (l *Logger) EnableDedup(window time.Duration) {
+	l.cfg.mutex.Lock()
+	prev := l.cfg.dedup
+	l.cfg.dedup = &dedupCache{
+		cfg:    l.cfg,
+		window: window,
+		states: map[dedupKey]*dedupState{},
+	}
+	l.cfg.mutex.Unlock()
+	flushDedup(prev)
+}
+
+// DisableDedup - Stop coalescing repeated messages, writing the summaries of
+// any messages that have repeated in their current window
+func (l *Logger) DisableDedup() {
+	l.cfg.mutex.Lock()
+	prev := l.cfg.dedup
+	l.cfg.dedup = nil
+	l.cfg.mutex.Unlock()
+	flushDedup(prev)
+}
+
+// EnableErrorChainCapture - Enable capturing the chain of wrapped errors for
+// every error passed as a format argument.
+func (l *Logger) EnableErrorChainCapture() {
+	l.cfg.mutex.Lock()
+	l.cfg.captureErrorChain = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableErrorChainCapture - Disable capturing the chain of wrapped errors
+func (l *Logger) DisableErrorChainCapture() {
+	l.cfg.mutex.Lock()
+	l.cfg.captureErrorChain = false
+	l.cfg.mutex.Unlock()
+}
+
+// EnableHealthEscalation - Enable logging unhealthy LogHealth results that are
+// requested at a level less severe than WARNING at WARNING.
+func (l *Logger) EnableHealthEscalation() {
+	l.cfg.mutex.Lock()
+	l.cfg.escalateHealth = true
+	l.cfg.mutex.Unlock()
+}
+
+// DisableHealthEscalation - Disable escalating unhealthy LogHealth results
+func (l *Logger) DisableHealthEscalation() {
+	l.cfg.mutex.Lock()
+	l.cfg.escalateHealth = false
+	l.cfg.mutex.Unlock()
+}
+
+// SetIDGenerator - Set the function used to generate request ids when a
+// RequestScope is opened without one.
+func (l *Logger) SetIDGenerator(f func() string) {
+	l.cfg.mutex.Lock()
+	l.cfg.idGenerator = f
+	l.cfg.mutex.Unlock()
+}
+
+// SinkStatus - Get the health of each writer that entries are currently written
+// to, as the error returned by its last write or nil if the last write
+// succeeded.
+func (l *Logger) SinkStatus() map[string]error {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	out := map[string]error{}
+	switch {
+	case len(l.cfg.outputs) > 0:
+		names := []string{"std_output", "json_output"}
+		for i, o := range l.cfg.outputs {
+			out[names[i]] = l.cfg.sinkErrors.get(o.writer)
+		}
+	case nil != l.cfg.levelWriters:
+		out["error_stream"] = l.cfg.sinkErrors.get(l.cfg.levelWriters[WARNING])
+		out["output_stream"] = l.cfg.sinkErrors.get(l.cfg.levelWriters[INFO])
+	default:
+		out["writer"] = l.cfg.sinkErrors.get(l.cfg.writer)
+	}
+	return out
+}
+
+// SetClock - Set the function used to timestamp entries.
+func (l *Logger) SetClock(clock func() time.Time) {
+	l.cfg.mutex.Lock()
+	l.cfg.clock = clock
+	l.cfg.mutex.Unlock()
+}
+
+// SetTimeLocation - Set the location that timestamps are captured in
+func (l *Logger) SetTimeLocation(loc *time.Location) {
+	if nil == loc {
+		loc = time.UTC
+	}
+	l.cfg.mutex.Lock()
+	l.cfg.location = loc
+	l.cfg.mutex.Unlock()
+}
+
+// UseDeltaTimestamps - Show the time elapsed since the previous line written to
+// the same writer in place of the timestamp in the std header
+func (l *Logger) UseDeltaTimestamps() {
+	l.cfg.mutex.Lock()
+	l.cfg.deltas = &deltaTracker{last: map[io.Writer]time.Time{}}
+	l.cfg.mutex.Unlock()
+}
+
+// UseAbsoluteTimestamps - Show the absolute timestamp in the std header again
+func (l *Logger) UseAbsoluteTimestamps() {
+	l.cfg.mutex.Lock()
+	l.cfg.deltas = nil
+	l.cfg.mutex.Unlock()
+}
+
+// SetTraceExtractor - Set the function used to extract the trace and span ids
+// of the active span from a context.Context for LogCtx and ChannelLog.Ctx.
+func (l *Logger) SetTraceExtractor(f func(ctx context.Context) (traceID, spanID string, ok bool)) {
+	l.cfg.mutex.Lock()
+	l.cfg.traceExtractor = f
+	l.cfg.mutex.Unlock()
+}
+
+// LintConfig - Check the current configuration for likely mistakes and return a
+// warning for each one found.
+func (l *Logger) LintConfig() []string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	warnings := []string{}
+	for _, check := range lintChecks {
+		if w := check(l.cfg); len(w) > 0 {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// GetChannelHeaderLen - Get the configured channel header length
+func (l *Logger) GetChannelHeaderLen() int {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.channelHeaderLen
+}
+
+// GetServiceName - Get the configured service name
+func (l *Logger) GetServiceName() string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.serviceName
+}
+
+// GetLabels - Get a copy of the configured labels
+func (l *Logger) GetLabels() map[string]string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	out := make(map[string]string, len(l.cfg.labels))
+	for k, v := range l.cfg.labels {
+		out[k] = v
+	}
+	return out
+}
+
+// GetIndentString - Get a copy of the indent string
+func (l *Logger) GetIndentString() string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.indent
+}
+
+// GetFatalExitCode - Get the exit code used by Fatalf
+func (l *Logger) GetFatalExitCode() int {
+	return l.cfg.getFatalExitCode()
+}
+
+// GetLevelOffset - Get the offset applied to the level of every channel
+func (l *Logger) GetLevelOffset() int {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.levelOffset
+}
+
+// GetMaxIndentDepth - Get the maximum indentation depth at which entries are
+// logged (0 for no limit)
+func (l *Logger) GetMaxIndentDepth() int {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.maxIndentDepth
+}
+
+// GetMaxIndent - Get the maximum number of rendered indents (0 for no limit)
+func (l *Logger) GetMaxIndent() int {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.maxIndent
+}
+
+// IndentEnabled - Get state of whether indentation is enabled
+func (l *Logger) IndentEnabled() bool {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.enableIndent
+}
+
+// GIDEnabled - Get state of whether the Goroutine ID is enabled
+func (l *Logger) GIDEnabled() bool {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.enableGID
+}
+
+// ScopeCorrelationEnabled - Get state of whether scope correlation is enabled
+func (l *Logger) ScopeCorrelationEnabled() bool {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.enableScopeCorrelation
+}
+
+// FuncSigEnabled - Get state of whether the full function signature is enabled
+func (l *Logger) FuncSigEnabled() bool {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	return l.cfg.fullFuncSig
+}
+
+// PrintConfig - Create a string representation of the current configuration.
+func (l *Logger) PrintConfig() string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+
+	channels := make([]string, 0, len(l.cfg.channelMap))
+	for k := range l.cfg.channelMap {
+		channels = append(channels, string(k))
+	}
+	sort.Strings(channels)
+
+	var b strings.Builder
+	b.WriteString("Default Level: ")
+	b.WriteString(levelToHeaderString(l.cfg.defaultLevel))
+	b.WriteString("\nChannel Map:")
+	for _, k := range channels {
+		b.WriteString("\n  ")
+		b.WriteString(k)
+		b.WriteString(": ")
+		b.WriteString(l.cfg.channelLevelString(LogChannel(k)))
+	}
+	return b.String()
+}
+
+// PrintConfigMap - Create a map representation of the full current
+// configuration.
+func (l *Logger) PrintConfigMap() map[string]interface{} {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+
+	channelMap := map[string]interface{}{}
+	for k := range l.cfg.channelMap {
+		channelMap[string(k)] = l.cfg.channelLevelString(k)
+	}
+	channelGroups := map[string]interface{}{}
+	for k, v := range l.cfg.channelGroups {
+		channelGroups[string(k)] = v
+	}
+	labels := map[string]interface{}{}
+	for k, v := range l.cfg.labels {
+		labels[k] = v
+	}
+	formatter := fmt.Sprintf("%T", l.cfg.formatter)
+	switch l.cfg.formatter.(type) {
+	case StdLogFormatter:
+		formatter = "std"
+	case JSONLogFormatter:
+		formatter = "json"
+	case NullFormatter:
+		formatter = "null"
+	case MultiFormatter:
+		formatter = "multi"
+	case ConsoleFormatter:
+		formatter = "console"
+	}
+	return map[string]interface{}{
+		"default_level":      LevelToHumanString(l.cfg.defaultLevel),
+		"channel_map":        channelMap,
+		"channel_groups":     channelGroups,
+		"service_name":       l.cfg.serviceName,
+		"labels":             labels,
+		"channel_header_len": l.cfg.channelHeaderLen,
+		"indent_string":      l.cfg.indent,
+		"enable_indent":      l.cfg.enableIndent,
+		"max_indent":         l.cfg.maxIndent,
+		"max_indent_depth":   l.cfg.maxIndentDepth,
+		"enable_gid":         l.cfg.enableGID,
+		"full_func_sig":      l.cfg.fullFuncSig,
+		"scope_correlation":  l.cfg.enableScopeCorrelation,
+		"scope_timing":       l.cfg.enableScopeTiming,
+		"error_chain":        l.cfg.captureErrorChain,
+		"health_escalation":  l.cfg.escalateHealth,
+		"sequence":           l.cfg.enableSequence,
+		"std_stream_split":   nil != l.cfg.levelWriters,
+		"dual_output":        len(l.cfg.outputs) > 0,
+		"delta_timestamps":   nil != l.cfg.deltas,
+		"time_location":      l.cfg.location.String(),
+		"error_context":      l.cfg.errorContextSize(),
+		"expand_slices":      l.cfg.expandSlices,
+		"level_offset":       l.cfg.levelOffset,
+		"fatal_exit_code":    l.cfg.fatalExitCode,
+		"formatter":          formatter,
+	}
+}
+
+//-- Logger Log Methods --------------------------------------------------------
+
+// Log - Alias to Printf. This is the standard log function.
+func (l *Logger) Log(channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	l.Printf(channel, level, format, v...)
+}
+
+// Printf - The standard Printf function
+func (l *Logger) Printf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	l.cfg.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// NewLogEntry - Create the entry that Printf would write, without writing it
+func (l *Logger) NewLogEntry(channel LogChannel, level LogLevel, format string, v ...interface{}) LogEntry {
+	return l.cfg.newEntry(channel, level, format, v)
+}
+
+// Fatalf - The standard Fatalf function. The writer is flushed before exiting.
+func (l *Logger) Fatalf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	l.cfg.fatalf(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	}, l.cfg.getFatalExitCode())
+}
+
+// FatalfWithCode - Fatalf that exits with the given code rather than the
+// configured fatal exit code
+func (l *Logger) FatalfWithCode(code int, channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	l.cfg.fatalf(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	}, code)
+}
+
+// Panicf - The standard Panicf function
+func (l *Logger) Panicf(channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	l.cfg.panicf(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// LogMap - Log a structured map entry
+func (l *Logger) LogMap(channel LogChannel, level LogLevel, mapData map[string]interface{}) {
+	testHelper()()
+	l.cfg.log(LogEntry{
+		Channel: channel,
+		Level:   level,
+		MapData: mapData,
+	})
+}
+
+// LogWithMap - Log a message with additional structured map data
+func (l *Logger) LogWithMap(channel LogChannel, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+	testHelper()()
+	l.cfg.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		MapData:   mapData,
+	})
+}
+
+// LogValue - Log a single named value
+func (l *Logger) LogValue(channel LogChannel, level LogLevel, name string, v interface{}) {
+	testHelper()()
+	l.cfg.log(valueEntry(channel, level, name, v))
+}
+
+// IsEnabled - Determine if a given channel/level combo is enabled
+func (l *Logger) IsEnabled(channel LogChannel, level LogLevel) bool {
+	l.cfg.mutex.RLock()
+	out := l.cfg.isEnabled(channel, level)
+	l.cfg.mutex.RUnlock()
+	return out
+}
+
+// Indent - Increase the indent level
+func (l *Logger) Indent() {
+	l.cfg.incrementIndent()
+}
+
+// Deindent - Decrease the indent level
+func (l *Logger) Deindent() {
+	l.cfg.decrementIndent()
+}
+
+// IndentScope - Increase the indent level and return a ScopedLogger whose
+// Close decreases it again exactly once
+func (l *Logger) IndentScope() ScopedLogger {
+	l.cfg.incrementIndent()
+	return &indentScopeImpl{cfg: l.cfg}
+}
+
+// LogScope - Create a log scope object to log a Start/End block
+func (l *Logger) LogScope(channel LogChannel, level LogLevel, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return l.cfg.logScope(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// FnLog - Create a log scope object with Start/End block containing the
+// function signature. This is always logged to the TRACE level.
+func (l *Logger) FnLog(channel LogChannel, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return l.cfg.fnLogImpl(2, LogEntry{
+		Channel:   channel,
+		Level:     TRACE,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// DetailFnLog - Create a log scope object with Start/End block containing the
+// function signature. This allows you to specify the log level.
+func (l *Logger) DetailFnLog(channel LogChannel, level LogLevel, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return l.cfg.fnLogImpl(2, LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// RequestScope - Create a log scope object to log a Start/End block for a
+// request.
+func (l *Logger) RequestScope(channel LogChannel, level LogLevel, requestID string, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	if len(requestID) == 0 {
+		requestID = l.cfg.newRequestID()
+	}
+	return l.cfg.logScope(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		RequestID: requestID,
+	})
+}
+
+// GetRequestID - Get the request id of the innermost RequestScope open on the
+// current goroutine, or an empty string if there is none.
+func (l *Logger) GetRequestID() string {
+	l.cfg.mutex.RLock()
+	defer l.cfg.mutex.RUnlock()
+	if len(l.cfg.scopeMap) == 0 {
+		return ""
+	}
+	if stack := l.cfg.scopeMap[getGID()]; len(stack) > 0 {
+		return stack[len(stack)-1].requestID
+	}
+	return ""
+}
+
+// CaptureEntries - Run fn with every entry emitted by this Logger captured
+// rather than written, and return the captured entries. The channel formatters
+// and dual outputs are set aside while fn runs and restored with the formatter
+// afterwards.
+func (l *Logger) CaptureEntries(fn func()) []LogEntry {
+	f := &captureFormatter{entries: []LogEntry{}}
+	l.cfg.mutex.Lock()
+	prevFormatter := l.cfg.formatter
+	prevChannelFormatters := l.cfg.channelFormatters
+	prevOutputs := l.cfg.outputs
+	l.cfg.formatter = f
+	l.cfg.channelFormatters = map[LogChannel]LogFormatter{}
+	l.cfg.outputs = nil
+	l.cfg.mutex.Unlock()
+	defer func() {
+		l.cfg.mutex.Lock()
+		l.cfg.formatter = prevFormatter
+		l.cfg.channelFormatters = prevChannelFormatters
+		l.cfg.outputs = prevOutputs
+		l.cfg.mutex.Unlock()
+	}()
+	fn()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.entries
+}
+
+// HTTPMiddleware - Wrap an http.Handler so that each request is handled inside
+// a RequestScope of this Logger. See the package-level HTTPMiddleware.
+func (l *Logger) HTTPMiddleware(channel LogChannel, level LogLevel) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !l.IsEnabled(channel, level) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			scope := l.RequestScope(channel, level, r.Header.Get(requestIDHeader), "%s %s", r.Method, r.URL.Path)
+			defer scope.Close()
+			rec := &statusRecorder{ResponseWriter: w}
+			start := time.Now()
+			next.ServeHTTP(rec, r)
+			duration := time.Since(start)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			l.cfg.log(LogEntry{
+				Channel: channel,
+				Level:   level,
+				MapData: map[string]interface{}{
+					"method":      r.Method,
+					"path":        r.URL.Path,
+					"status":      rec.status,
+					"duration_ms": float64(duration) / float64(time.Millisecond),
+				},
+				Format:     "Status %d in %s",
+				Expansion:  []interface{}{rec.status, duration},
+				formatKeys: []string{"method", "path", "status", "duration_ms"},
+			})
+		})
+	}
+}
+
+// UseChannel - Create a channel object that logs to the given channel through
+// this Logger
+func (l *Logger) UseChannel(channel LogChannel) ChannelLogger {
+	return &channelLogImpl{
+		cfg:     l.cfg,
+		channel: channel,
+	}
+}
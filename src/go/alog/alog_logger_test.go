@@ -0,0 +1,255 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"reflect"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Logger Instances ////////////////////////////////////////////////////
+
+////
+// NewLogger - Independent levels and writers
+// 1) Configure the default logger and a new logger with different levels,
+//    writers and formatters, then log the same lines to both
+//  -> Each writer receives only the lines enabled on its own logger, formatted
+//     with its own formatter
+// 2) Reset the new logger
+//  -> The default logger keeps its configuration
+////
+func Test_AlogLogger_LevelsAndWriters(t *testing.T) {
+
+	// Configure
+	appEntries := []string{}
+	ConfigStdLogWriter(&appEntries)
+	Config(INFO, ChannelMap{"LIB": OFF})
+	defer ResetDefaults()
+
+	lib := NewLogger()
+	libWriter := NewMemoryWriter()
+	lib.SetWriter(libWriter)
+	lib.UseJSONLogFormatter()
+	lib.Config(DEBUG, ChannelMap{"APP": OFF})
+	assert.NotEqual(t, DefaultLogger(), lib)
+	assert.Equal(t, DEBUG, lib.GetDefaultLevel())
+	assert.Equal(t, INFO, GetDefaultLevel())
+
+	// Log to both
+	for _, l := range []*Logger{DefaultLogger(), lib} {
+		l.Log("APP", INFO, "app info")
+		l.Log("LIB", DEBUG, "lib debug")
+		l.LogMap("LIB", INFO, map[string]interface{}{"k": "v"})
+		l.UseChannel("APP").Log(DEBUG, "app debug")
+	}
+	assert.True(t, VerifyLogs(appEntries, []ExpEntry{
+		ExpEntry{channel: "APP  ", level: "INFO", body: "app info"},
+	}))
+	entries := libWriter.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, LogChannel("LIB"), entries[0].Channel)
+		assert.Equal(t, "lib debug", entries[0].Format)
+		assert.Equal(t, "v", entries[1].MapData["k"])
+	}
+
+	// Reset the new logger
+	lib.ResetDefaults()
+	assert.Equal(t, OFF, lib.GetDefaultLevel())
+	assert.Equal(t, INFO, GetDefaultLevel())
+	assert.True(t, IsEnabled("APP", INFO))
+	assert.False(t, lib.IsEnabled("APP", INFO))
+}
+
+////
+// NewLogger - Independent indentation and formatting configuration
+// 1) Open scopes and indent on a new logger, then log to both loggers
+//  -> Only the new logger's lines are indented
+// 2) Configure a different indent string and channel length on the new logger
+//  -> Only the new logger's lines use them
+// 3) Close the scopes and log
+//  -> Both loggers back to no indentation
+////
+func Test_AlogLogger_Indentation(t *testing.T) {
+
+	// Configure
+	appEntries := []string{}
+	ConfigStdLogWriter(&appEntries)
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+
+	libEntries := []string{}
+	lib := NewLogger()
+	lib.SetWriter(&TestWriter{entries: &libEntries})
+	lib.ConfigDefaultLevel(DEBUG)
+	assert.Nil(t, lib.SetIndentString("    "))
+	lib.SetMaxChannelLen(3)
+
+	// Scopes on the new logger only
+	scope := lib.LogScope("LIB", INFO, "scope")
+	indent := lib.IndentScope()
+	lib.Log("LIB", INFO, "nested")
+	Log("APP", INFO, "not nested")
+	lib.UseChannel("LIB").Log(INFO, "channel nested")
+
+	// Close
+	indent.Close()
+	scope.Close()
+	lib.Log("LIB", INFO, "done")
+	Log("APP", INFO, "done")
+
+	assert.Equal(t, "  ", GetIndentString())
+	assert.True(t, VerifyLogs(appEntries, []ExpEntry{
+		ExpEntry{channel: "APP  ", level: "INFO", body: "not nested", nIndent: 0},
+		ExpEntry{channel: "APP  ", level: "INFO", body: "done", nIndent: 0},
+	}))
+	expLib := []string{
+		"[LIB:INFO] Start: scope\n",
+		"[LIB:INFO]         nested\n",
+		"[LIB:INFO]         channel nested\n",
+		"[LIB:INFO] End: scope\n",
+		"[LIB:INFO] done\n",
+	}
+	if assert.Equal(t, len(expLib), len(libEntries)) {
+		for i, exp := range expLib {
+			assert.Contains(t, libEntries[i], exp)
+		}
+	}
+}
+
+////
+// ChannelIsEnabled - ChannelLog.IsEnabled uses its own logger's levels
+// 1) Configure the default logger and a new logger with different levels
+// 2) Check IsEnabled on a channel of each
+//  -> Each channel reports the levels of the logger that created it
+////
+func Test_AlogLogger_ChannelIsEnabled(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	lib := NewLogger()
+	lib.Config(WARNING, ChannelMap{"LIB": DEBUG})
+
+	app := UseChannel("LIB")
+	libCh := lib.UseChannel("LIB")
+	other := lib.UseChannel("OTHER")
+	assert.True(t, app.IsEnabled(INFO))
+	assert.False(t, app.IsEnabled(DEBUG))
+	assert.True(t, libCh.IsEnabled(DEBUG))
+	assert.False(t, other.IsEnabled(INFO))
+	assert.True(t, other.IsEnabled(WARNING))
+
+	// Changing one logger does not affect the other
+	ConfigDefaultLevel(DEBUG4)
+	assert.False(t, other.IsEnabled(INFO))
+	lib.ConfigDefaultLevel(OFF)
+	assert.True(t, UseChannel("OTHER").IsEnabled(DEBUG4))
+}
+
+////
+// ResetTestHelper - Creating or resetting a new logger keeps the test helper
+// 1) Configure a TestingWriter on the default logger
+// 2) Create a new logger and reset it
+//  -> The default logger still attributes lines to the test
+// 3) Reset the default logger
+//  -> The test helper is cleared
+////
+func Test_AlogLogger_ResetTestHelper(t *testing.T) {
+	nop := reflect.ValueOf(nopTestHelper).Pointer()
+	SetWriter(NewTestingWriter(t))
+	defer ResetDefaults()
+	assert.NotEqual(t, nop, reflect.ValueOf(testHelper()).Pointer())
+
+	lib := NewLogger()
+	lib.ResetDefaults()
+	assert.NotEqual(t, nop, reflect.ValueOf(testHelper()).Pointer())
+
+	ResetDefaults()
+	assert.Equal(t, nop, reflect.ValueOf(testHelper()).Pointer())
+}
+
+////
+// InstanceSettings - Settings made through a Logger only affect that Logger
+// 1) Change settings on a new logger that also exist as package functions
+//  -> The new logger reports and uses them
+//  -> The default logger keeps its defaults
+// 2) Call FatalfWithCode on the new logger
+//  -> The line is written by the new logger with the given code
+////
+func Test_AlogLogger_InstanceSettings(t *testing.T) {
+	appEntries := []string{}
+	ConfigStdLogWriter(&appEntries)
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+
+	lib := NewLogger()
+	libWriter := NewMemoryWriter()
+	lib.SetWriter(libWriter)
+	lib.UseJSONLogFormatter()
+	lib.ConfigDefaultLevel(INFO)
+	lib.SetLevelOffset(-1)
+	lib.EnableSequence()
+	lib.EnableScopeCorrelation()
+	lib.SetMaxIndent(2)
+	lib.SetIDGenerator(func() string { return "lib-id" })
+
+	assert.Equal(t, -1, lib.GetLevelOffset())
+	assert.Equal(t, 0, GetLevelOffset())
+	assert.True(t, lib.ScopeCorrelationEnabled())
+	assert.False(t, ScopeCorrelationEnabled())
+	assert.Equal(t, 2, lib.GetMaxIndent())
+	assert.Equal(t, 0, GetMaxIndent())
+
+	// Logging
+	func() {
+		defer lib.RequestScope("LIB", WARNING, "", "request").Close()
+		assert.Equal(t, "lib-id", lib.GetRequestID())
+		assert.Equal(t, "", GetRequestID())
+		lib.Log("LIB", INFO, "offset hides this")
+		Log("APP", INFO, "app line")
+	}()
+	libEntries := libWriter.Entries()
+	if assert.Len(t, libEntries, 2) {
+		for _, e := range libEntries {
+			assert.Equal(t, "lib-id", e.RequestID)
+			assert.NotEqual(t, "", e.ScopeID)
+			assert.NotEqual(t, uint64(0), e.Sequence)
+		}
+	}
+	assert.True(t, VerifyLogs(appEntries, []ExpEntry{
+		ExpEntry{channel: "APP  ", level: "INFO", body: "app line"},
+	}))
+
+	// FatalfWithCode
+	codes := []int{}
+	SetExitFunc(func(code int) { codes = append(codes, code) })
+	libWriter.Reset()
+	lib.FatalfWithCode(3, "LIB", ERROR, "fatal")
+	assert.Equal(t, []int{3}, codes)
+	assert.Len(t, libWriter.Lines(), 1)
+	assert.Len(t, appEntries, 1)
+}
@@ -0,0 +1,154 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//-- Message Redaction ---------------------------------------------------------
+
+// RedactorHandle - Handle identifying a registered message redactor, used to
+// remove it
+type RedactorHandle uint64
+
+// A single registered message redaction
+type messageRedactor struct {
+	handle      RedactorHandle
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// The registered redactors are held as an immutable slice that is replaced on
+// registration so that the formatters can read it without locking
+var (
+	messageRedactors     atomic.Value
+	messageRedactorsLock sync.Mutex
+	nextRedactorHandle   RedactorHandle
+)
+
+// RegisterMessageRedactor - Replace every match of pattern in the formatted
+// message body with replacement in both the StdLogFormatter and the
+// JSONLogFormatter. The replacement may reference capture groups as in
+// regexp.Regexp.ReplaceAllString. Redactors run in the order they were
+// registered. Map data is not affected. The returned handle identifies the
+// redactor for RemoveMessageRedactor.
+//
+// By default the redactors run when the message is formatted, after all
+// hooks, so a hook sees the message before redaction. Register RedactionHook
+// to redact at a chosen position in the hook pipeline instead.
+func RegisterMessageRedactor(pattern *regexp.Regexp, replacement string) RedactorHandle {
+	messageRedactorsLock.Lock()
+	defer messageRedactorsLock.Unlock()
+	current, _ := messageRedactors.Load().([]messageRedactor)
+	updated := make([]messageRedactor, len(current), len(current)+1)
+	copy(updated, current)
+	nextRedactorHandle++
+	updated = append(updated, messageRedactor{
+		handle:      nextRedactorHandle,
+		pattern:     pattern,
+		replacement: replacement,
+	})
+	messageRedactors.Store(updated)
+	return nextRedactorHandle
+}
+
+// RemoveMessageRedactor - Remove a registered message redactor. The remaining
+// redactors keep their order. Returns false if the handle does not identify a
+// registered redactor.
+func RemoveMessageRedactor(handle RedactorHandle) bool {
+	messageRedactorsLock.Lock()
+	defer messageRedactorsLock.Unlock()
+	current, _ := messageRedactors.Load().([]messageRedactor)
+	for i, r := range current {
+		if r.handle == handle {
+			updated := make([]messageRedactor, 0, len(current)-1)
+			updated = append(updated, current[:i]...)
+			updated = append(updated, current[i+1:]...)
+			messageRedactors.Store(updated)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearMessageRedactors - Remove all registered message redactors
+func ClearMessageRedactors() {
+	messageRedactorsLock.Lock()
+	messageRedactors.Store([]messageRedactor{})
+	messageRedactorsLock.Unlock()
+}
+
+// RedactionHook - Hook that applies the registered message redactors to the
+// entry so that hooks registered after it (e.g. one that ships entries to a
+// remote service) only see the redacted message. Register it with AddHookAt
+// at the position where redaction should happen. The formatters do not redact
+// an entry a second time.
+//
+// NOTE: Lazy values are resolved and the message is expanded into e.Format
+//  with any '%' escaped as "%%" and e.Expansion cleared, so later hooks should
+//  read the message with fmt.Sprintf(e.Format, e.Expansion...)
+////
+func RedactionHook(e *LogEntry) bool {
+	resolveLazyValues(e)
+	msg := redactMessage(fmt.Sprintf(e.Format, e.Expansion...))
+	e.Format = strings.ReplaceAll(msg, "%", "%%")
+	e.Expansion = nil
+	e.redacted = true
+	return true
+}
+
+// The formatted message of an entry with the registered redactors applied
+// unless they already ran in the hook pipeline
+func (e LogEntry) message() string {
+	msg := fmt.Sprintf(e.Format, e.Expansion...)
+	if e.redacted {
+		return msg
+	}
+	return redactMessage(msg)
+}
+
+// Apply all registered redactors to a formatted message
+func redactMessage(msg string) string {
+	redactors, _ := messageRedactors.Load().([]messageRedactor)
+	for _, r := range redactors {
+		msg = r.pattern.ReplaceAllString(msg, r.replacement)
+	}
+	return msg
+}
+
+// Apply all registered redactors to a formatted message in a buffer, returning
+// the buffer's contents unchanged if there are none
+func redactMessageBytes(msg []byte) []byte {
+	redactors, _ := messageRedactors.Load().([]messageRedactor)
+	for _, r := range redactors {
+		msg = r.pattern.ReplaceAll(msg, []byte(r.replacement))
+	}
+	return msg
+}
@@ -0,0 +1,193 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"regexp"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Message Redaction ///////////////////////////////////////////////////
+
+////
+// RegisterMessageRedactor - Mask secrets in message bodies
+// 1) Register a pattern for bearer tokens and log a message containing one
+//    with both formatters
+//  -> Token masked in the message, map data untouched
+// 2) Register a second pattern that builds on the first replacement
+//  -> Patterns applied in registration order
+// 3) Log a multi-line message with the Std formatter
+//  -> Each line redacted
+// 4) Clear the redactors
+//  -> Messages logged unchanged
+////
+func Test_AlogRedact_Message(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	defer ClearMessageRedactors()
+	RegisterMessageRedactor(regexp.MustCompile(`Bearer [A-Za-z0-9._-]{16,}`), "Bearer [REDACTED]")
+
+	// JSON
+	LogWithMap("AUTH", INFO, map[string]interface{}{"user": "bob"},
+		"Calling with header Authorization: %s", "Bearer eyJhbGciOiJIUzI1NiJ9.abc123")
+	entries := w.Entries()
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "Calling with header Authorization: Bearer [REDACTED]", entries[0].Format)
+		assert.Equal(t, "bob", entries[0].MapData["user"])
+	}
+	assert.NotContains(t, w.Lines()[0], "eyJhbGci")
+
+	// Ordered patterns
+	w.Reset()
+	RegisterMessageRedactor(regexp.MustCompile(`(Bearer) \[REDACTED\]`), "$1 ***")
+	Log("AUTH", INFO, "token Bearer 0123456789abcdef0123")
+	if entries = w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "token Bearer ***", entries[0].Format)
+	}
+
+	// Std
+	lines := []string{}
+	ConfigStdLogWriter(&lines)
+	Log("AUTH", INFO, "first Bearer 0123456789abcdef0123\nsecond Bearer abcdefabcdefabcdef")
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "AUTH ", level: "INFO", body: "first Bearer ***"},
+		ExpEntry{channel: "AUTH ", level: "INFO", body: "second Bearer ***"},
+	}))
+
+	// Cleared
+	lines = []string{}
+	ClearMessageRedactors()
+	Log("AUTH", INFO, "Bearer 0123456789abcdef0123")
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "AUTH ", level: "INFO", body: "Bearer 0123456789abcdef0123"},
+	}))
+}
+
+////
+// RemoveMessageRedactor - Remove redactors one at a time
+// 1) Register two redactors and remove the first
+//  -> Only the second applied
+// 2) Remove the same handle again
+//  -> Returns false
+////
+func Test_AlogRedact_Remove(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	defer ClearMessageRedactors()
+
+	h := RegisterMessageRedactor(regexp.MustCompile(`secret`), "[S]")
+	RegisterMessageRedactor(regexp.MustCompile(`token`), "[T]")
+	assert.True(t, RemoveMessageRedactor(h))
+	Log("AUTH", INFO, "token secret")
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "[T] secret", entries[0].Format)
+	}
+
+	// Remove again
+	assert.False(t, RemoveMessageRedactor(h))
+}
+
+////
+// RedactionHook - Redact at a position in the hook pipeline
+// 1) Add a hook that ships the message without the redaction hook
+//  -> Shipped message not redacted, written line redacted
+// 2) Insert the redaction hook before the shipping hook
+//  -> Shipped message and written line redacted once
+// 3) Log a message with a '%' through the redaction hook with the std formatter
+//  -> '%' kept literally
+////
+func Test_AlogRedact_Hook(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+
+	RegisterMessageRedactor(regexp.MustCompile(`secret`), "[REDACTED]")
+	RegisterMessageRedactor(regexp.MustCompile(`\[REDACTED\]`), "[REDACTED] [REDACTED]")
+	shipped := []string{}
+	AddHook(func(e *LogEntry) bool {
+		shipped = append(shipped, fmt.Sprintf(e.Format, e.Expansion...))
+		return true
+	})
+
+	// Without the redaction hook
+	Log("AUTH", INFO, "token %s", "secret")
+	assert.Equal(t, []string{"token secret"}, shipped)
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "token [REDACTED] [REDACTED]", entries[0].Format)
+	}
+
+	// With the redaction hook
+	w.Reset()
+	shipped = []string{}
+	AddHookAt(0, RedactionHook)
+	Log("AUTH", INFO, "token %s", "secret")
+	assert.Equal(t, []string{"token [REDACTED] [REDACTED]"}, shipped)
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "token [REDACTED] [REDACTED]", entries[0].Format)
+	}
+
+	// Std with a '%'
+	w.Reset()
+	UseStdLogFormatter()
+	Log("AUTH", INFO, "100%% %s", "secret")
+	if lines := w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], " [AUTH :INFO] 100% [REDACTED] [REDACTED]")
+	}
+}
+
+////
+// RedactionHook - Lazy format arguments
+// 1) Log a message with a LazyFunc argument through the redaction hook
+//  -> The lazy value is resolved and redacted in the message
+////
+func Test_AlogRedact_HookLazy(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+
+	RegisterMessageRedactor(regexp.MustCompile(`secret`), "[REDACTED]")
+	AddHook(RedactionHook)
+	Log("AUTH", INFO, "token %s", LazyFunc(func() interface{} { return "secret" }))
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "token [REDACTED]", entries[0].Format)
+	}
+}
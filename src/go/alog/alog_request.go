@@ -0,0 +1,169 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+//-- Request Scopes ------------------------------------------------------------
+
+// Fallback counter used if the system random source fails
+var requestIDCounter uint64
+
+// Default request id generator producing 16 random hex characters
+func defaultIDGenerator() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); nil != err {
+		return strconv.FormatUint(atomic.AddUint64(&requestIDCounter, 1), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Generate a new request id with the configured generator
+func (cfg *alogger) newRequestID() string {
+	cfg.mutex.RLock()
+	gen := cfg.idGenerator
+	cfg.mutex.RUnlock()
+	if nil == gen {
+		gen = defaultIDGenerator
+	}
+	return gen()
+}
+
+// SetIDGenerator - Set the function used to generate request ids when a
+// RequestScope is opened without one. Pass nil to restore the default, which
+// generates 16 random hex characters.
+func SetIDGenerator(f func() string) {
+	defaultLogger.SetIDGenerator(f)
+}
+
+// RequestScope - Create a log scope object to log a Start/End block for a
+// request. Every entry logged on the same goroutine while the scope is open,
+// including those in nested scopes, is tagged with the request id (request_id
+// in JSON output). If requestID is empty, a new id is generated with the
+// configured id generator.
+func RequestScope(channel LogChannel, level LogLevel, requestID string, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	return defaultLogger.RequestScope(channel, level, requestID, format, v...)
+}
+
+// GetRequestID - Get the request id of the innermost RequestScope open on the
+// current goroutine, or an empty string if there is none. This can be used to
+// propagate the id to downstream calls.
+func GetRequestID() string {
+	return defaultLogger.GetRequestID()
+}
+
+// RequestScope - RequestScope for a LogChannel instance
+func (ch *channelLogImpl) RequestScope(level LogLevel, requestID string, format string, v ...interface{}) ScopedLogger {
+	testHelper()()
+	if len(requestID) == 0 {
+		requestID = ch.cfg.newRequestID()
+	}
+	e := ch.entry(level, nil, format, v)
+	e.RequestID = requestID
+	return ch.cfg.logScope(e)
+}
+
+// RequestScope - Returns a ScopedLogger that does nothing
+func (nopChannelLog) RequestScope(level LogLevel, requestID string, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+
+//-- HTTP Middleware -----------------------------------------------------------
+
+// Header that an incoming request id is read from by HTTPMiddleware
+const requestIDHeader = "X-Request-ID"
+
+// ResponseWriter wrapper that records the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader - Record the status and pass it on
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write - Record the implicit 200 status of a write without a header
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush - Flush the wrapped ResponseWriter if it supports it
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack - Hijack the connection of the wrapped ResponseWriter if it supports
+// it. The status of a hijacked response is not recorded.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := r.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Push - Initiate an HTTP/2 server push on the wrapped ResponseWriter if it
+// supports it
+func (r *statusRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap - Get the wrapped ResponseWriter, e.g. for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// HTTPMiddleware - Wrap an http.Handler so that each request is handled inside
+// a RequestScope on the given channel and level, named by the request's method
+// and path. Entries logged by the handler are indented inside the scope and
+// tagged with the request id, which is taken from the X-Request-ID header if
+// present. Before the scope closes, a line with the response status and the
+// duration of the request is logged, with the method, path, status and
+// duration_ms as map data. The StdLogFormatter renders only the message of
+// this line.
+func HTTPMiddleware(channel LogChannel, level LogLevel) func(http.Handler) http.Handler {
+	return defaultLogger.HTTPMiddleware(channel, level)
+}
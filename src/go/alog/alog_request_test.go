@@ -0,0 +1,354 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Request Scopes //////////////////////////////////////////////////////
+
+////
+// RequestScope - Generated request ids shared across nested logs
+// 1) Open a RequestScope without an id and log inside nested scopes
+//  -> A generated 16 hex character id is set on every entry, including the
+//     Start/End entries of the nested scope
+//  -> Entries outside the request have no request id
+// 2) Open a second request
+//  -> A different id is generated
+// 3) Open a request with an explicit id through a ChannelLog
+//  -> The explicit id is used and GetRequestID returns it
+////
+func Test_AlogRequest_Generated(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+
+	// First request
+	handle := func(msg string) {
+		defer RequestScope("TEST", INFO, "", "request").Close()
+		Log("TEST", INFO, msg)
+		func() {
+			defer LogScope("TEST", DEBUG, "inner").Close()
+			Log("TEST", DEBUG, "nested")
+		}()
+	}
+	Log("TEST", INFO, "Before")
+	handle("first")
+	Log("TEST", INFO, "After")
+
+	entries := w.Entries()
+	if assert.Equal(t, 8, len(entries)) {
+		id := entries[1].RequestID
+		assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{16}$"), id)
+		assert.Equal(t, "", entries[0].RequestID)
+		assert.Equal(t, "", entries[7].RequestID)
+		for i := 1; i < 7; i++ {
+			assert.Equal(t, id, entries[i].RequestID, "entry %d", i)
+		}
+		assert.Contains(t, w.Lines()[1], `"request_id":"`+id+`"`)
+		assert.NotContains(t, w.Lines()[0], "request_id")
+
+		// Second request
+		w.Reset()
+		handle("second")
+		entries = w.Entries()
+		if assert.Equal(t, 6, len(entries)) {
+			assert.NotEqual(t, "", entries[0].RequestID)
+			assert.NotEqual(t, id, entries[0].RequestID)
+		}
+	}
+
+	// Explicit id
+	w.Reset()
+	func() {
+		defer UseChannel("TEST").RequestScope(INFO, "req-123", "request").Close()
+		assert.Equal(t, "req-123", GetRequestID())
+		Log("TEST", INFO, "inside")
+	}()
+	assert.Equal(t, "", GetRequestID())
+	entries = w.Entries()
+	if assert.Equal(t, 3, len(entries)) {
+		for _, e := range entries {
+			assert.Equal(t, "req-123", e.RequestID)
+		}
+	}
+}
+
+////
+// SetIDGenerator - Custom request id generation
+// 1) Set a counting generator and open two requests
+//  -> Each request uses the next generated id
+// 2) Open requests on several goroutines
+//  -> Each goroutine's entries carry only its own id
+// 3) Reset the generator to nil
+//  -> The default generator is used
+////
+func Test_AlogRequest_IDGenerator(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Counting generator
+	mu := sync.Mutex{}
+	n := 0
+	SetIDGenerator(func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return "id-" + string(rune('a'+n-1))
+	})
+	for i := 0; i < 2; i++ {
+		func() {
+			defer RequestScope("TEST", INFO, "", "request").Close()
+			Log("TEST", INFO, "inside")
+		}()
+	}
+	entries := w.Entries()
+	if assert.Equal(t, 6, len(entries)) {
+		for i, e := range entries {
+			if i < 3 {
+				assert.Equal(t, "id-a", e.RequestID)
+			} else {
+				assert.Equal(t, "id-b", e.RequestID)
+			}
+		}
+	}
+
+	// Concurrent requests
+	w.Reset()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer RequestScope("TEST", INFO, "", "worker").Close()
+			for j := 0; j < 5; j++ {
+				Log("TEST", INFO, "work")
+			}
+		}()
+	}
+	wg.Wait()
+	counts := map[string]int{}
+	for _, e := range w.Entries() {
+		counts[e.RequestID]++
+	}
+	assert.Equal(t, 4, len(counts))
+	for id, c := range counts {
+		assert.Equal(t, 7, c, "request %s", id)
+	}
+
+	// Default generator
+	w.Reset()
+	SetIDGenerator(nil)
+	func() {
+		defer RequestScope("TEST", INFO, "", "request").Close()
+	}()
+	entries = w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{16}$"), entries[0].RequestID)
+	}
+}
+
+// Tests - HTTP Middleware /////////////////////////////////////////////////////
+
+////
+// HTTPMiddleware - Request scope around a handler
+// 1) Serve a request with a status and a log from inside the handler
+//  -> Start line named by method and path
+//  -> Handler's line indented inside the scope
+//  -> Status and duration line, then the End line
+// 2) Serve a JSON request with an X-Request-ID header and no explicit status
+//  -> Every entry tagged with the request id
+//  -> Status 200 and a duration in the map data
+// 3) Serve a request with the level disabled
+//  -> Handler still called, nothing logged
+////
+func Test_AlogRequest_HTTPMiddleware(t *testing.T) {
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	handler := HTTPMiddleware("HTTP", INFO)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Log("HTTP", INFO, "Handling")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	// Std
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("GET", "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	if assert.Len(t, entries, 4) {
+		assert.True(t, VerifyLogs([]string{entries[0], entries[1], entries[3]}, []ExpEntry{
+			ExpEntry{channel: "HTTP ", level: "INFO", body: "Start: GET /missing"},
+			ExpEntry{channel: "HTTP ", level: "INFO", body: "Handling", nIndent: 1},
+			ExpEntry{channel: "HTTP ", level: "INFO", body: "End: GET /missing"},
+		}))
+		assert.Regexp(t, `\[HTTP :INFO\]   Status 404 in [0-9.]+[nµm]?s`, entries[2])
+	}
+
+	// JSON
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	req := httptest.NewRequest("POST", "/items", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	logged := w.Entries()
+	if assert.Len(t, logged, 4) {
+		for _, e := range logged {
+			assert.Equal(t, "req-123", e.RequestID)
+		}
+		assert.Equal(t, "Start: POST /items", logged[0].Format)
+		status := logged[2].MapData
+		assert.Equal(t, "POST", status["method"])
+		assert.Equal(t, "/items", status["path"])
+		assert.Equal(t, "200", fmt.Sprint(status["status"]))
+		assert.Contains(t, status, "duration_ms")
+	}
+
+	// Disabled
+	w.Reset()
+	called := false
+	HTTPMiddleware("HTTP", DEBUG)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.True(t, called)
+	assert.Empty(t, w.Lines())
+}
+
+// ResponseWriter that supports hijacking and server push
+type hijackPushWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	pushed   string
+}
+
+func (w *hijackPushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *hijackPushWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+////
+// HTTPMiddleware - Optional ResponseWriter interfaces
+// 1) Hijack, push and flush through a writer that supports them
+//  -> Calls forwarded to the wrapped writer
+// 2) Hijack and push through a writer that does not support them
+//  -> http.ErrNotSupported
+// 3) Unwrap the writer seen by the handler
+//  -> Original writer returned
+////
+func Test_AlogRequest_HTTPMiddlewareWriter(t *testing.T) {
+	SetWriter(NewMemoryWriter())
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	var hijackErr, pushErr error
+	var unwrapped http.ResponseWriter
+	handler := HTTPMiddleware("HTTP", INFO)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, hijackErr = w.(http.Hijacker).Hijack()
+		pushErr = w.(http.Pusher).Push("/style.css", nil)
+		w.(http.Flusher).Flush()
+		unwrapped = w.(interface{ Unwrap() http.ResponseWriter }).Unwrap()
+	}))
+
+	// Supported
+	w := &hijackPushWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, hijackErr)
+	assert.Nil(t, pushErr)
+	assert.True(t, w.hijacked)
+	assert.Equal(t, "/style.css", w.pushed)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, w, unwrapped)
+
+	// Not supported
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(struct{ http.ResponseWriter }{rec}, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.ErrNotSupported, hijackErr)
+	assert.Equal(t, http.ErrNotSupported, pushErr)
+}
+
+////
+// Logger.HTTPMiddleware - Request scope through a Logger instance
+// 1) Serve a request through a Logger's middleware with the default Logger
+//    disabled
+//  -> Start, handler, status and End lines written to the Logger's writer
+//  -> Nothing written by the default Logger
+////
+func Test_AlogRequest_LoggerHTTPMiddleware(t *testing.T) {
+	appWriter := NewMemoryWriter()
+	SetWriter(appWriter)
+	ConfigDefaultLevel(OFF)
+	defer ResetDefaults()
+
+	l := NewLogger()
+	w := NewMemoryWriter()
+	l.SetWriter(w)
+	l.UseJSONLogFormatter()
+	l.ConfigDefaultLevel(INFO)
+	ch := l.UseChannel("HTTP")
+	l.HTTPMiddleware("HTTP", INFO)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch.Log(INFO, "Handling")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	entries := w.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "Start: GET /items", entries[0].Format)
+		assert.Equal(t, "Handling", entries[1].Format)
+		assert.Equal(t, 1, entries[1].NIndent)
+		assert.Equal(t, "200", fmt.Sprint(entries[2].MapData["status"]))
+		assert.Equal(t, "End: GET /items", entries[3].Format)
+		assert.NotEmpty(t, entries[1].RequestID)
+	}
+	assert.Empty(t, appWriter.Lines())
+}
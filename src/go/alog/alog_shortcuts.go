@@ -0,0 +1,225 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+// This file holds the level-specific shorthands for Log. Each one builds its
+// entry and hands it straight to the core logger rather than calling Log so
+// that the call depth between user code and the core logger is the same as for
+// Printf.
+
+//-- Package Level Shorthands --------------------------------------------------
+
+// Errorf - Log to the given channel at the ERROR level
+func Errorf(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     ERROR,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Warningf - Log to the given channel at the WARNING level
+func Warningf(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     WARNING,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Infof - Log to the given channel at the INFO level
+func Infof(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     INFO,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Tracef - Log to the given channel at the TRACE level
+func Tracef(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     TRACE,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Debugf - Log to the given channel at the DEBUG level
+func Debugf(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     DEBUG,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Debug1f - Log to the given channel at the DEBUG1 level
+func Debug1f(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     DEBUG1,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Debug2f - Log to the given channel at the DEBUG2 level
+func Debug2f(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     DEBUG2,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Debug3f - Log to the given channel at the DEBUG3 level
+func Debug3f(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     DEBUG3,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+// Debug4f - Log to the given channel at the DEBUG4 level
+func Debug4f(channel LogChannel, format string, v ...interface{}) {
+	testHelper()()
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     DEBUG4,
+		Format:    format,
+		Expansion: v,
+	})
+}
+
+//-- Channel Log Shorthands ----------------------------------------------------
+
+// Errorf - Errorf for a LogChannel instance
+func (ch *channelLogImpl) Errorf(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(ERROR, nil, format, v))
+}
+
+// Warningf - Warningf for a LogChannel instance
+func (ch *channelLogImpl) Warningf(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(WARNING, nil, format, v))
+}
+
+// Infof - Infof for a LogChannel instance
+func (ch *channelLogImpl) Infof(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(INFO, nil, format, v))
+}
+
+// Tracef - Tracef for a LogChannel instance
+func (ch *channelLogImpl) Tracef(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(TRACE, nil, format, v))
+}
+
+// Debugf - Debugf for a LogChannel instance
+func (ch *channelLogImpl) Debugf(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(DEBUG, nil, format, v))
+}
+
+// Debug1f - Debug1f for a LogChannel instance
+func (ch *channelLogImpl) Debug1f(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(DEBUG1, nil, format, v))
+}
+
+// Debug2f - Debug2f for a LogChannel instance
+func (ch *channelLogImpl) Debug2f(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(DEBUG2, nil, format, v))
+}
+
+// Debug3f - Debug3f for a LogChannel instance
+func (ch *channelLogImpl) Debug3f(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(DEBUG3, nil, format, v))
+}
+
+// Debug4f - Debug4f for a LogChannel instance
+func (ch *channelLogImpl) Debug4f(format string, v ...interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.entry(DEBUG4, nil, format, v))
+}
+
+//-- Nop Channel Log Shorthands ------------------------------------------------
+
+// Errorf - No-op
+func (nopChannelLog) Errorf(format string, v ...interface{}) {
+}
+
+// Warningf - No-op
+func (nopChannelLog) Warningf(format string, v ...interface{}) {
+}
+
+// Infof - No-op
+func (nopChannelLog) Infof(format string, v ...interface{}) {
+}
+
+// Tracef - No-op
+func (nopChannelLog) Tracef(format string, v ...interface{}) {
+}
+
+// Debugf - No-op
+func (nopChannelLog) Debugf(format string, v ...interface{}) {
+}
+
+// Debug1f - No-op
+func (nopChannelLog) Debug1f(format string, v ...interface{}) {
+}
+
+// Debug2f - No-op
+func (nopChannelLog) Debug2f(format string, v ...interface{}) {
+}
+
+// Debug3f - No-op
+func (nopChannelLog) Debug3f(format string, v ...interface{}) {
+}
+
+// Debug4f - No-op
+func (nopChannelLog) Debug4f(format string, v ...interface{}) {
+}
@@ -0,0 +1,116 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Level Shorthands ////////////////////////////////////////////////////
+
+////
+// Package shorthands - Each shorthand logs at its fixed level
+// 1) Configure the default level to DEBUG4
+// 2) Log with each shorthand
+//  -> One line per shorthand at the matching level
+////
+func Test_AlogShortcuts_Package(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(DEBUG4)
+	defer ResetDefaults()
+
+	// Log with each shorthand
+	Errorf("TEST", "Errorf %d", 1)
+	Warningf("TEST", "Warningf %d", 2)
+	Infof("TEST", "Infof %d", 3)
+	Tracef("TEST", "Tracef %d", 4)
+	Debugf("TEST", "Debugf %d", 5)
+	Debug1f("TEST", "Debug1f %d", 6)
+	Debug2f("TEST", "Debug2f %d", 7)
+	Debug3f("TEST", "Debug3f %d", 8)
+	Debug4f("TEST", "Debug4f %d", 9)
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "Errorf 1"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "Warningf 2"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Infof 3"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Tracef 4"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "Debugf 5"},
+		ExpEntry{channel: "TEST ", level: "DBG1", body: "Debug1f 6"},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Debug2f 7"},
+		ExpEntry{channel: "TEST ", level: "DBG3", body: "Debug3f 8"},
+		ExpEntry{channel: "TEST ", level: "DBG4", body: "Debug4f 9"},
+	}))
+}
+
+////
+// Channel shorthands - Shorthands respect the channel and its level
+// 1) Configure the default level to INFO and the channel to DEBUG
+// 2) Log with each shorthand through a ChannelLog with a component
+//  -> Lines above DEBUG are filtered out and the component is kept
+// 3) Log with each shorthand through NopChannelLog
+//  -> Nothing logged
+////
+func Test_AlogShortcuts_Channel(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	Config(INFO, ChannelMap{"TEST": DEBUG})
+	defer ResetDefaults()
+
+	// Log with each shorthand
+	ch := UseChannel("TEST").WithComponent("comp")
+	ch.Errorf("Errorf")
+	ch.Warningf("Warningf")
+	ch.Infof("Infof")
+	ch.Tracef("Tracef")
+	ch.Debugf("Debugf %s", "x")
+	ch.Debug1f("Debug1f")
+	ch.Debug2f("Debug2f")
+	ch.Debug3f("Debug3f")
+	ch.Debug4f("Debug4f")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "ERRR", body: "(comp) Errorf"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "(comp) Warningf"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "(comp) Infof"},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "(comp) Tracef"},
+		ExpEntry{channel: "TEST ", level: "DBUG", body: "(comp) Debugf x"},
+	}))
+
+	// Nothing from the nop logger
+	entries = entries[:0]
+	nop := NopChannelLog()
+	nop.Errorf("Errorf")
+	nop.Infof("Infof")
+	nop.Debug4f("Debug4f")
+	assert.Equal(t, 0, len(entries))
+}
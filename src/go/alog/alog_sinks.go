@@ -0,0 +1,99 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+//-- Sink Health ---------------------------------------------------------------
+
+// The last write error of each writer that is currently failing. Writers are
+// removed once a write to them succeeds again.
+type sinkErrors struct {
+	mutex   sync.Mutex
+	errs    map[io.Writer]error
+	nFailed uint32
+}
+
+// Record the result of a write to a writer. Successful writes only take the
+// lock if some writer is currently failing. Writers whose dynamic type is not
+// comparable can't be used as map keys and are not tracked.
+func (s *sinkErrors) record(w io.Writer, err error) {
+	if nil == err && 0 == atomic.LoadUint32(&s.nFailed) {
+		return
+	}
+	if !reflect.TypeOf(w).Comparable() {
+		return
+	}
+	s.mutex.Lock()
+	if nil == err {
+		delete(s.errs, w)
+	} else {
+		if nil == s.errs {
+			s.errs = map[io.Writer]error{}
+		}
+		s.errs[w] = err
+	}
+	atomic.StoreUint32(&s.nFailed, uint32(len(s.errs)))
+	s.mutex.Unlock()
+}
+
+// Get the last write error of a writer, or nil if it is healthy
+func (s *sinkErrors) get(w io.Writer) error {
+	if 0 == atomic.LoadUint32(&s.nFailed) || !reflect.TypeOf(w).Comparable() {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.errs[w]
+}
+
+// Forget all recorded errors
+func (s *sinkErrors) clear() {
+	s.mutex.Lock()
+	s.errs = nil
+	atomic.StoreUint32(&s.nFailed, 0)
+	s.mutex.Unlock()
+}
+
+// SinkStatus - Get the health of each writer that entries are currently
+// written to, as the error returned by its last write or nil if the last write
+// succeeded. This is intended for health endpoints that report on the log
+// pipeline. The writers are named by their role:
+//
+// * "writer" - The writer set with SetWriter
+// * "error_stream"/"output_stream" - The writers for severe and other entries
+//    while the std stream split is enabled
+// * "std_output"/"json_output" - The writers while dual output is enabled
+//
+// Writers whose dynamic type is not comparable (e.g. a struct value holding a
+// slice) can't be tracked and always report nil.
+func SinkStatus() map[string]error {
+	return defaultLogger.SinkStatus()
+}
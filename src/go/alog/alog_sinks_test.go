@@ -0,0 +1,111 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"errors"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Sink Health /////////////////////////////////////////////////////////
+
+// Writer that fails while err is set
+type toggleWriter struct {
+	err error
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if nil != w.err {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+////
+// SinkStatus - Report the last write error of the writer
+// 1) Log to a healthy writer
+//  -> Writer reports nil
+// 2) Make the writer fail and log
+//  -> Writer reports the error
+// 3) Let the writer recover and log
+//  -> Writer reports nil again
+////
+func Test_AlogSinks_Writer(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	w := &toggleWriter{}
+	SetWriter(w)
+
+	// Healthy
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, map[string]error{"writer": nil}, SinkStatus())
+
+	// Failing
+	w.err = errors.New("disk full")
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, map[string]error{"writer": w.err}, SinkStatus())
+
+	// Recovered
+	w.err = nil
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, map[string]error{"writer": nil}, SinkStatus())
+}
+
+////
+// SinkStatus - Report each writer of a multi-writer configuration
+// 1) Enable dual output with a failing JSON writer and log
+//  -> std_output reports nil, json_output reports the error
+// 2) Split the std streams with a failing error writer and log at both levels
+//  -> error_stream reports the error, output_stream reports nil
+////
+func Test_AlogSinks_MultiWriter(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	defer DisableDualOutput()
+
+	// Dual output
+	failing := &toggleWriter{err: errors.New("connection refused")}
+	EnableDualOutput(NewMemoryWriter(), failing)
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, map[string]error{
+		"std_output":  nil,
+		"json_output": failing.err,
+	}, SinkStatus())
+	DisableDualOutput()
+
+	// Stream split
+	failingErr := &toggleWriter{err: errors.New("broken pipe")}
+	std.enableStreamSplit(failingErr, NewMemoryWriter())
+	Log("TEST", WARNING, "Careful")
+	Log("TEST", INFO, "Hello")
+	assert.Equal(t, map[string]error{
+		"error_stream":  failingErr.err,
+		"output_stream": nil,
+	}, SinkStatus())
+}
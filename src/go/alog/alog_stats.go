@@ -0,0 +1,109 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+//-- Stats ---------------------------------------------------------------------
+
+// Stats - Snapshot of the counts of entries emitted per level and suppressed
+// by level filtering per channel
+type Stats struct {
+	Emitted    map[LogLevel]uint64
+	Suppressed map[LogChannel]uint64
+}
+
+// Counters for the stats. These are kept outside of the alogger so that they
+// can be updated from inside the read lock without contention.
+var (
+	emittedCounts    [DEBUG4 + 1]uint64
+	suppressedCounts sync.Map
+)
+
+// Count an entry that was emitted
+func countEmitted(level LogLevel) {
+	if level >= OFF && level <= DEBUG4 {
+		atomic.AddUint64(&emittedCounts[level], 1)
+	}
+}
+
+// Count an entry that was suppressed by level filtering
+func countSuppressed(channel LogChannel) {
+	counter, ok := suppressedCounts.Load(channel)
+	if !ok {
+		var zero uint64
+		counter, _ = suppressedCounts.LoadOrStore(channel, &zero)
+	}
+	atomic.AddUint64(counter.(*uint64), 1)
+}
+
+// Check whether a channel and level are enabled, counting the entry as
+// suppressed if not. This is used by functions that skip building the entry
+// entirely when it is disabled.
+func (cfg *alogger) enabledOrSuppressed(channel LogChannel, level LogLevel) bool {
+	cfg.mutex.RLock()
+	enabled := cfg.isEnabled(channel, level)
+	cfg.mutex.RUnlock()
+	if !enabled {
+		countSuppressed(channel)
+	}
+	return enabled
+}
+
+// GetStats - Get the number of entries emitted per level and suppressed per
+// channel since startup or the last call to ResetStats. Levels and channels
+// with no entries are omitted.
+func GetStats() Stats {
+	stats := Stats{
+		Emitted:    map[LogLevel]uint64{},
+		Suppressed: map[LogChannel]uint64{},
+	}
+	for lvl := range emittedCounts {
+		if n := atomic.LoadUint64(&emittedCounts[lvl]); n > 0 {
+			stats.Emitted[LogLevel(lvl)] = n
+		}
+	}
+	suppressedCounts.Range(func(k, v interface{}) bool {
+		if n := atomic.LoadUint64(v.(*uint64)); n > 0 {
+			stats.Suppressed[k.(LogChannel)] = n
+		}
+		return true
+	})
+	return stats
+}
+
+// ResetStats - Set all counters back to zero
+func ResetStats() {
+	for lvl := range emittedCounts {
+		atomic.StoreUint64(&emittedCounts[lvl], 0)
+	}
+	suppressedCounts.Range(func(k, v interface{}) bool {
+		atomic.StoreUint64(v.(*uint64), 0)
+		return true
+	})
+}
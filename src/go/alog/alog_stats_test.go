@@ -0,0 +1,89 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Stats ///////////////////////////////////////////////////////////////
+
+////
+// Stats - Emitted and suppressed entries are counted
+// 1) Reset the stats and configure INFO by default with a DEBUG channel
+// 2) Log enabled and disabled entries through the various log functions
+//  -> Emitted counted per level, suppressed counted per channel
+// 3) Reset the stats
+//  -> All counts empty
+////
+func Test_AlogStats_Counts(t *testing.T) {
+
+	// Configure
+	ResetStats()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	Config(INFO, ChannelMap{"LOUD": DEBUG})
+	defer ResetDefaults()
+	defer ResetStats()
+
+	// Enabled
+	Log("TEST", INFO, "emitted")
+	LogMap("TEST", WARNING, map[string]interface{}{"a": 1})
+	LogWithMap("LOUD", DEBUG, map[string]interface{}{"a": 1}, "emitted")
+	UseChannel("LOUD").Debugf("emitted")
+
+	// Disabled
+	Log("TEST", DEBUG, "suppressed")
+	LogMap("TEST", TRACE, map[string]interface{}{"a": 1})
+	LogFunc("TEST", DEBUG, func() string { return "suppressed" })
+	UseChannel("LOUD").Debug2f("suppressed")
+	Log("QUIET", DEBUG4, "suppressed")
+
+	// Check the counts
+	stats := GetStats()
+	assert.Equal(t, map[LogLevel]uint64{
+		INFO:    1,
+		WARNING: 1,
+		DEBUG:   2,
+	}, stats.Emitted)
+	assert.Equal(t, map[LogChannel]uint64{
+		"TEST":  3,
+		"LOUD":  1,
+		"QUIET": 1,
+	}, stats.Suppressed)
+
+	// Output unchanged
+	assert.Equal(t, 5, len(entries))
+
+	// Reset
+	ResetStats()
+	stats = GetStats()
+	assert.Equal(t, 0, len(stats.Emitted))
+	assert.Equal(t, 0, len(stats.Suppressed))
+}
@@ -0,0 +1,76 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+//-- Standard Library Log Bridge -----------------------------------------------
+
+// Writer that passes each line to a standard library logger
+type stdLogWriter struct {
+	logger *log.Logger
+}
+
+// NewStdLogWriter - Create an io.Writer that passes each line written to it to
+// the given standard library logger, so SetWriter can send alog output through
+// an existing *log.Logger. The logger's prefix and flags are applied on top of
+// the alog header, so it is usually created with flags set to 0.
+func NewStdLogWriter(l *log.Logger) io.Writer {
+	return &stdLogWriter{logger: l}
+}
+
+// Write - Write a line to the standard logger
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	if err := w.logger.Output(2, strings.TrimSuffix(string(p), "\n")); nil != err {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Writer that logs each line written to it as an alog entry
+type channelLevelWriter struct {
+	channel LogChannel
+	level   LogLevel
+}
+
+// Write - Log the line without its trailing newline
+func (w channelLevelWriter) Write(p []byte) (int, error) {
+	if IsEnabled(w.channel, w.level) {
+		Log(w.channel, w.level, "%s", strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+// AsStdLogger - Get a standard library logger whose output is logged on the
+// given channel and level, e.g. for the ErrorLog of an http.Server or any other
+// library that requires a *log.Logger. The logger has no prefix or flags since
+// alog adds its own header.
+func AsStdLogger(channel LogChannel, level LogLevel) *log.Logger {
+	return log.New(channelLevelWriter{channel: channel, level: level}, "", 0)
+}
@@ -0,0 +1,81 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Standard Library Log Bridge /////////////////////////////////////////
+
+////
+// StdLogWriter - Test writing alog output to a standard library logger
+//
+// 1) Log single and multi-line entries with a StdLogWriter as the writer
+//  -> One standard log line per alog line, with the logger's prefix
+////
+func Test_AlogStdLog_Writer(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	buf := &bytes.Buffer{}
+	SetWriter(NewStdLogWriter(log.New(buf, "legacy: ", 0)))
+
+	Log("TEST", INFO, "hello")
+	Log("TEST", WARNING, "line one\nline two")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Regexp(t, `^legacy: .* \[TEST :INFO\] hello$`, lines[0])
+		assert.Regexp(t, `^legacy: .* \[TEST :WARN\] line one$`, lines[1])
+		assert.Regexp(t, `^legacy: .* \[TEST :WARN\] line two$`, lines[2])
+	}
+}
+
+////
+// AsStdLogger - Test logging through a standard library logger
+//
+// 1) Print to the logger from AsStdLogger at an enabled level
+//  -> Logged on the channel and level without the trailing newline
+// 2) Print to a logger for a disabled level
+//  -> Nothing logged
+////
+func Test_AlogStdLog_AsStdLogger(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	AsStdLogger("HTTP", WARNING).Printf("http: TLS handshake error from %s", "1.2.3.4")
+	AsStdLogger("HTTP", DEBUG).Println("not shown")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "HTTP ", level: "WARN", body: "http: TLS handshake error from 1.2.3.4"},
+	}))
+}
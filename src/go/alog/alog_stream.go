@@ -0,0 +1,137 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//-- Stream Writer -------------------------------------------------------------
+
+// Buffer size used by NewStreamWriter
+const defaultStreamBufSize = 256
+
+// StreamWriter - io.Writer implementation that sends each formatted line on a
+// channel so that it can be streamed to clients, for example from an SSE or
+// websocket handler. The trailing newline is removed from each line.
+//
+// The DropPolicy decides what happens when the channel buffer is full. All
+// methods are safe to call from multiple goroutines.
+type StreamWriter struct {
+	// Accessed atomically, so kept first for 64-bit alignment
+	dropped uint64
+
+	mutex     sync.Mutex
+	lines     chan string
+	policy    DropPolicy
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewStreamWriter - Create a writer and the channel that receives each line
+// written to it. The channel buffers up to 256 lines and new lines are dropped
+// while it is full so that logging never blocks on a slow client. Use
+// NewStreamWriterWithPolicy to configure this.
+func NewStreamWriter() (io.Writer, <-chan string) {
+	return NewStreamWriterWithPolicy(defaultStreamBufSize, DropNewest)
+}
+
+// NewStreamWriterWithPolicy - Create a StreamWriter whose channel buffers up to
+// bufSize lines, using the given policy when it is full. A bufSize less than 1
+// is treated as 1.
+func NewStreamWriterWithPolicy(bufSize int, policy DropPolicy) (*StreamWriter, <-chan string) {
+	if bufSize < 1 {
+		bufSize = 1
+	}
+	sw := &StreamWriter{
+		lines:  make(chan string, bufSize),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	return sw, sw.lines
+}
+
+// Write - Send a single log line on the channel. With the DropNewest and
+// DropOldest policies this never blocks and never reports dropped lines as
+// errors; use DroppedCount to find out how many were lost.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return 0, errors.New("Write to closed StreamWriter")
+	}
+	line := strings.TrimSuffix(string(p), "\n")
+	for {
+		select {
+		case w.lines <- line:
+			return len(p), nil
+		default:
+		}
+		switch w.policy {
+		case DropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case DropOldest:
+			// The reader may empty the channel in the meantime, so only count
+			// a line as dropped if one was actually removed
+			select {
+			case <-w.lines:
+				atomic.AddUint64(&w.dropped, 1)
+			default:
+			}
+		default:
+			select {
+			case w.lines <- line:
+				return len(p), nil
+			case <-w.done:
+				return 0, errors.New("Write to closed StreamWriter")
+			}
+		}
+	}
+}
+
+// DroppedCount - Get the total number of lines dropped because the channel was
+// full
+func (w *StreamWriter) DroppedCount() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close - Close the channel once any blocked Write has returned. Subsequent
+// writes return an error.
+func (w *StreamWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.mutex.Lock()
+		w.closed = true
+		close(w.lines)
+		w.mutex.Unlock()
+	})
+	return nil
+}
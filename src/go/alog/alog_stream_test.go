@@ -0,0 +1,149 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Stream Writer ///////////////////////////////////////////////////////
+
+// Read everything currently buffered on a stream channel
+func drainStream(lines <-chan string) []string {
+	out := []string{}
+	for {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				return out
+			}
+			out = append(out, l)
+		default:
+			return out
+		}
+	}
+}
+
+////
+// NewStreamWriter - Test streaming formatted lines through the log pipeline
+// 1) Configure a stream writer and log single and multi-line entries
+//  -> Each formatted line received on the channel without the newline
+// 2) Close the writer
+//  -> Channel closed and further writes fail
+////
+func Test_AlogStream_Lines(t *testing.T) {
+
+	// Configure
+	w, lines := NewStreamWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	Log("TEST", INFO, "first")
+	Log("TEST", INFO, "second\nthird")
+	got := drainStream(lines)
+	if assert.Equal(t, 3, len(got)) {
+		assert.True(t, VerifyLogs([]string{got[0] + "\n", got[1] + "\n", got[2] + "\n"}, []ExpEntry{
+			{channel: "TEST ", level: "INFO", body: "first"},
+			{channel: "TEST ", level: "INFO", body: "second"},
+			{channel: "TEST ", level: "INFO", body: "third"},
+		}))
+	}
+
+	// Close
+	sw := w.(*StreamWriter)
+	assert.Nil(t, sw.Close())
+	assert.Nil(t, sw.Close())
+	_, ok := <-lines
+	assert.False(t, ok)
+	_, err := sw.Write([]byte("late\n"))
+	assert.NotNil(t, err)
+}
+
+////
+// NewStreamWriterWithPolicy - Test backpressure handling when nobody reads
+// 1) Write past the buffer with DropNewest
+//  -> Oldest lines kept, the rest counted as dropped
+// 2) Write past the buffer with DropOldest
+//  -> Newest lines kept, the rest counted as dropped
+// 3) Write past the buffer with BlockWhenFull, then read
+//  -> The write blocks until there is room
+// 4) Close while a write is blocked
+//  -> The blocked write returns an error
+////
+func Test_AlogStream_Backpressure(t *testing.T) {
+
+	// Drop newest
+	sw, lines := NewStreamWriterWithPolicy(2, DropNewest)
+	for _, l := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		n, err := sw.Write([]byte(l))
+		assert.Nil(t, err)
+		assert.Equal(t, len(l), n)
+	}
+	assert.Equal(t, []string{"a", "b"}, drainStream(lines))
+	assert.Equal(t, uint64(2), sw.DroppedCount())
+
+	// Drop oldest
+	sw, lines = NewStreamWriterWithPolicy(2, DropOldest)
+	for _, l := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		sw.Write([]byte(l))
+	}
+	assert.Equal(t, []string{"c", "d"}, drainStream(lines))
+	assert.Equal(t, uint64(2), sw.DroppedCount())
+
+	// Block when full
+	sw, lines = NewStreamWriterWithPolicy(1, BlockWhenFull)
+	sw.Write([]byte("a\n"))
+	done := make(chan error)
+	go func() {
+		_, err := sw.Write([]byte("b\n"))
+		done <- err
+	}()
+	select {
+	case <-done:
+		assert.Fail(t, "Write did not block")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, "a", <-lines)
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{"b"}, drainStream(lines))
+	assert.Equal(t, uint64(0), sw.DroppedCount())
+
+	// Close while blocked
+	sw.Write([]byte("c\n"))
+	go func() {
+		_, err := sw.Write([]byte("d\n"))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sw.Close()
+	assert.NotNil(t, <-done)
+	assert.Equal(t, []string{"c"}, drainStream(lines))
+}
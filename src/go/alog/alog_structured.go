@@ -0,0 +1,199 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"time"
+)
+
+//-- Structured Helpers --------------------------------------------------------
+
+// Create the entry for a LogRetry call. The error field is only set when there
+// is an error.
+func retryEntry(channel LogChannel, level LogLevel, attempt, maxAttempts int, lastErr error, nextDelay time.Duration) LogEntry {
+	e := LogEntry{
+		Channel: channel,
+		Level:   level,
+		MapData: map[string]interface{}{
+			"attempt":       attempt,
+			"max_attempts":  maxAttempts,
+			"next_delay_ms": nextDelay.Milliseconds(),
+		},
+		formatKeys: []string{"attempt", "max_attempts", "next_delay_ms", "error"},
+	}
+	if nil != lastErr {
+		e.MapData["error"] = lastErr.Error()
+		e.Format = "Attempt %d/%d failed: %v. Retrying in %v"
+		e.Expansion = []interface{}{attempt, maxAttempts, lastErr, nextDelay}
+	} else {
+		e.Format = "Attempt %d/%d. Retrying in %v"
+		e.Expansion = []interface{}{attempt, maxAttempts, nextDelay}
+	}
+	return e
+}
+
+// LogRetry - Log an attempt of a retry loop with the standard fields attempt,
+// max_attempts, next_delay_ms and, if lastErr is not nil, error
+func LogRetry(channel LogChannel, level LogLevel, attempt, maxAttempts int, lastErr error, nextDelay time.Duration) {
+	testHelper()()
+	std.log(retryEntry(channel, level, attempt, maxAttempts, lastErr, nextDelay))
+}
+
+// LogRetry - LogRetry for a LogChannel instance
+func (ch *channelLogImpl) LogRetry(level LogLevel, attempt, maxAttempts int, lastErr error, nextDelay time.Duration) {
+	testHelper()()
+	ch.cfg.log(ch.structuredEntry(retryEntry(ch.channel, level, attempt, maxAttempts, lastErr, nextDelay)))
+}
+
+// Create the entry for a LogFlag call. The reason is left out of the message
+// when it is empty.
+func flagEntry(channel LogChannel, level LogLevel, flagName string, value interface{}, reason string) LogEntry {
+	e := LogEntry{
+		Channel: channel,
+		Level:   level,
+		MapData: map[string]interface{}{
+			"flag":        flagName,
+			"flag_value":  value,
+			"flag_reason": reason,
+		},
+		formatKeys: []string{"flag", "flag_value", "flag_reason"},
+	}
+	if len(reason) > 0 {
+		e.Format = "Flag %s evaluated to %v: %s"
+		e.Expansion = []interface{}{flagName, value, reason}
+	} else {
+		e.Format = "Flag %s evaluated to %v"
+		e.Expansion = []interface{}{flagName, value}
+	}
+	return e
+}
+
+// LogFlag - Log the evaluation of a feature flag with the standard fields flag,
+// flag_value and flag_reason
+func LogFlag(channel LogChannel, level LogLevel, flagName string, value interface{}, reason string) {
+	testHelper()()
+	std.log(flagEntry(channel, level, flagName, value, reason))
+}
+
+// LogFlag - LogFlag for a LogChannel instance
+func (ch *channelLogImpl) LogFlag(level LogLevel, flagName string, value interface{}, reason string) {
+	testHelper()()
+	ch.cfg.log(ch.structuredEntry(flagEntry(ch.channel, level, flagName, value, reason)))
+}
+
+// Create the entry for a LogMismatch call
+func mismatchEntry(channel LogChannel, level LogLevel, field string, expected, actual interface{}) LogEntry {
+	return LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    "Mismatch for %s: expected [%v], got [%v]",
+		Expansion: []interface{}{field, expected, actual},
+		MapData: map[string]interface{}{
+			"field":    field,
+			"expected": expected,
+			"actual":   actual,
+		},
+		formatKeys: []string{"field", "expected", "actual"},
+	}
+}
+
+// LogMismatch - Log that a value did not match what was expected with the
+// standard fields field, expected and actual
+func LogMismatch(channel LogChannel, level LogLevel, field string, expected, actual interface{}) {
+	testHelper()()
+	std.log(mismatchEntry(channel, level, field, expected, actual))
+}
+
+// LogMismatch - LogMismatch for a LogChannel instance
+func (ch *channelLogImpl) LogMismatch(level LogLevel, field string, expected, actual interface{}) {
+	testHelper()()
+	ch.cfg.log(ch.structuredEntry(mismatchEntry(ch.channel, level, field, expected, actual)))
+}
+
+// Create the entry for a LogHealth call. If escalate is set, unhealthy results
+// are escalated to WARNING if the requested level is less severe. The checked
+// component is carried in the entry's Component rather than the map data.
+func healthEntry(channel LogChannel, level LogLevel, component string, healthy bool, detail string, escalate bool) LogEntry {
+	if escalate && !healthy && level > WARNING {
+		level = WARNING
+	}
+	e := LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Component: component,
+		MapData: map[string]interface{}{
+			"healthy": healthy,
+			"detail":  detail,
+		},
+		formatKeys: []string{"healthy", "detail"},
+	}
+	status := "healthy"
+	if !healthy {
+		status = "unhealthy"
+	}
+	if len(detail) > 0 {
+		e.Format = "Health check %s: %s"
+		e.Expansion = []interface{}{status, detail}
+	} else {
+		e.Format = "Health check %s"
+		e.Expansion = []interface{}{status}
+	}
+	return e
+}
+
+// Whether unhealthy LogHealth results are escalated to WARNING
+func (cfg *alogger) healthEscalation() bool {
+	cfg.mutex.RLock()
+	defer cfg.mutex.RUnlock()
+	return cfg.escalateHealth
+}
+
+// EnableHealthEscalation - Enable logging unhealthy LogHealth results that are
+// requested at a level less severe than WARNING (e.g. INFO) at WARNING so that
+// failing checks are visible at typical production levels. This is disabled by
+// default.
+func EnableHealthEscalation() {
+	defaultLogger.EnableHealthEscalation()
+}
+
+// DisableHealthEscalation - Disable escalating unhealthy LogHealth results
+func DisableHealthEscalation() {
+	defaultLogger.DisableHealthEscalation()
+}
+
+// LogHealth - Log the result of a readiness or liveness check with the standard
+// fields component, healthy and detail. Unhealthy results are logged at the
+// requested level unless EnableHealthEscalation is set.
+func LogHealth(channel LogChannel, level LogLevel, component string, healthy bool, detail string) {
+	testHelper()()
+	std.log(healthEntry(channel, level, component, healthy, detail, std.healthEscalation()))
+}
+
+// LogHealth - LogHealth for a LogChannel instance. The checked component
+// replaces the component of the ChannelLog for this entry.
+func (ch *channelLogImpl) LogHealth(level LogLevel, component string, healthy bool, detail string) {
+	testHelper()()
+	ch.cfg.log(ch.structuredEntry(healthEntry(ch.channel, level, component, healthy, detail, ch.cfg.healthEscalation())))
+}
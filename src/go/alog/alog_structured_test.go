@@ -0,0 +1,338 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Structured Helpers //////////////////////////////////////////////////
+
+////
+// Structured Helpers - Standard fields through a ChannelLog
+// 1) Log with each helper through a ChannelLog with a component and fields
+//    using JSON, with and without a trace context
+//  -> Level, message and the helper's fields set alongside the channel fields
+//  -> Component of the ChannelLog kept, except for LogHealth which sets its own
+//  -> Trace and span ids set when logged through Ctx
+// 2) Log the same with the Std formatter
+//  -> A single descriptive line per entry followed by the channel fields
+////
+func Test_AlogStructured_Helpers(t *testing.T) {
+	SetTraceExtractor(fakeExtractor)
+	defer ResetDefaults()
+	ctx := context.WithValue(context.Background(), fakeSpanKey{}, fakeSpan{"abc", "def"})
+
+	testCases := []struct {
+		name      string
+		log       func(ch ChannelLogger)
+		level     LogLevel
+		component string
+		format    string
+		mapData   map[string]interface{}
+	}{
+		{
+			name:      "LogValue",
+			log:       func(ch ChannelLogger) { ch.LogValue(INFO, "count", "3") },
+			level:     INFO,
+			component: "comp",
+			format:    "count = 3",
+			mapData:   map[string]interface{}{"count": "3"},
+		},
+		{
+			name:      "LogFlag",
+			log:       func(ch ChannelLogger) { ch.LogFlag(INFO, "new-checkout", true, "user in beta cohort") },
+			level:     INFO,
+			component: "comp",
+			format:    "Flag new-checkout evaluated to true: user in beta cohort",
+			mapData: map[string]interface{}{
+				"flag":        "new-checkout",
+				"flag_value":  true,
+				"flag_reason": "user in beta cohort",
+			},
+		},
+		{
+			name:      "LogMismatch",
+			log:       func(ch ChannelLogger) { ch.LogMismatch(WARNING, "owner", "bob", "alice") },
+			level:     WARNING,
+			component: "comp",
+			format:    "Mismatch for owner: expected [bob], got [alice]",
+			mapData: map[string]interface{}{
+				"field":    "owner",
+				"expected": "bob",
+				"actual":   "alice",
+			},
+		},
+		{
+			name: "LogRetry",
+			log: func(ch ChannelLogger) {
+				ch.LogRetry(WARNING, 2, 5, errors.New("connection refused"), 1500*time.Millisecond)
+			},
+			level:     WARNING,
+			component: "comp",
+			format:    "Attempt 2/5 failed: connection refused. Retrying in 1.5s",
+			mapData: map[string]interface{}{
+				"attempt":       "2",
+				"max_attempts":  "5",
+				"error":         "connection refused",
+				"next_delay_ms": "1500",
+			},
+		},
+		{
+			name:      "LogHealth",
+			log:       func(ch ChannelLogger) { ch.LogHealth(INFO, "db", false, "connection refused") },
+			level:     INFO,
+			component: "db",
+			format:    "Health check unhealthy: connection refused",
+			mapData: map[string]interface{}{
+				"healthy": false,
+				"detail":  "connection refused",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, withCtx := range []bool{false, true} {
+			ch := UseChannel("TEST").WithComponent("comp").WithFields(map[string]interface{}{"pod": "a"})
+			traceID, spanID := "", ""
+			if withCtx {
+				ch = ch.Ctx(ctx)
+				traceID, spanID = "abc", "def"
+			}
+
+			// JSON
+			w := NewMemoryWriter()
+			SetWriter(w)
+			UseJSONLogFormatter()
+			ConfigDefaultLevel(INFO)
+			tc.log(ch)
+			if entries := w.Entries(); assert.Equal(t, 1, len(entries), tc.name) {
+				e := entries[0]
+				assert.Equal(t, tc.level, e.Level, tc.name)
+				assert.Equal(t, tc.format, e.Format, tc.name)
+				assert.Equal(t, tc.component, e.Component, tc.name)
+				assert.Equal(t, traceID, e.TraceID, tc.name)
+				assert.Equal(t, spanID, e.SpanID, tc.name)
+				assert.Equal(t, len(tc.mapData)+1, len(e.MapData), tc.name)
+				assert.Equal(t, "a", e.MapData["pod"], tc.name)
+				// Numbers are parsed back as json.Number, so they are compared
+				// by their string form
+				for k, v := range tc.mapData {
+					if s, ok := v.(string); ok {
+						assert.Equal(t, s, fmt.Sprint(e.MapData[k]), tc.name)
+					} else {
+						assert.Equal(t, v, e.MapData[k], tc.name)
+					}
+				}
+			}
+
+			// Std
+			w.Reset()
+			UseStdLogFormatter()
+			tc.log(ch)
+			if lines := w.Lines(); assert.Equal(t, 2, len(lines), tc.name) {
+				assert.Contains(t, lines[0], "("+tc.component+") "+tc.format, tc.name)
+				assert.Contains(t, lines[1], "pod: a", tc.name)
+			}
+		}
+	}
+}
+
+////
+// LogRetry - Attempts without an error
+// (The shared fields are covered by Test_AlogStructured_Helpers above)
+// 1) Log an attempt with a nil error using JSON
+//  -> No error field
+// 2) Log the same with the Std formatter
+//  -> No error in the line
+////
+func Test_AlogStructured_RetryNilError(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// JSON
+	LogRetry("RTRY", INFO, 1, 5, nil, 250*time.Millisecond)
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "Attempt 1/5. Retrying in 250ms", entries[0].Format)
+		assert.Equal(t, 3, len(entries[0].MapData))
+		assert.NotContains(t, entries[0].MapData, "error")
+	}
+
+	// Std output
+	lines := []string{}
+	ConfigStdLogWriter(&lines)
+	LogRetry("RTRY", INFO, 1, 5, nil, time.Second)
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "RTRY ", level: "INFO", body: "Attempt 1/5. Retrying in 1s"},
+	}))
+}
+
+////
+// LogFlag - Evaluations without a reason
+// (The shared fields are covered by Test_AlogStructured_Helpers above)
+// 1) Log an evaluation with no reason using JSON
+//  -> flag_reason present but empty
+// 2) Log the same with the Std formatter
+//  -> No reason in the line
+////
+func Test_AlogStructured_FlagNoReason(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// JSON
+	LogFlag("FLAG", INFO, "theme", "dark", "")
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "Flag theme evaluated to dark", entries[0].Format)
+		assert.Equal(t, map[string]interface{}{
+			"flag":        "theme",
+			"flag_value":  "dark",
+			"flag_reason": "",
+		}, entries[0].MapData)
+	}
+
+	// Std output
+	lines := []string{}
+	ConfigStdLogWriter(&lines)
+	LogFlag("FLAG", INFO, "limit", 10, "")
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "FLAG ", level: "INFO", body: "Flag limit evaluated to 10"},
+	}))
+}
+
+////
+// LogMismatch - Nil and composite values
+// (The shared fields are covered by Test_AlogStructured_Helpers above)
+// 1) Log a mismatch with a nil actual value using JSON
+//  -> actual present as null
+// 2) Log a mismatch of slices with the Std formatter
+//  -> Values rendered readably in a single line
+////
+func Test_AlogStructured_MismatchValues(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Nil actual
+	LogMismatch("VALID", WARNING, "owner", "bob", nil)
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "Mismatch for owner: expected [bob], got [<nil>]", entries[0].Format)
+		assert.Equal(t, "bob", entries[0].MapData["expected"])
+		if assert.Contains(t, entries[0].MapData, "actual") {
+			assert.Nil(t, entries[0].MapData["actual"])
+		}
+	}
+
+	// Std output
+	lines := []string{}
+	ConfigStdLogWriter(&lines)
+	LogMismatch("VALID", ERROR, "tags", []string{"a"}, []string{"a", "b"})
+	assert.True(t, VerifyLogs(lines, []ExpEntry{
+		ExpEntry{channel: "VALID", level: "ERRR", body: "Mismatch for tags: expected [[a]], got [[a b]]"},
+	}))
+}
+
+////
+// LogHealth - Escalation of unhealthy results
+// (The shared fields are covered by Test_AlogStructured_Helpers above)
+// 1) Log healthy and unhealthy results at INFO with escalation enabled
+//  -> Healthy result at INFO, unhealthy result escalated to WARNING
+// 2) Log an unhealthy result at ERROR
+//  -> Kept at ERROR
+// 3) Log with the Std formatter
+//  -> A single descriptive line per result with the component
+// 4) Disable escalation and log an unhealthy result at INFO
+//  -> Kept at INFO
+////
+func Test_AlogStructured_HealthEscalation(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	EnableHealthEscalation()
+	defer ResetDefaults()
+
+	// Healthy and unhealthy
+	ch := UseChannel("HLTH")
+	ch.LogHealth(INFO, "db", true, "")
+	ch.LogHealth(INFO, "cache", false, "connection refused")
+
+	// Already severe
+	LogHealth("HLTH", ERROR, "queue", false, "backlog full")
+
+	entries := w.Entries()
+	if assert.Equal(t, 3, len(entries)) {
+		assert.Equal(t, INFO, entries[0].Level)
+		assert.Equal(t, "Health check healthy", entries[0].Format)
+		assert.Equal(t, map[string]interface{}{
+			"healthy": true,
+			"detail":  "",
+		}, entries[0].MapData)
+		assert.Equal(t, WARNING, entries[1].Level)
+		assert.Equal(t, ERROR, entries[2].Level)
+		assert.Equal(t, "queue", entries[2].Component)
+	}
+
+	// Std output
+	lines := []string{}
+	ConfigStdLogWriter(&lines)
+	LogHealth("HLTH", INFO, "db", true, "all good")
+	LogHealth("HLTH", DEBUG, "cache", false, "")
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], "[HLTH :INFO] (db) Health check healthy: all good")
+	assert.Contains(t, lines[1], "[HLTH :WARN] (cache) Health check unhealthy")
+
+	// Escalation disabled
+	lines = []string{}
+	DisableHealthEscalation()
+	LogHealth("HLTH", INFO, "cache", false, "")
+	UseChannel("HLTH").LogHealth(INFO, "db", false, "")
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], "[HLTH :INFO] (cache) Health check unhealthy")
+	assert.Contains(t, lines[1], "[HLTH :INFO] (db) Health check unhealthy")
+}
@@ -0,0 +1,256 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+//-- Syslog Writer -------------------------------------------------------------
+
+// Maximum number of lines held while the syslog connection is down
+const syslogMaxPending = 1024
+
+// Minimum time to wait between attempts to redial a dropped connection
+const syslogRedialInterval = time.Second
+
+// Timeout used when dialing the remote syslog daemon
+const syslogDialTimeout = 5 * time.Second
+
+// Timeout for writing a single line before the connection is treated as
+// dropped, so that a stalled daemon does not block logging
+const syslogWriteTimeout = time.Second
+
+// SyslogWriter - io.Writer implementation that sends log lines to a remote
+// syslog daemon over UDP or TCP.
+//
+// If the connection drops or a line can't be written within a short timeout,
+// lines are buffered (up to a fixed limit) and the connection is redialed in
+// the background until the buffered lines are delivered, so Write never waits
+// on a dial or a stalled daemon. Once the buffer is full, the oldest lines are
+// dropped and Write returns an error describing the failure. All methods are
+// safe to call from multiple goroutines.
+type SyslogWriter struct {
+	mutex          sync.Mutex
+	network        string
+	addr           string
+	conn           net.Conn
+	pending        [][]byte
+	lastErr        error
+	lastDial       time.Time
+	redialInterval time.Duration
+	writeTimeout   time.Duration
+	dialing        bool
+	closed         bool
+	done           chan struct{}
+
+	// Function used to dial the daemon, replaceable for testing
+	dial func(network, addr string) (net.Conn, error)
+}
+
+// Default dial function for the SyslogWriter
+func dialSyslog(network, addr string) (net.Conn, error) {
+	return net.DialTimeout(network, addr, syslogDialTimeout)
+}
+
+// NewSyslogWriter - Dial a remote syslog daemon and create a writer for it
+// that can be passed to SetWriter. The network must be one of "udp", "udp4",
+// "udp6", "tcp", "tcp4" or "tcp6". The writer is a *SyslogWriter, so Close and
+// Err are available through a type assertion. Use DialSyslogWriter to get the
+// concrete type directly.
+func NewSyslogWriter(network, addr string) (io.Writer, error) {
+	w, err := DialSyslogWriter(network, addr)
+	if nil != err {
+		return nil, err
+	}
+	return w, nil
+}
+
+// DialSyslogWriter - Dial a remote syslog daemon and create a SyslogWriter for
+// it. The network must be one of "udp", "udp4", "udp6", "tcp", "tcp4" or
+// "tcp6".
+func DialSyslogWriter(network, addr string) (*SyslogWriter, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("Unsupported syslog network [%s]", network)
+	}
+	w := &SyslogWriter{
+		network:        network,
+		addr:           addr,
+		redialInterval: syslogRedialInterval,
+		writeTimeout:   syslogWriteTimeout,
+		done:           make(chan struct{}),
+		dial:           dialSyslog,
+		lastDial:       time.Now(),
+	}
+	conn, err := w.dial(network, addr)
+	if nil != err {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+// Write - Send a single log line to the syslog daemon
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return 0, errors.New("Write to closed SyslogWriter")
+	}
+
+	// Always queue a copy since the caller may reuse the buffer
+	line := make([]byte, len(p))
+	copy(line, p)
+	w.pending = append(w.pending, line)
+
+	// Attempt to deliver everything that is queued, starting a redial if the
+	// connection is down
+	w.flushPending()
+
+	// If the queue has overflowed, drop the oldest lines and report it
+	if len(w.pending) > syslogMaxPending {
+		nDropped := len(w.pending) - syslogMaxPending
+		w.pending = w.pending[nDropped:]
+		return len(p), fmt.Errorf("Dropped %d syslog lines: %v", nDropped, w.lastErr)
+	}
+	return len(p), nil
+}
+
+// Err - Get the most recent connection error, or nil if the connection is
+// currently healthy
+func (w *SyslogWriter) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.lastErr
+}
+
+// Close - Tear down the connection. Any lines still buffered are discarded.
+func (w *SyslogWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.pending = nil
+	close(w.done)
+	if nil != w.conn {
+		err := w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Start redialing the remote daemon in the background unless a dial is
+// already in progress or the redial interval has not passed
+//
+// NOTE: Must be called with the mutex held
+////
+func (w *SyslogWriter) startRedial() {
+	if w.dialing || w.closed || time.Since(w.lastDial) < w.redialInterval {
+		return
+	}
+	w.dialing = true
+	w.lastDial = time.Now()
+	go w.redial()
+}
+
+// Dial the remote daemon without holding the mutex, then install the new
+// connection and deliver the pending lines. A failed dial is retried after the
+// redial interval for as long as lines are pending and the writer is open.
+func (w *SyslogWriter) redial() {
+	for {
+		conn, err := w.dial(w.network, w.addr)
+		w.mutex.Lock()
+		if nil == err {
+			w.dialing = false
+			if w.closed {
+				conn.Close()
+			} else {
+				w.conn = conn
+				w.lastErr = nil
+				w.flushPending()
+			}
+			w.mutex.Unlock()
+			return
+		}
+		w.lastErr = err
+		if w.closed || len(w.pending) == 0 {
+			w.dialing = false
+			w.mutex.Unlock()
+			return
+		}
+		interval := w.redialInterval
+		w.mutex.Unlock()
+
+		// Wait before the next attempt, stopping early if the writer is closed
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-w.done:
+			timer.Stop()
+		}
+		w.mutex.Lock()
+		if w.closed {
+			w.dialing = false
+			w.mutex.Unlock()
+			return
+		}
+		w.lastDial = time.Now()
+		w.mutex.Unlock()
+	}
+}
+
+// Write out as many pending lines as possible, each with a write deadline. If
+// the connection is down or a write fails or times out, the connection is
+// dropped, a background redial is started and the lines stay queued.
+//
+// NOTE: Must be called with the mutex held
+////
+func (w *SyslogWriter) flushPending() {
+	for len(w.pending) > 0 {
+		if nil == w.conn {
+			w.startRedial()
+			return
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+		if _, err := w.conn.Write(w.pending[0]); nil != err {
+			w.lastErr = err
+			w.conn.Close()
+			w.conn = nil
+			continue
+		}
+		w.pending = w.pending[1:]
+	}
+}
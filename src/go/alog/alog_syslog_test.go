@@ -0,0 +1,437 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Syslog Writer ///////////////////////////////////////////////////////
+
+////
+// SyslogWriter TCP - Send lines to a local TCP listener
+// 1) Create a local listener and a SyslogWriter pointed at it
+// 2) Configure alog to use the writer and log some lines
+//  -> Lines received by the listener in order
+////
+func Test_AlogSyslog_TCP(t *testing.T) {
+
+	// Set up the listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// Create the writer and accept the connection
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer w.Close()
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// Log some lines through alog
+	Config(INFO, ChannelMap{})
+	SetWriter(w)
+	defer ResetDefaults()
+	Log("TEST", INFO, "Line one")
+	Log("TEST", INFO, "Line two")
+
+	// Read them back
+	entries := []string{}
+	reader := bufio.NewReader(conn)
+	for i := 0; i < 2; i++ {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		entries = append(entries, line)
+	}
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line one"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line two"},
+	}))
+	assert.Nil(t, w.Err())
+}
+
+////
+// SyslogWriter UDP - Send lines to a local UDP socket
+// 1) Create a local packet listener and a SyslogWriter pointed at it
+// 2) Write a line
+//  -> Line received as a single datagram
+////
+func Test_AlogSyslog_UDP(t *testing.T) {
+
+	// Set up the listener
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+
+	// Create the writer and write a line
+	w, err := DialSyslogWriter("udp", pc.LocalAddr().String())
+	assert.Nil(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("Hello syslog\n"))
+	assert.Nil(t, err)
+
+	// Read it back
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello syslog\n", string(buf[:n]))
+}
+
+////
+// SyslogWriter Reconnect - Make sure a dropped connection is redialed
+// 1) Create a local listener and a SyslogWriter pointed at it
+// 2) Drop the connection from the server side
+// 3) Keep writing until the writer reconnects
+//  -> A new connection is accepted
+//  -> Lines written after the reconnect are received on the new connection
+////
+func Test_AlogSyslog_Reconnect(t *testing.T) {
+
+	// Set up the listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// Create the writer with no delay between redials
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer w.Close()
+	w.redialInterval = 0
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+
+	// Make sure the first connection works, then drop it
+	_, err = w.Write([]byte("first\n"))
+	assert.Nil(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "first\n", line)
+	conn.Close()
+
+	// Accept the reconnect in the background
+	connChan := make(chan net.Conn, 1)
+	go func() {
+		if c, err := l.Accept(); nil == err {
+			connChan <- c
+		}
+	}()
+
+	// Keep writing until the writer redials
+	var newConn net.Conn
+	for i := 0; nil == newConn && i < 500; i++ {
+		w.Write([]byte(fmt.Sprintf("retry %d\n", i)))
+		select {
+		case newConn = <-connChan:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !assert.NotNil(t, newConn) {
+		return
+	}
+	defer newConn.Close()
+
+	// Write a final line and make sure it shows up on the new connection
+	_, err = w.Write([]byte("final\n"))
+	assert.Nil(t, err)
+	newConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(newConn)
+	found := false
+	for !found {
+		line, err := reader.ReadString('\n')
+		if nil != err {
+			break
+		}
+		found = line == "final\n"
+	}
+	assert.True(t, found)
+}
+
+////
+// SyslogWriter Persistent Failure - Make sure a dead daemon doesn't crash
+// 1) Create a local listener and a SyslogWriter pointed at it
+// 2) Shut down the listener and its connection
+// 3) Write lines from several goroutines past the pending buffer limit
+//  -> No panic
+//  -> Write eventually returns an error
+//  -> Err reports the failure
+////
+func Test_AlogSyslog_PersistentFailure(t *testing.T) {
+
+	// Set up the listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	// Create the writer, then kill the remote end
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer w.Close()
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+	conn.Close()
+	l.Close()
+
+	// Write past the buffer limit from multiple goroutines
+	var mu sync.Mutex
+	var gotErr error
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < syslogMaxPending; i++ {
+				if _, err := w.Write([]byte("lost line\n")); nil != err {
+					mu.Lock()
+					gotErr = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NotNil(t, gotErr)
+	assert.NotNil(t, w.Err())
+
+	// Close cleanly and make sure further writes fail
+	assert.Nil(t, w.Close())
+	_, err = w.Write([]byte("after close\n"))
+	assert.NotNil(t, err)
+}
+
+////
+// SyslogWriter Bad Network - Make sure an unsupported network is rejected
+////
+func Test_AlogSyslog_BadNetwork(t *testing.T) {
+	w, err := DialSyslogWriter("unix", "/dev/log")
+	assert.Nil(t, w)
+	assert.NotNil(t, err)
+	iw, err := NewSyslogWriter("unix", "/dev/log")
+	assert.Nil(t, iw)
+	assert.NotNil(t, err)
+}
+
+////
+// NewSyslogWriter - Create the writer as an io.Writer
+// 1) Create a writer for a local listener
+//  -> A *SyslogWriter that can be closed through io.Closer
+////
+func Test_AlogSyslog_NewWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	w, err := NewSyslogWriter("tcp", l.Addr().String())
+	if assert.Nil(t, err) {
+		assert.IsType(t, &SyslogWriter{}, w)
+		if c, ok := w.(io.Closer); assert.True(t, ok) {
+			assert.Nil(t, c.Close())
+		}
+	}
+}
+
+////
+// SyslogWriter Slow Redial - Make sure Write does not wait on a redial
+// 1) Create a writer whose redials block until released
+// 2) Drop the connection and write
+//  -> Write returns while the redial is blocked
+// 3) Release the redial
+//  -> Queued lines delivered on the new connection
+////
+func Test_AlogSyslog_SlowRedial(t *testing.T) {
+
+	// Set up the listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// Create the writer with a blocking redial
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer w.Close()
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+	conn.Close()
+	release := make(chan struct{})
+	w.mutex.Lock()
+	w.redialInterval = 0
+	w.conn.Close()
+	w.conn = nil
+	w.dial = func(network, addr string) (net.Conn, error) {
+		<-release
+		return dialSyslog(network, addr)
+	}
+	w.mutex.Unlock()
+
+	// Write while the redial is blocked
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("queued one\n"))
+		w.Write([]byte("queued two\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on the redial")
+	}
+
+	// Release the redial and read the queued lines
+	close(release)
+	newConn, err := l.Accept()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer newConn.Close()
+	newConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(newConn)
+	for _, exp := range []string{"queued one\n", "queued two\n"} {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		assert.Equal(t, exp, line)
+	}
+}
+
+////
+// SyslogWriter Stalled Daemon - Make sure a peer that stops reading does not
+// block Write
+// 1) Point a writer at a connection that is never read
+// 2) Write a line
+//  -> Write returns once the write deadline passes
+//  -> The connection is dropped and Err reports the timeout
+////
+func Test_AlogSyslog_StalledDaemon(t *testing.T) {
+
+	// Set up the listener and writer
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer w.Close()
+
+	// Swap in a connection that never reads
+	client, server := net.Pipe()
+	defer server.Close()
+	w.mutex.Lock()
+	w.conn.Close()
+	w.conn = client
+	w.writeTimeout = 20 * time.Millisecond
+	w.redialInterval = time.Hour
+	w.mutex.Unlock()
+
+	// Write and make sure it returns
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("stalled\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on a stalled daemon")
+	}
+	w.mutex.Lock()
+	assert.Nil(t, w.conn)
+	assert.Equal(t, 1, len(w.pending))
+	w.mutex.Unlock()
+	if nerr, ok := w.Err().(net.Error); assert.True(t, ok) {
+		assert.True(t, nerr.Timeout())
+	}
+}
+
+////
+// SyslogWriter Redial Retry - Make sure queued lines are delivered without
+// further writes
+// 1) Create a writer whose first redial fails
+// 2) Drop the connection and write a single line
+//  -> The redial is retried in the background
+//  -> The line is delivered on the new connection
+////
+func Test_AlogSyslog_RedialRetry(t *testing.T) {
+
+	// Set up the listener and writer
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	w, err := DialSyslogWriter("tcp", l.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer w.Close()
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+	conn.Close()
+
+	// Fail the first redial
+	var dialMutex sync.Mutex
+	nDials := 0
+	w.mutex.Lock()
+	w.conn.Close()
+	w.conn = nil
+	w.redialInterval = 10 * time.Millisecond
+	w.lastDial = time.Time{}
+	w.dial = func(network, addr string) (net.Conn, error) {
+		dialMutex.Lock()
+		nDials++
+		n := nDials
+		dialMutex.Unlock()
+		if n == 1 {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return dialSyslog(network, addr)
+	}
+	w.mutex.Unlock()
+
+	// Write a single line and wait for it on the new connection
+	_, err = w.Write([]byte("eventually\n"))
+	assert.Nil(t, err)
+	newConn, err := l.Accept()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer newConn.Close()
+	newConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(newConn).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "eventually\n", line)
+	dialMutex.Lock()
+	assert.Equal(t, 2, nDials)
+	dialMutex.Unlock()
+}
@@ -0,0 +1,152 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"sync"
+	"time"
+)
+
+//-- Temporary Channel Levels --------------------------------------------------
+
+// The configuration of a channel before its first pending temporary level
+type channelRevert struct {
+	level LogLevel
+	had   bool
+	exact bool
+	timer revertTimer
+}
+
+// Pending reverts for temporary channel levels of a logger, keyed by channel.
+// These have their own mutex since the reverts run on timer goroutines and
+// take the logger's lock while holding it.
+type channelReverts struct {
+	mutex   sync.Mutex
+	pending map[LogChannel]*channelRevert
+}
+
+// Timer for a pending revert
+type revertTimer interface {
+	Stop() bool
+}
+
+// The function used to schedule reverts, replaceable for testing
+var startRevertTimer = func(d time.Duration, f func()) revertTimer {
+	return time.AfterFunc(d, f)
+}
+
+// ConfigChannelFor - Set the level for a specific channel for the duration d,
+// then restore the channel's previous level (or remove it from the channel map
+// if it was not configured). Calls on different channels revert independently.
+// If the channel already has a pending revert, its timer is replaced so the
+// channel reverts to the level it had before the first call once the latest
+// duration expires. ResetDefaults cancels all pending reverts.
+//
+// NOTE: The revert overwrites the channel's level even if it was changed some
+//  other way in the meantime.
+////
+func ConfigChannelFor(channel LogChannel, level LogLevel, d time.Duration) {
+	defaultLogger.ConfigChannelFor(channel, level, d)
+}
+
+// Common implementation for ConfigChannelFor
+func (cfg *alogger) configChannelFor(channel LogChannel, level LogLevel, d time.Duration) {
+	cfg.reverts.mutex.Lock()
+	defer cfg.reverts.mutex.Unlock()
+	if nil == cfg.reverts.pending {
+		cfg.reverts.pending = map[LogChannel]*channelRevert{}
+	}
+
+	// Each call gets its own revert so that a replaced timer which already
+	// fired finds it is no longer the pending one
+	var r *channelRevert
+	if prev, ok := cfg.reverts.pending[channel]; ok {
+		prev.timer.Stop()
+		r = &channelRevert{level: prev.level, had: prev.had, exact: prev.exact}
+	} else {
+		cfg.mutex.RLock()
+		prevLevel, had := cfg.channelMap[channel]
+		r = &channelRevert{level: prevLevel, had: had, exact: cfg.exactChannels[channel]}
+		cfg.mutex.RUnlock()
+	}
+	cfg.reverts.pending[channel] = r
+	cfg.mutex.Lock()
+	if nil == cfg.channelMap {
+		cfg.channelMap = ChannelMap{}
+	}
+	cfg.channelMap[channel] = level
+	delete(cfg.exactChannels, channel)
+	cfg.mutex.Unlock()
+	r.timer = startRevertTimer(d, func() { cfg.revertChannel(channel, r) })
+}
+
+// Restore a channel's level if the revert is still the pending one for it
+func (cfg *alogger) revertChannel(channel LogChannel, r *channelRevert) {
+	cfg.reverts.mutex.Lock()
+	defer cfg.reverts.mutex.Unlock()
+	if cfg.reverts.pending[channel] != r {
+		return
+	}
+	delete(cfg.reverts.pending, channel)
+
+	// Replace the maps rather than modifying them since GetChannelMap hands
+	// out the live map and this runs on a timer goroutine
+	cfg.mutex.Lock()
+	channelMap := ChannelMap{}
+	for ch, lvl := range cfg.channelMap {
+		channelMap[ch] = lvl
+	}
+	exactChannels := map[LogChannel]bool{}
+	for ch := range cfg.exactChannels {
+		exactChannels[ch] = true
+	}
+	if r.had {
+		channelMap[channel] = r.level
+	} else {
+		delete(channelMap, channel)
+	}
+	if r.exact {
+		exactChannels[channel] = true
+	} else {
+		delete(exactChannels, channel)
+	}
+	cfg.channelMap = channelMap
+	cfg.exactChannels = exactChannels
+	cfg.mutex.Unlock()
+}
+
+// Stop and discard all pending reverts without applying them
+//
+// NOTE: This takes the revert lock, so it must not be called with the
+//  logger's lock held
+////
+func (cfg *alogger) clearChannelReverts() {
+	cfg.reverts.mutex.Lock()
+	for _, r := range cfg.reverts.pending {
+		r.timer.Stop()
+	}
+	cfg.reverts.pending = nil
+	cfg.reverts.mutex.Unlock()
+}
@@ -0,0 +1,217 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Helpers ////////////////////////////////////////////////////////////////
+
+// Revert timer that only fires when told to
+type fakeRevertTimer struct {
+	d       time.Duration
+	f       func()
+	stopped bool
+}
+
+func (t *fakeRevertTimer) Stop() bool {
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+// Fire the revert regardless of whether the timer was stopped, like a timer
+// that expired while being replaced
+func (t *fakeRevertTimer) fire() {
+	t.f()
+}
+
+// Replace the revert timers with fakes for the duration of the test and return
+// the list of timers started
+func useFakeRevertTimers(t *testing.T) *[]*fakeRevertTimer {
+	timers := &[]*fakeRevertTimer{}
+	prev := startRevertTimer
+	startRevertTimer = func(d time.Duration, f func()) revertTimer {
+		timer := &fakeRevertTimer{d: d, f: f}
+		*timers = append(*timers, timer)
+		return timer
+	}
+	t.Cleanup(func() { startRevertTimer = prev })
+	return timers
+}
+
+// Tests - Temporary Channel Levels ////////////////////////////////////////////
+
+////
+// ConfigChannelFor - Temporary levels revert independently per channel
+// 1) Temporarily raise a configured channel and add an unconfigured one for
+//    different durations
+//  -> Both levels applied, unrelated channel untouched
+//  -> One timer started per channel with its duration
+// 2) Fire the first timer
+//  -> Only the first channel reverted to its previous level
+// 3) Fire the second timer
+//  -> Second channel removed from the channel map
+//  -> Unrelated channel untouched throughout
+////
+func Test_AlogTemporary_Revert(t *testing.T) {
+	timers := useFakeRevertTimers(t)
+	Config(INFO, ChannelMap{"HTTP": WARNING, "KEEP": ERROR})
+	defer ResetAll()
+
+	// Apply
+	ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	ConfigChannelFor("DB", DEBUG2, time.Hour)
+	assert.Equal(t, ChannelMap{"HTTP": DEBUG, "DB": DEBUG2, "KEEP": ERROR}, GetChannelMap())
+	if !assert.Len(t, *timers, 2) {
+		return
+	}
+	assert.Equal(t, time.Minute, (*timers)[0].d)
+	assert.Equal(t, time.Hour, (*timers)[1].d)
+
+	// First expires
+	(*timers)[0].fire()
+	assert.Equal(t, ChannelMap{"HTTP": WARNING, "DB": DEBUG2, "KEEP": ERROR}, GetChannelMap())
+
+	// Second expires
+	(*timers)[1].fire()
+	assert.Equal(t, ChannelMap{"HTTP": WARNING, "KEEP": ERROR}, GetChannelMap())
+}
+
+////
+// ConfigChannelFor - Overlapping calls on the same channel
+// 1) Temporarily set an exact channel twice
+//  -> Second level applied, first timer stopped
+// 2) Fire the first timer anyway
+//  -> Second level still applied
+// 3) Fire the second timer
+//  -> Original exact level restored
+////
+func Test_AlogTemporary_Overlapping(t *testing.T) {
+	timers := useFakeRevertTimers(t)
+	Config(INFO, ChannelMap{})
+	ConfigChannelExact("HTTP", WARNING)
+	defer ResetAll()
+
+	// Overlap
+	ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	ConfigChannelFor("HTTP", DEBUG3, time.Hour)
+	assert.Equal(t, DEBUG3, GetChannelMap()["HTTP"])
+	assert.Equal(t, map[LogChannel]bool{}, GetExactChannels())
+	if !assert.Len(t, *timers, 2) {
+		return
+	}
+	assert.True(t, (*timers)[0].stopped)
+
+	// Stale timer
+	(*timers)[0].fire()
+	assert.Equal(t, DEBUG3, GetChannelMap()["HTTP"])
+
+	// Latest timer
+	(*timers)[1].fire()
+	assert.Equal(t, WARNING, GetChannelMap()["HTTP"])
+	assert.Equal(t, map[LogChannel]bool{"HTTP": true}, GetExactChannels())
+}
+
+////
+// ConfigChannelFor - Resetting cancels pending reverts
+// 1) Temporarily set a channel and call ResetDefaults
+//  -> Timer stopped
+// 2) Configure the channel and fire the timer anyway
+//  -> New configuration kept
+// 3) Repeat with ResetAll
+//  -> New configuration kept
+////
+func Test_AlogTemporary_Reset(t *testing.T) {
+	timers := useFakeRevertTimers(t)
+	Config(INFO, ChannelMap{"HTTP": WARNING})
+	defer ResetAll()
+
+	// ResetDefaults
+	ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	ResetDefaults()
+	if !assert.Len(t, *timers, 1) {
+		return
+	}
+	assert.True(t, (*timers)[0].stopped)
+	ConfigChannel("HTTP", ERROR)
+	(*timers)[0].fire()
+	assert.Equal(t, ChannelMap{"HTTP": ERROR}, GetChannelMap())
+
+	// ResetAll
+	ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	ResetAll()
+	if !assert.Len(t, *timers, 2) {
+		return
+	}
+	assert.True(t, (*timers)[1].stopped)
+	ConfigChannel("HTTP", ERROR)
+	(*timers)[1].fire()
+	assert.Equal(t, ChannelMap{"HTTP": ERROR}, GetChannelMap())
+}
+
+////
+// ConfigChannelFor - Temporary levels on a Logger instance
+// 1) Temporarily set a channel on a new Logger
+//  -> Level applied to the Logger only
+// 2) Fire the timer
+//  -> Logger's channel reverted, default Logger untouched
+// 3) Temporarily set a channel on the Logger and reset it
+//  -> Timer stopped
+////
+func Test_AlogTemporary_Logger(t *testing.T) {
+	timers := useFakeRevertTimers(t)
+	Config(INFO, ChannelMap{"HTTP": WARNING})
+	defer ResetAll()
+	l := NewLogger()
+	l.Config(INFO, ChannelMap{"HTTP": ERROR})
+
+	// Apply
+	l.ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	assert.Equal(t, ChannelMap{"HTTP": DEBUG}, l.GetChannelMap())
+	assert.Equal(t, ChannelMap{"HTTP": WARNING}, GetChannelMap())
+	if !assert.Len(t, *timers, 1) {
+		return
+	}
+
+	// Revert
+	(*timers)[0].fire()
+	assert.Equal(t, ChannelMap{"HTTP": ERROR}, l.GetChannelMap())
+	assert.Equal(t, ChannelMap{"HTTP": WARNING}, GetChannelMap())
+
+	// Reset
+	l.ConfigChannelFor("HTTP", DEBUG, time.Minute)
+	l.ResetDefaults()
+	if !assert.Len(t, *timers, 2) {
+		return
+	}
+	assert.True(t, (*timers)[1].stopped)
+}
@@ -26,9 +26,19 @@ package alog
 
 import (
 	// Standard
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	// Third Party
 	"github.com/stretchr/testify/assert"
@@ -180,6 +190,136 @@ func Test_Alog_IndentDisabled(t *testing.T) {
 	ResetDefaults()
 }
 
+////
+// IndentScope - Test the indentation guard
+// 1) Open an IndentScope in a function that returns early
+//  -> Lines inside indented, no Start/End lines, indentation restored after
+//     the early return
+// 2) Close a scope explicitly and again with defer
+//  -> Only one level of indentation removed
+////
+func Test_Alog_IndentScope(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	// Early return
+	doWork := func(fail bool) {
+		defer IndentScope().Close()
+		Log("TEST", DEBUG2, "Working")
+		if fail {
+			return
+		}
+		Log("TEST", DEBUG2, "Done")
+	}
+	Log("TEST", DEBUG2, "Outside")
+	doWork(true)
+	Log("TEST", DEBUG2, "Between")
+	doWork(false)
+	Log("TEST", DEBUG2, "After")
+
+	// Double close
+	Indent()
+	func() {
+		s := IndentScope()
+		defer s.Close()
+		Log("TEST", DEBUG2, "Level 2")
+		s.Close()
+		Log("TEST", DEBUG2, "Level 1")
+	}()
+	Log("TEST", DEBUG2, "Still level 1")
+	Deindent()
+	Log("TEST", DEBUG2, "Made it!")
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Outside", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Working", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Between", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Working", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Done", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "After", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Level 2", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Level 1", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Still level 1", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "DBG2", body: "Made it!", nIndent: 0},
+	}))
+}
+
+////
+// IndentString - Test a custom indent string
+// 1) Set invalid indent strings
+//  -> Errors returned and the indent string unchanged
+// 2) Set a 4-space indent and log with indentation
+//  -> Four spaces per level
+// 3) Log the same as JSON and convert back to plain text and to entries
+//  -> Round-trip matches the Std output with the 4-space indent configured
+// 4) Reset defaults
+//  -> Two space indent restored
+////
+func Test_Alog_IndentString(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Invalid
+	for _, indent := range []string{"", "--", " x ", "\n"} {
+		assert.NotNil(t, SetIndentString(indent), "indent %q", indent)
+	}
+	assert.Equal(t, "  ", GetIndentString())
+
+	// Four spaces
+	assert.Nil(t, SetIndentString("    "))
+	assert.Equal(t, "    ", GetIndentString())
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	logIndented := func() {
+		Log("TEST", INFO, "Level 0")
+		Indent()
+		Log("TEST", INFO, "Level 1")
+		Indent()
+		Log("TEST", INFO, "Level 2")
+		Deindent()
+		Deindent()
+	}
+	logIndented()
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 0", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 1", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 2", nIndent: 2},
+	}))
+	if assert.Equal(t, 3, len(entries)) {
+		assert.Contains(t, entries[2], "]         Level 2\n")
+	}
+
+	// JSON round-trip
+	jsonEntries := []string{}
+	ConfigJSONLogWriter(&jsonEntries)
+	logIndented()
+	plain := []string{}
+	for i, js := range jsonEntries {
+		lines, err := JSONToPlainText(js)
+		if assert.Nil(t, err) && assert.Equal(t, 1, len(lines)) {
+			plain = append(plain, lines[0])
+			le, err := PlainTextToLogEntry(lines[0])
+			if assert.Nil(t, err) {
+				assert.Equal(t, i, le.NIndent)
+			}
+		}
+	}
+	assert.True(t, VerifyLogs(plain, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 0", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 1", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Level 2", nIndent: 2},
+	}))
+
+	// Reset
+	ResetDefaults()
+	assert.Equal(t, "  ", GetIndentString())
+}
+
 ////
 // Channel - Test basic functionality of ChannelLog
 //
@@ -280,6 +420,64 @@ func Test_Alog_GID(t *testing.T) {
 	ResetDefaults()
 }
 
+////
+// GIDFunc - Test injecting the goroutine id
+//
+// 1) Set a GID function returning a fixed id and enable GID display
+// 2) Indent and log, then log from another goroutine
+//  -> The fixed id is shown in the header of every line
+//  -> Both goroutines share the indentation of the fixed id
+// 3) Log with JSON
+//  -> thread_id is the fixed id
+// 4) Restore the default
+//  -> Ids differ between goroutines again
+////
+func Test_Alog_GIDFunc(t *testing.T) {
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	EnableGID()
+	SetGIDFunc(func() uint64 { return 42 })
+	defer ResetAll()
+
+	// Std
+	func() {
+		defer IndentScope().Close()
+		Log("TEST", INFO, "main")
+		done := make(chan bool)
+		go func() {
+			Log("TEST", INFO, "other")
+			done <- true
+		}()
+		<-done
+	}()
+	Log("TEST", INFO, "after")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "main", hasGid: true, nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "other", hasGid: true, nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "after", hasGid: true},
+	}))
+	for _, entry := range entries {
+		assert.Contains(t, entry, "[TEST :INFO:42]")
+	}
+
+	// JSON
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	Log("TEST", INFO, "json")
+	if logged := w.Entries(); assert.Len(t, logged, 1) && assert.NotNil(t, logged[0].GoroutineID) {
+		assert.Equal(t, uint64(42), *logged[0].GoroutineID)
+	}
+
+	// Default
+	SetGIDFunc(nil)
+	gids := make(chan uint64)
+	go func() { gids <- getGID() }()
+	assert.NotEqual(t, <-gids, getGID())
+	assert.NotEqual(t, uint64(42), getGID())
+}
+
 ////
 // IsEnabled - Test the functionality of the IsEnabled function
 //
@@ -371,6 +569,60 @@ func Test_Alog_Scope(t *testing.T) {
 	ResetDefaults()
 }
 
+func doubleCloseFnLog() {
+	s := FnLog("TEST", "")
+	defer s.Close()
+	s.Close()
+}
+
+////
+// ScopeDoubleClose - Test that closing a scope twice has no further effect
+//
+// 1) Close a LogScope explicitly inside a function that also defers Close
+//  -> Single End line, indentation back to the enclosing level
+// 2) Same with FnLog inside an enclosing scope with scope correlation enabled
+//  -> Single End line, enclosing scope still indented and correlated
+////
+func Test_Alog_ScopeDoubleClose(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(TRACE)
+	EnableScopeCorrelation()
+	defer ResetDefaults()
+
+	// LogScope
+	func() {
+		s := LogScope("TEST", INFO, "scope")
+		defer s.Close()
+		Log("TEST", INFO, "inside")
+		s.Close()
+	}()
+	Log("TEST", INFO, "after scope")
+
+	// FnLog inside a scope
+	func() {
+		defer LogScope("TEST", INFO, "outer").Close()
+		doubleCloseFnLog()
+		Log("TEST", INFO, "in outer")
+	}()
+	Log("TEST", INFO, "done")
+
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Start: scope", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "inside", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "End: scope", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "after scope", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Start: outer", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: doubleCloseFnLog()", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: doubleCloseFnLog()", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "in outer", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "End: outer", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "done", nIndent: 0},
+	}))
+}
+
 func freeFuncTest() {
 	ch := UseChannel("FREE")
 	defer ch.DetailFnLog(DEBUG, "").Close()
@@ -504,6 +756,158 @@ func Test_Alog_ServiceName(t *testing.T) {
 	ResetDefaults()
 }
 
+////
+// Sequence - Test tagging entries with a global sequence number
+//
+// 1) Enable the sequence and log from several goroutines on several channels
+//  -> Every entry has a unique sequence number
+//  -> Numbers increase strictly in the order each goroutine logged
+// 2) Log with the std formatter
+//  -> seq= shown in the header and parsed back from the line
+// 3) Disable the sequence
+//  -> No sequence number
+////
+func Test_Alog_Sequence(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	EnableSequence()
+
+	// Concurrent
+	nRoutines, nLines := 8, 50
+	wg := sync.WaitGroup{}
+	for r := 0; r < nRoutines; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			ch := UseChannel(LogChannel(fmt.Sprintf("CH%d", r%3)))
+			for i := 0; i < nLines; i++ {
+				ch.LogWithMap(INFO, map[string]interface{}{"routine": r}, "line %d", i)
+			}
+		}(r)
+	}
+	wg.Wait()
+	entries := w.Entries()
+	assert.Equal(t, nRoutines*nLines, len(entries))
+	seen := map[uint64]bool{}
+	last := map[string]uint64{}
+	for _, e := range entries {
+		assert.True(t, e.Sequence > 0)
+		assert.False(t, seen[e.Sequence])
+		seen[e.Sequence] = true
+		r := fmt.Sprintf("%v", e.MapData["routine"])
+		assert.True(t, e.Sequence > last[r])
+		last[r] = e.Sequence
+	}
+
+	// Std
+	w.Reset()
+	UseStdLogFormatter()
+	Log("TEST", INFO, "Hello")
+	lines := w.Lines()
+	if assert.Equal(t, 1, len(lines)) {
+		assert.Regexp(t, regexp.MustCompile(` \[TEST :INFO seq=[0-9]+\] Hello`), lines[0])
+		e, err := ParseLine(lines[0])
+		if assert.Nil(t, err) {
+			assert.True(t, e.Sequence > 0)
+		}
+	}
+
+	// Disabled
+	w.Reset()
+	DisableSequence()
+	Log("TEST", INFO, "Hello")
+	if lines = w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], " [TEST :INFO] Hello")
+	}
+}
+
+////
+// Unicode Channel - Test header truncation and padding of multi-byte channels
+//
+// 1) Log on a multi-byte channel longer than the header length, with the
+//    truncation boundary just after a multi-byte rune
+//  -> First runes kept intact and the header is valid UTF-8
+// 2) Log on a multi-byte channel shorter than the header length
+//  -> Padded to the header length in runes
+////
+func Test_Alog_UnicodeChannelHeader(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+
+	// Truncated
+	SetMaxChannelLen(4)
+	Log("café-svc", INFO, "Hello")
+	Log("日本語チャンネル", INFO, "Hello")
+	lines := w.Lines()
+	if assert.Equal(t, 2, len(lines)) {
+		assert.True(t, utf8.ValidString(lines[0]))
+		assert.Contains(t, lines[0], " [café:INFO] Hello")
+		assert.True(t, utf8.ValidString(lines[1]))
+		assert.Contains(t, lines[1], " [日本語チ:INFO] Hello")
+	}
+
+	// Padded
+	w.Reset()
+	SetMaxChannelLen(6)
+	Log("café", INFO, "Hello")
+	lines = w.Lines()
+	if assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], " [café  :INFO] Hello")
+		e, err := ParseLine(lines[0])
+		if assert.Nil(t, err) {
+			assert.Equal(t, LogChannel("café"), e.Channel)
+		}
+	}
+}
+
+////
+// Labels - Test static labels with the Std formatter
+//
+// 1) Configure a service name and labels, then log two lines
+//  -> Labels shown sorted after the service name on every line
+//  -> Lines parse back to the labels
+// 2) Reset the defaults
+//  -> Labels cleared
+////
+func Test_Alog_Labels(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	w := NewMemoryWriter()
+	SetWriter(w)
+	SetServiceName("test_service")
+	labels := map[string]string{"tenant": "acme", "region": "eu"}
+	SetLabels(labels)
+	labels["tenant"] = "changed"
+	assert.Equal(t, map[string]string{"tenant": "acme", "region": "eu"}, GetLabels())
+
+	Log("TEST", INFO, "Hi there")
+	LogMap("TEST", INFO, map[string]interface{}{"key": "val"})
+
+	// Check the result
+	lines := w.Lines()
+	if assert.Equal(t, 2, len(lines)) {
+		for _, line := range lines {
+			assert.Contains(t, line, " <test_service> <region=eu,tenant=acme> [TEST :INFO] ")
+			e, err := ParseLine(line)
+			if assert.Nil(t, err) {
+				assert.Equal(t, "test_service", e.Servicename)
+				assert.Equal(t, map[string]string{"tenant": "acme", "region": "eu"}, e.Labels)
+			}
+		}
+	}
+
+	// Reset
+	ResetDefaults()
+	assert.Equal(t, 0, len(GetLabels()))
+}
+
 ////
 // LogMap - Test structured map data logging
 //
@@ -565,130 +969,1705 @@ func Test_Alog_LogWithMap(t *testing.T) {
 	ResetDefaults()
 }
 
-// JSON Tests //////////////////////////////////////////////////////////////////
-
 ////
-// JSON Basic Channel - Verify basic functionality of JSON formatter using
-//  LogChannel
+// OutputTransform - Test that the output transform is applied to every line
 //
-// 1) Configure to use JSON formatter
-// 2) Log a formatted line that should print
-//  -> Log line parses as JSON and is correctly formatted
-// 3) Log on a disabled level
-//  -> Line not logged
+// 1) Configure a transform that wraps each line in STX/ETX markers
+// 2) Log a single line, a multi-line message, and a map
+//  -> Every written line is wrapped in the markers
+// 3) Remove the transform
+//  -> Lines are written unchanged
 ////
-func Test_Alog_JSONBasicChannel(t *testing.T) {
+func Test_Alog_OutputTransform(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
 
-	// Configure
+	// Set up the writer to capture logged lines
 	entries := []string{}
-	ConfigJSONLogWriter(&entries)
-	ConfigDefaultLevel(DEBUG)
-	ch := UseChannel("MAIN")
+	ConfigStdLogWriter(&entries)
+	SetOutputTransform(func(b []byte) []byte {
+		return append(append([]byte{0x02}, b...), 0x03)
+	})
 
-	// Log a simple line on info
-	ch.Log(INFO, "[%d] This is a formatted test", 10)
+	Log("TEST", INFO, "Single line")
+	Log("TEST", INFO, "Line one\nLine two")
+	LogMap("TEST", INFO, map[string]interface{}{"a": 1, "b": 2})
+
+	// Check that each line is wrapped and unwrap for verification
+	assert.Equal(t, 5, len(entries))
+	unwrapped := []string{}
+	for _, entry := range entries {
+		assert.Equal(t, byte(0x02), entry[0])
+		assert.Equal(t, byte(0x03), entry[len(entry)-1])
+		unwrapped = append(unwrapped, entry[1:len(entry)-1])
+	}
+	assert.True(t, VerifyLogs(unwrapped, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Single line"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line one"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line two"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "a: 1"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "b: 2"},
+	}))
 
-	// Log a line on debug3 that shouldn't show up
-	ch.Log(DEBUG3, "This shouldn't show up")
+	// Disable the transform
+	entries = entries[:0]
+	SetOutputTransform(nil)
+	Log("TEST", INFO, "Plain again")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Plain again"},
+	}))
+}
 
-	// Check the result
-	assert.True(t, len(entries) == 1)
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
-		ExpEntry{channel: "MAIN", level: "info", body: "[10] This is a formatted test"},
+// Writer with a Sync method that counts invocations
+type syncCountWriter struct {
+	nSync int
+}
+
+func (w *syncCountWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *syncCountWriter) Sync() error {
+	w.nSync++
+	return nil
+}
+
+////
+// Flush - Test flushing a buffered writer
+//
+// 1) Configure a bufio.Writer wrapping a buffer as the writer
+// 2) Log a line
+//  -> Line not yet visible in the buffer
+// 3) Flush
+//  -> Line visible in the buffer
+// 4) Configure a writer with a Sync method and flush
+//  -> Sync invoked
+// 5) Configure os.Stderr and a plain writer and flush
+//  -> No error
+////
+func Test_Alog_Flush(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Buffered writer
+	buf := &bytes.Buffer{}
+	SetWriter(bufio.NewWriter(buf))
+	Log("TEST", INFO, "Buffered line")
+	assert.Equal(t, 0, buf.Len())
+	assert.Nil(t, Flush())
+	assert.True(t, VerifyLogs([]string{buf.String()}, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Buffered line"},
 	}))
 
-	// Reset for next test
-	ResetDefaults()
+	// Sync writer
+	sw := &syncCountWriter{}
+	SetWriter(sw)
+	assert.Nil(t, Flush())
+	assert.Equal(t, 1, sw.nSync)
+
+	// Plain writers
+	SetWriter(os.Stderr)
+	assert.Nil(t, Flush())
+	SetWriter(NewMemoryWriter())
+	assert.Nil(t, Flush())
 }
 
 ////
-// JSON Happy Path - Repeat the HappyPath test from the Std formatter with JSON
+// NopChannelLog - Test that the nop channel log is safe and silent
 //
-// 1) Log with no configuration
-//  -> No logs created
-// 2) Log to a channel without a specific level filter
-//  -> Use the default level
-// 3) LUog to a channel with a specific level filter
-//  -> se that filter
-// 4) Log with formatting operators
-//  -> Ensure formatting is properly printed
-// 5) Log to a channel with a long name
-//  -> Ensure channel name gets truncated correctly
+// 1) Configure all channels to DEBUG4
+// 2) Use every method of a NopChannelLog
+//  -> No output
+//  -> IsEnabled is false
+//  -> Indentation unchanged
+// 3) Use it as a default struct member
+//  -> Safe to call without initialization
+// 4) Call Panicf
+//  -> Panics without output
 ////
-func Test_Alog_JSONHappyPath(t *testing.T) {
+func Test_Alog_NopChannelLog(t *testing.T) {
+	ConfigDefaultLevel(DEBUG4)
+	defer ResetDefaults()
 
-	// Configure
+	// Set up the writer to capture logged lines
 	entries := []string{}
-	ConfigJSONLogWriter(&entries)
+	ConfigStdLogWriter(&entries)
 
-	// Log with no configuration
-	Log("TEST", INFO, "Test1")
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{}))
-	entries = []string{}
+	// Use every method
+	ch := NopChannelLog()
+	ch.Log(INFO, "Log %d", 1)
+	ch.Printf(INFO, "Printf %d", 1)
+	ch.LogMap(INFO, map[string]interface{}{"a": 1})
+	assert.False(t, ch.IsEnabled(FATAL))
+	func() {
+		defer ch.LogScope(INFO, "scope").Close()
+		defer ch.FnLog("").Close()
+		defer ch.DetailFnLog(DEBUG, "").Close()
+		ch.Log(INFO, "Inside scopes")
+	}()
+	assert.Equal(t, 0, len(entries))
 
-	// Set default level and try again
-	ConfigDefaultLevel(INFO)
-	Log("TEST", INFO, "Test2")
-	Log("TEST", DEBUG, "Debug test!")
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
-		ExpEntry{channel: "TEST", level: "info", body: "Test2"},
-	}))
-	entries = []string{}
+	// Default struct member
+	type doit struct {
+		ch ChannelLog
+	}
+	d := doit{ch: NopChannelLog()}
+	d.ch.Log(INFO, "Still nothing")
+	assert.Equal(t, 0, len(entries))
 
-	// Set TEST channel back to OFF
-	ConfigChannel("TEST", OFF)
-	Log("TEST", INFO, "Can't see me...")
-	Log("FOO", INFO, "Use the default!")
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
-		ExpEntry{channel: "FOO", level: "info", body: "Use the default!"},
-	}))
-	entries = []string{}
+	// Panicf still panics
+	assert.Panics(t, func() { ch.Panicf(ERROR, "Oh no") })
+	assert.Equal(t, 0, len(entries))
 
-	// Log with some formatting
-	for i := 1; i < 3; i++ {
-		Log("FOO", INFO, "This is formatting test [%d]", i)
-	}
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
-		ExpEntry{channel: "FOO", level: "info", body: "This is formatting test [1]"},
-		ExpEntry{channel: "FOO", level: "info", body: "This is formatting test [2]"},
+	// Regular logging is unaffected and not indented
+	Log("TEST", INFO, "Real log")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Real log", nIndent: 0},
 	}))
-	entries = []string{}
+}
 
-	// Test a really long channel name (don't truncate for JSON)
-	Log("LONGCHANNEL", INFO, "Line me up nice...")
-	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
-		ExpEntry{channel: "LONGCHANNEL", level: "info", body: "Line me up nice..."},
-	}))
-	entries = []string{}
+// ChannelLog implementation with only the original method set
+type minimalChannelLog struct {
+	entries *[]string
+}
 
-	// Reset for next test
-	ResetDefaults()
+func (m minimalChannelLog) Log(level LogLevel, format string, v ...interface{}) {
+	*m.entries = append(*m.entries, fmt.Sprintf(format, v...))
+}
+func (m minimalChannelLog) Printf(level LogLevel, format string, v ...interface{}) {
+	m.Log(level, format, v...)
+}
+func (m minimalChannelLog) Panicf(level LogLevel, format string, v ...interface{}) {
+}
+func (m minimalChannelLog) Fatalf(level LogLevel, format string, v ...interface{}) {
+}
+func (m minimalChannelLog) LogMap(level LogLevel, mapData map[string]interface{}) {
+}
+func (m minimalChannelLog) IsEnabled(level LogLevel) bool {
+	return true
+}
+func (m minimalChannelLog) LogScope(level LogLevel, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+func (m minimalChannelLog) FnLog(format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
+}
+func (m minimalChannelLog) DetailFnLog(level LogLevel, format string, v ...interface{}) ScopedLogger {
+	return nopScopedLogger{}
 }
 
 ////
-// JSON Service Name - Test ServiceName functionality with JSON output
+// ChannelLog - Test that the original interface is still satisfied
 //
-// 1) Configure a service name
-// 2) Log an enabled message
-//  -> Logged with service name
-// 3) Log a disabled message
-//  -> Not logged
+// 1) Use an implementation with only the original methods as a ChannelLog
+//  -> Calls reach the implementation
+//  -> Not a ChannelLogger
+// 2) Use the value returned by UseChannel as a ChannelLog
+//  -> Can be asserted back to a ChannelLogger
 ////
-func Test_Alog_JSONServiceName(t *testing.T) {
+func Test_Alog_ChannelLogCompat(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
 
-	// Configure
+	// Minimal implementation
 	entries := []string{}
-	ConfigJSONLogWriter(&entries)
-	sn := "test_service"
-	SetServiceName(sn)
-	ConfigDefaultLevel(DEBUG)
-	ch := UseChannel("MAIN")
+	var ch ChannelLog = minimalChannelLog{entries: &entries}
+	ch.Log(INFO, "Hello %s", "world")
+	assert.Equal(t, []string{"Hello world"}, entries)
+	_, ok := ch.(ChannelLogger)
+	assert.False(t, ok)
+
+	// Extension
+	ch = UseChannel("TEST")
+	_, ok = ch.(ChannelLogger)
+	assert.True(t, ok)
+}
 
-	// Log a simple line on info
-	ch.Log(INFO, "[%d] This is a formatted test", 10)
+////
+// LogValue - Test logging a single named value
+//
+// 1) Log a named value with LogValue
+//  -> Single "name = value" line
+////
+func Test_Alog_LogValue(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
 
-	// Log a line on debug3 that shouldn't show up
-	ch.Log(DEBUG3, "This shouldn't show up")
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	ch := UseChannel("TEST")
+	ch.LogValue(INFO, "count", 42)
+	LogValue("TEST", DEBUG3, "hidden", true)
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "count = 42"},
+	}))
+}
+
+////
+// NewLogEntry - Test creating an entry without writing it
+//
+// 1) Configure a service name and indent, then create an entry
+//  -> Fields populated as in the real log path
+//  -> Nothing written
+// 2) Format the entry and log the same message
+//  -> Formatted line matches the logged line
+////
+func Test_Alog_NewLogEntry(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetServiceName("svc")
+	defer ResetDefaults()
+
+	mw := NewMemoryWriter()
+	SetWriter(mw)
+	Indent()
+	defer Deindent()
+
+	before := time.Now().UTC()
+	e := NewLogEntry("TEST", INFO, "Hello %s", "world")
+	assert.Equal(t, LogChannel("TEST"), e.Channel)
+	assert.Equal(t, INFO, e.Level)
+	assert.Equal(t, "Hello %s", e.Format)
+	assert.Equal(t, []interface{}{"world"}, e.Expansion)
+	assert.Equal(t, 1, e.NIndent)
+	assert.Equal(t, "svc", e.Servicename)
+	assert.Equal(t, time.UTC, e.Timestamp.Location())
+	assert.False(t, e.Timestamp.Before(before))
+	assert.Equal(t, 0, len(mw.Lines()))
+
+	// Same output as a real log call
+	SetClock(func() time.Time { return e.Timestamp })
+	Log("TEST", INFO, "Hello %s", "world")
+	assert.Equal(t, mw.Lines(), StdLogFormatter{}.FormatEntry(e))
+}
+
+////
+// WithComponent - Test adding a component to a ChannelLog
+//
+// 1) Create a ChannelLog and a component logger from it
+// 2) Log with the component logger, including a scope
+//  -> Component shown after the indentation on each line
+// 3) Log with the base logger
+//  -> No component shown
+////
+func Test_Alog_WithComponent(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	base := UseChannel("HTTP")
+	router := base.WithComponent("router")
+	router.Log(INFO, "Routing %s", "/foo")
+	func() {
+		defer router.LogScope(DEBUG, "handle").Close()
+		router.LogMap(INFO, map[string]interface{}{"a": 1})
+	}()
+	base.Log(INFO, "No component")
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "(router) Routing /foo"},
+		ExpEntry{channel: "HTTP ", level: "DBUG", body: "(router) Start: handle"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "(router) a: 1", nIndent: 1},
+		ExpEntry{channel: "HTTP ", level: "DBUG", body: "(router) End: handle"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "No component"},
+	}))
+}
+
+////
+// WithFields - Test persistent fields on a ChannelLog with the Std formatter
+//
+// 1) Create a ChannelLog with fields
+// 2) Log a message
+//  -> Message followed by one line per inherited field
+// 3) Log a map with a colliding key
+//  -> Call-site value wins, other inherited fields kept
+// 4) Log a value
+//  -> Value line followed by the inherited fields
+// 5) Log with the parent logger
+//  -> No fields shown
+////
+func Test_Alog_WithFields(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	base := UseChannel("HTTP")
+	req := base.WithFields(map[string]interface{}{
+		"request_id": "abc",
+		"user_id":    7,
+	})
+	req.Log(INFO, "Handling")
+	req.LogMap(INFO, map[string]interface{}{"user_id": 8})
+	req.LogValue(DEBUG, "n", 3)
+	base.Log(INFO, "No fields")
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "Handling"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "request_id: abc"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "user_id: 7"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "request_id: abc"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "user_id: 8"},
+		ExpEntry{channel: "HTTP ", level: "DBUG", body: "n = 3"},
+		ExpEntry{channel: "HTTP ", level: "DBUG", body: "request_id: abc"},
+		ExpEntry{channel: "HTTP ", level: "DBUG", body: "user_id: 7"},
+		ExpEntry{channel: "HTTP ", level: "INFO", body: "No fields"},
+	}))
+}
+
+// Formatter that only implements FormatEntryTo in a meaningful way
+type bufferOnlyFormatter struct{}
+
+func (f bufferOnlyFormatter) FormatEntry(e LogEntry) []string {
+	return []string{"unused\n"}
+}
+
+func (f bufferOnlyFormatter) FormatEntryTo(buf *bytes.Buffer, e LogEntry) {
+	buf.WriteString("first: " + e.Format + "\n")
+	buf.WriteString("second: " + e.Format + "\n")
+}
+
+////
+// BufferLogFormatter - Test that FormatEntryTo is preferred when implemented
+//
+// 1) Configure a formatter implementing BufferLogFormatter
+// 2) Log a line
+//  -> FormatEntryTo used and each line written separately
+// 3) Compare FormatEntry and FormatEntryTo for the built-in formatters
+//  -> Identical output
+////
+func Test_Alog_BufferLogFormatter(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Custom formatter
+	entries := []string{}
+	SetWriter(&TestWriter{entries: &entries})
+	SetFormatter(bufferOnlyFormatter{})
+	Log("TEST", INFO, "msg")
+	assert.Equal(t, []string{"first: msg\n", "second: msg\n"}, entries)
+
+	// Built-in formatters
+	e := LogEntry{
+		Channel:   "TEST",
+		Level:     INFO,
+		Format:    "Line %d\nLine %d",
+		Expansion: []interface{}{1, 2},
+		NIndent:   2,
+		MapData:   map[string]interface{}{"b": 2, "a": "x"},
+		Component: "comp",
+	}
+	for _, f := range []BufferLogFormatter{StdLogFormatter{}, JSONLogFormatter{}} {
+		buf := &bytes.Buffer{}
+		f.FormatEntryTo(buf, e)
+		lines := f.FormatEntry(e)
+		joined := ""
+		for _, l := range lines {
+			joined += l
+		}
+		assert.Equal(t, buf.String(), joined)
+	}
+	assert.Equal(t, 4, len(StdLogFormatter{}.FormatEntry(e)))
+}
+
+////
+// ChannelFormatter - Test per-channel formatter overrides
+//
+// 1) Set a JSON formatter for the AUDIT channel and log to it and to a channel
+//    using the global Std formatter, including map data
+//  -> AUDIT lines are JSON, other lines are Std
+// 2) Change the global formatter to JSON and the AUDIT formatter to Std
+//  -> Each channel still uses its own formatter
+// 3) Remove the override and reset defaults
+//  -> All channels use the global formatter again
+////
+func Test_Alog_ChannelFormatter(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// JSON audit, Std default
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	SetChannelFormatter("AUDIT", JSONLogFormatter{})
+	Log("AUDIT", INFO, "user login")
+	Log("TEST", INFO, "debug line")
+	LogMap("AUDIT", INFO, map[string]interface{}{"user": "bob"})
+	UseChannel("AUDIT").LogWithMap(INFO, map[string]interface{}{"ok": true}, "done")
+	if assert.Equal(t, 4, len(entries)) {
+		for _, i := range []int{0, 2, 3} {
+			le, err := JSONToLogEntry(entries[i])
+			if assert.Nil(t, err, "entry %d", i) {
+				assert.Equal(t, LogChannel("AUDIT"), le.Channel)
+			}
+		}
+		assert.True(t, VerifyLogs(entries[1:2], []ExpEntry{
+			{channel: "TEST ", level: "INFO", body: "debug line"},
+		}))
+	}
+
+	// Global JSON, Std audit
+	entries = []string{}
+	UseJSONLogFormatter()
+	SetChannelFormatter("AUDIT", StdLogFormatter{})
+	Log("AUDIT", INFO, "std audit")
+	Log("TEST", INFO, "json line")
+	if assert.Equal(t, 2, len(entries)) {
+		assert.True(t, VerifyLogs(entries[0:1], []ExpEntry{
+			{channel: "AUDIT", level: "INFO", body: "std audit"},
+		}))
+		assert.True(t, VerifyJSONLogs(entries[1:], []ExpEntry{
+			{channel: "TEST", level: "info", body: "json line"},
+		}))
+	}
+
+	// Remove the override
+	entries = []string{}
+	SetChannelFormatter("AUDIT", nil)
+	Log("AUDIT", INFO, "json audit")
+	SetChannelFormatter("AUDIT", JSONLogFormatter{})
+	ResetDefaults()
+	SetWriter(&TestWriter{entries: &entries})
+	ConfigDefaultLevel(INFO)
+	Log("AUDIT", INFO, "std audit")
+	if assert.Equal(t, 2, len(entries)) {
+		assert.True(t, VerifyJSONLogs(entries[0:1], []ExpEntry{
+			{channel: "AUDIT", level: "info", body: "json audit"},
+		}))
+		assert.True(t, VerifyLogs(entries[1:], []ExpEntry{
+			{channel: "AUDIT", level: "INFO", body: "std audit"},
+		}))
+	}
+}
+
+// Writer that requires a specific format
+type taggedWriter struct {
+	MemoryWriter
+	tag string
+}
+
+func (w *taggedWriter) FormatTag() string {
+	return w.tag
+}
+
+////
+// ValidateOutput - Test checking formatters against writers
+//
+// 1) Use an untagged writer with each formatter
+//  -> No error
+// 2) Pair a writer requiring "gelf" with the Std formatter
+//  -> Error naming both formats
+// 3) Pair a writer requiring "json" with the JSON formatter and an untagged
+//    custom formatter
+//  -> No error
+// 4) Add a Std channel formatter
+//  -> Error naming the channel
+// 5) Split the streams with a tagged error stream
+//  -> Error for the tagged stream
+////
+func Test_Alog_ValidateOutput(t *testing.T) {
+	defer ResetDefaults()
+
+	// Untagged
+	SetWriter(NewMemoryWriter())
+	assert.Nil(t, ValidateOutput())
+	UseJSONLogFormatter()
+	assert.Nil(t, ValidateOutput())
+
+	// Incompatible
+	SetWriter(&taggedWriter{tag: "gelf"})
+	UseStdLogFormatter()
+	if err := ValidateOutput(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "global formatter produces [std]")
+		assert.Contains(t, err.Error(), "requires [gelf]")
+	}
+
+	// Compatible
+	SetWriter(&taggedWriter{tag: "json"})
+	UseJSONLogFormatter()
+	assert.Nil(t, ValidateOutput())
+	SetFormatter(bufferOnlyFormatter{})
+	assert.Nil(t, ValidateOutput())
+
+	// Channel formatter
+	UseJSONLogFormatter()
+	SetChannelFormatter("AUDIT", StdLogFormatter{})
+	if err := ValidateOutput(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "formatter for channel [AUDIT] produces [std]")
+	}
+	SetChannelFormatter("AUDIT", nil)
+
+	// Stream split
+	std.enableStreamSplit(&taggedWriter{tag: "gelf"}, NewMemoryWriter())
+	if err := ValidateOutput(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "global formatter produces [json]")
+		assert.Contains(t, err.Error(), "requires [gelf]")
+	}
+}
+
+////
+// StdStreamSplit - Test routing severe entries to a separate stream
+//
+// 1) Enable the split with two MemoryWriters standing in for the streams and
+//    log at several levels
+//  -> ERROR and WARNING on the error stream, INFO and DEBUG on the output
+//     stream, both with the configured formatter
+// 2) Disable the split
+//  -> All entries on the configured writer
+// 3) Enable the split and reset defaults
+//  -> Split disabled
+////
+func Test_Alog_StdStreamSplit(t *testing.T) {
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+	errW := NewMemoryWriter()
+	outW := NewMemoryWriter()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+
+	// Split
+	std.enableStreamSplit(errW, outW)
+	Log("TEST", ERROR, "error")
+	Log("TEST", WARNING, "warning")
+	Log("TEST", INFO, "info")
+	Log("TEST", DEBUG, "debug")
+	levels := func(entries []LogEntry) []LogLevel {
+		out := []LogLevel{}
+		for _, e := range entries {
+			out = append(out, e.Level)
+		}
+		return out
+	}
+	assert.Equal(t, []LogLevel{ERROR, WARNING}, levels(errW.Entries()))
+	assert.Equal(t, []LogLevel{INFO, DEBUG}, levels(outW.Entries()))
+	assert.Equal(t, 0, len(w.Lines()))
+
+	// Disable
+	DisableStdStreamSplit()
+	Log("TEST", ERROR, "error")
+	Log("TEST", INFO, "info")
+	assert.Equal(t, []LogLevel{ERROR, INFO}, levels(w.Entries()))
+	assert.Equal(t, 2, len(errW.Lines()))
+	assert.Equal(t, 2, len(outW.Lines()))
+
+	// Reset
+	EnableStdStreamSplit()
+	assert.Equal(t, true, PrintConfigMap()["std_stream_split"])
+	ResetDefaults()
+	assert.Equal(t, false, PrintConfigMap()["std_stream_split"])
+}
+
+////
+// ExactChannel - Test channels that enable only their exact level
+//
+// 1) Configure one channel exactly at WARNING and another at WARNING as a
+//    threshold
+//  -> Exact channel enables WARNING only
+//  -> Threshold channel enables WARNING and more severe
+// 2) Check the printed config
+//  -> Exact channel shown with "="
+// 3) Reconfigure the exact channel with ConfigChannel
+//  -> Threshold semantics restored
+// 4) Make a channel exact and replace the config with Config
+//  -> No exact channels left
+////
+func Test_Alog_ExactChannel(t *testing.T) {
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Exact and threshold
+	ConfigChannelExact("EXCT", WARNING)
+	ConfigChannel("THRS", WARNING)
+	for _, ch := range []LogChannel{"EXCT", "THRS"} {
+		Log(ch, FATAL, "fatal")
+		Log(ch, ERROR, "error")
+		Log(ch, WARNING, "warning")
+		Log(ch, INFO, "info")
+	}
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "EXCT ", level: "WARN", body: "warning"},
+		ExpEntry{channel: "THRS ", level: "FATL", body: "fatal"},
+		ExpEntry{channel: "THRS ", level: "ERRR", body: "error"},
+		ExpEntry{channel: "THRS ", level: "WARN", body: "warning"},
+	}))
+
+	// Printed config
+	assert.Equal(t,
+		"Default Level: INFO\nChannel Map:\n  EXCT: =warning\n  THRS: warning",
+		PrintConfig())
+	assert.Equal(t, map[LogChannel]bool{"EXCT": true}, GetExactChannels())
+
+	// Back to threshold
+	ConfigChannel("EXCT", WARNING)
+	assert.True(t, IsEnabled("EXCT", ERROR))
+	assert.Equal(t, map[LogChannel]bool{}, GetExactChannels())
+
+	// Replaced by Config
+	ConfigChannelExact("EXCT", DEBUG)
+	Config(INFO, ChannelMap{"EXCT": DEBUG})
+	assert.True(t, IsEnabled("EXCT", INFO))
+	assert.Equal(t, map[LogChannel]bool{}, GetExactChannels())
+}
+
+////
+// ScopeTiming - Test logging the elapsed time of scopes
+//
+// 1) Open and close a scope without timing
+//  -> End line unchanged
+// 2) Enable timing and sleep inside a scope
+//  -> End line ends with a plausible duration in ms
+// 3) Repeat with JSON output
+//  -> duration_ms on the End entry only
+////
+func Test_Alog_ScopeTiming(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	// Off
+	LogScope("TEST", INFO, "untimed").Close()
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Start: untimed"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "End: untimed"},
+	}))
+
+	// On
+	entries = entries[:0]
+	EnableScopeTiming()
+	func() {
+		defer LogScope("TEST", INFO, "timed %d", 1).Close()
+		time.Sleep(20 * time.Millisecond)
+	}()
+	if assert.Len(t, entries, 2) {
+		m := regexp.MustCompile(`End: timed 1 \(([0-9]+\.[0-9]{2})ms\)\n$`).FindStringSubmatch(entries[1])
+		if assert.Len(t, m, 2, entries[1]) {
+			var ms float64
+			fmt.Sscanf(m[1], "%f", &ms)
+			assert.True(t, ms >= 20 && ms < 5000, "duration %v", ms)
+		}
+	}
+
+	// JSON
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	func() {
+		defer LogScope("TEST", INFO, "json").Close()
+		time.Sleep(5 * time.Millisecond)
+	}()
+	logged := w.Entries()
+	if assert.Len(t, logged, 2) {
+		assert.NotContains(t, logged[0].MapData, "duration_ms")
+		if assert.Contains(t, logged[1].MapData, "duration_ms") {
+			ms, err := logged[1].MapData["duration_ms"].(json.Number).Float64()
+			assert.Nil(t, err)
+			assert.True(t, ms >= 5, "duration %v", ms)
+		}
+	}
+}
+
+////
+// GetFormatter - Test reading back the configured formatter
+//
+// 1) Check the default
+//  -> StdLogFormatter, not JSON
+// 2) Switch to JSON, then to the console formatter, then back to std
+//  -> Getter and IsJSONOutput follow each change
+////
+func Test_Alog_GetFormatter(t *testing.T) {
+	defer ResetDefaults()
+	assert.Equal(t, StdLogFormatter{}, GetFormatter())
+	assert.False(t, IsJSONOutput())
+
+	UseJSONLogFormatter()
+	assert.Equal(t, JSONLogFormatter{}, GetFormatter())
+	assert.True(t, IsJSONOutput())
+
+	UseConsoleFormatter()
+	assert.IsType(t, ConsoleFormatter{}, GetFormatter())
+	assert.False(t, IsJSONOutput())
+
+	UseStdLogFormatter()
+	assert.Equal(t, StdLogFormatter{}, GetFormatter())
+	assert.False(t, IsJSONOutput())
+}
+
+////
+// MergeConfig - Test overlaying channel levels onto the current config
+//
+// 1) Configure a default level and three channels, one of them exact
+// 2) Merge a change to two channels and a new channel
+//  -> Merged channels updated, exact channel back to a threshold
+//  -> Unlisted channel and default level unchanged
+////
+func Test_Alog_MergeConfig(t *testing.T) {
+	defer ResetDefaults()
+	Config(WARNING, ChannelMap{"ONE": INFO, "TWO": DEBUG, "KEEP": ERROR})
+	ConfigChannelExact("TWO", DEBUG)
+
+	MergeConfig(ChannelMap{"ONE": DEBUG3, "TWO": INFO, "NEW": TRACE})
+	assert.Equal(t, WARNING, GetDefaultLevel())
+	assert.True(t, ValidateChannelMap(GetChannelMap(), ChannelMap{
+		"ONE":  DEBUG3,
+		"TWO":  INFO,
+		"KEEP": ERROR,
+		"NEW":  TRACE,
+	}))
+	assert.Equal(t, map[LogChannel]bool{}, GetExactChannels())
+}
+
+////
+// MuteAllowOnly - Test muting channels and the channel allowlist
+//
+// 1) Configure a channel at DEBUG4, mute it and log at FATAL and DEBUG4
+//  -> Nothing logged on the muted channel
+//  -> Other channels log as configured
+// 2) Unmute the channel
+//  -> Channel logs at its configured level again
+// 3) Allow only one channel and log INFO on it and on another channel
+//  -> Only the allowed channel is logged
+//  -> Allowed channel still follows its level
+// 4) Turn the allowlist off
+//  -> All channels log again
+// 5) Mute, allow and reset
+//  -> Both are cleared
+////
+func Test_Alog_MuteAllowOnly(t *testing.T) {
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Mute overrides the level
+	ConfigChannel("NOISY", DEBUG4)
+	MuteChannel("NOISY")
+	assert.False(t, IsEnabled("NOISY", FATAL))
+	Log("NOISY", FATAL, "fatal")
+	Log("NOISY", DEBUG4, "debug4")
+	Log("OTHER", INFO, "other")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "OTHER", level: "INFO", body: "other"},
+	}))
+
+	// Unmute
+	entries = entries[:0]
+	UnmuteChannel("NOISY")
+	Log("NOISY", DEBUG4, "debug4")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "NOISY", level: "DBG4", body: "debug4"},
+	}))
+
+	// Allowlist
+	entries = entries[:0]
+	AllowOnlyChannels("FOCUS")
+	Log("FOCUS", INFO, "focus")
+	Log("FOCUS", DEBUG, "focus debug")
+	Log("OTHER", INFO, "other")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "FOCUS", level: "INFO", body: "focus"},
+	}))
+
+	// Allowlist off
+	entries = entries[:0]
+	AllowOnlyChannels()
+	Log("OTHER", INFO, "other")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "OTHER", level: "INFO", body: "other"},
+	}))
+
+	// Reset clears both
+	MuteChannel("OTHER")
+	AllowOnlyChannels("FOCUS")
+	ResetDefaults()
+	ConfigDefaultLevel(INFO)
+	assert.True(t, IsEnabled("OTHER", INFO))
+}
+
+////
+// DualOutput - Test writing each entry as std and JSON at once
+//
+// 1) Enable dual output with two MemoryWriters and log a line, a map and a
+//    multi-line message
+//  -> Std lines in the std writer
+//  -> One JSON line per entry in the JSON writer
+//  -> Nothing in the configured writer
+// 2) Disable dual output
+//  -> Entries on the configured writer only
+////
+func Test_Alog_DualOutput(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	stdW := NewMemoryWriter()
+	jsonW := NewMemoryWriter()
+
+	// Dual
+	EnableDualOutput(stdW, jsonW)
+	assert.Equal(t, true, PrintConfigMap()["dual_output"])
+	Log("TEST", INFO, "Hello")
+	LogMap("TEST", WARNING, map[string]interface{}{"key": "val"})
+	Log("TEST", DEBUG, "Not enabled")
+	Log("TEST", INFO, "Line one\nLine two")
+	assert.True(t, VerifyLogs(stdW.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Hello"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "key: val"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line one"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line two"},
+	}))
+	assert.True(t, VerifyJSONLogs(jsonW.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST", level: "info", body: "Hello"},
+		ExpEntry{channel: "TEST", level: "warning", mapData: map[string]interface{}{"key": "val"}},
+		ExpEntry{channel: "TEST", level: "info", body: "Line one\nLine two"},
+	}))
+	assert.Equal(t, 0, len(w.Lines()))
+
+	// Disable
+	DisableDualOutput()
+	assert.Equal(t, false, PrintConfigMap()["dual_output"])
+	Log("TEST", INFO, "Single")
+	assert.Equal(t, 1, len(w.Lines()))
+	assert.Equal(t, 4, len(stdW.Lines()))
+	assert.Equal(t, 3, len(jsonW.Lines()))
+}
+
+func cappedRecursion(n int) {
+	defer FnLog("TEST", "%d", n).Close()
+	Log("TEST", INFO, "depth %d", n)
+	if n > 0 {
+		cappedRecursion(n - 1)
+	}
+}
+
+////
+// MaxIndentDepth - Test suppressing entries beyond a maximum depth
+//
+// 1) Cap the depth at 2 and call a recursive function using FnLog five deep
+//  -> Start/End lines and log lines up to depth 2 logged, nothing deeper
+//  -> Indentation fully unwound afterwards
+// 2) Remove the cap
+//  -> All levels logged
+////
+func Test_Alog_MaxIndentDepth(t *testing.T) {
+	ConfigDefaultLevel(TRACE)
+	MaxIndentDepth(2)
+	defer ResetDefaults()
+	assert.Equal(t, 2, GetMaxIndentDepth())
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	// Capped
+	cappedRecursion(4)
+	Log("TEST", INFO, "after")
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: cappedRecursion(4)", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "depth 4", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: cappedRecursion(3)", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "depth 3", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "Start: cappedRecursion(2)", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: cappedRecursion(2)", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: cappedRecursion(3)", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "End: cappedRecursion(4)", nIndent: 0},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "after", nIndent: 0},
+	}))
+
+	// Uncapped
+	entries = []string{}
+	MaxIndentDepth(0)
+	cappedRecursion(3)
+	assert.Equal(t, 12, len(entries))
+	Log("TEST", INFO, "after")
+	assert.True(t, VerifyLogs(entries[12:], []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "after", nIndent: 0},
+	}))
+}
+
+////
+// LevelOffset - Test shifting the level of every channel
+//
+// 1) Configure INFO with a DEBUG channel and an OFF channel, then log
+//  -> DEBUG lines hidden on the default channel, OFF channel silent
+// 2) Set an offset of +1 and log again
+//  -> TRACE lines shown on the default channel, DEBUG1 on the DEBUG channel,
+//     OFF channel still silent
+// 3) Set an offset of -10 and log again
+//  -> Only FATAL enabled on the non-OFF channels
+// 4) Set an offset of +20
+//  -> Clamped to DEBUG4
+////
+func Test_Alog_LevelOffset(t *testing.T) {
+	Config(INFO, ChannelMap{"DBG": DEBUG, "MUTE": OFF})
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	logAll := func() {
+		Log("TEST", TRACE, "trace")
+		Log("TEST", INFO, "info")
+		Log("DBG", DEBUG1, "debug1")
+		Log("MUTE", ERROR, "muted")
+	}
+
+	// No offset
+	logAll()
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "info"},
+	}))
+
+	// Offset +1
+	entries = []string{}
+	SetLevelOffset(1)
+	assert.Equal(t, 1, GetLevelOffset())
+	logAll()
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "TRCE", body: "trace"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "info"},
+		ExpEntry{channel: "DBG  ", level: "DBG1", body: "debug1"},
+	}))
+
+	// Offset -10
+	SetLevelOffset(-10)
+	assert.False(t, IsEnabled("TEST", ERROR))
+	assert.True(t, IsEnabled("TEST", FATAL))
+	assert.True(t, IsEnabled("DBG", FATAL))
+	assert.False(t, IsEnabled("MUTE", FATAL))
+
+	// Offset +20
+	SetLevelOffset(20)
+	assert.True(t, IsEnabled("TEST", DEBUG4))
+	assert.False(t, IsEnabled("MUTE", FATAL))
+}
+
+////
+// MaxIndent - Test capping the rendered indentation
+//
+// 1) Indent past the configured maximum and log
+//  -> Rendered indentation capped, indent count preserved
+// 2) Deindent back under the maximum and log
+//  -> Actual indentation rendered
+// 3) Remove the maximum and log deep again
+//  -> Full indentation rendered
+////
+func Test_Alog_MaxIndent(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetMaxIndent(3)
+	defer ResetDefaults()
+	assert.Equal(t, 3, GetMaxIndent())
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	// Indent past the max
+	for i := 0; i < 5; i++ {
+		Indent()
+	}
+	Log("TEST", INFO, "Capped")
+
+	// Back under the max
+	for i := 0; i < 3; i++ {
+		Deindent()
+	}
+	Log("TEST", INFO, "Under")
+
+	// No max
+	SetMaxIndent(0)
+	for i := 0; i < 3; i++ {
+		Indent()
+	}
+	Log("TEST", INFO, "Uncapped")
+	for i := 0; i < 5; i++ {
+		Deindent()
+	}
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Capped", nIndent: 3},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Under", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Uncapped", nIndent: 5},
+	}))
+}
+
+// Writer that always fails
+type failingWriter struct{}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+////
+// WriteErrorHandler - Test handling errors returned by the writer
+//
+// 1) Log with a failing writer and no handler
+//  -> Nothing happens
+// 2) Set a handler that falls back to another writer and logs from there
+//  -> Handler called once per entry with the error and entry, no deadlock
+//  -> Fallback line logged
+// 3) Log with a successful writer
+//  -> Handler not called
+////
+func Test_Alog_WriteErrorHandler(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// No handler
+	SetWriter(failingWriter{})
+	Log("TEST", INFO, "Lost")
+
+	// Fallback handler
+	entries := []string{}
+	var gotErrs []error
+	var gotEntries []LogEntry
+	SetWriteErrorHandler(func(err error, e LogEntry) {
+		gotErrs = append(gotErrs, err)
+		gotEntries = append(gotEntries, e)
+		SetWriter(&TestWriter{entries: &entries})
+		Log("FALL", WARNING, "Write failed: %v", err)
+	})
+	LogMap("TEST", INFO, map[string]interface{}{"a": 1, "b": 2})
+	if assert.Equal(t, 1, len(gotErrs)) {
+		assert.EqualError(t, gotErrs[0], "disk full")
+		assert.Equal(t, LogChannel("TEST"), gotEntries[0].Channel)
+		assert.Equal(t, 2, len(gotEntries[0].MapData))
+	}
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "FALL ", level: "WARN", body: "Write failed: disk full"},
+	}))
+
+	// Successful writes
+	Log("TEST", INFO, "Fine")
+	assert.Equal(t, 1, len(gotErrs))
+	assert.Equal(t, 2, len(entries))
+}
+
+////
+// ExpandSlices - Test rendering long slices one element per line
+//
+// 1) Log a map with a short and a long slice with no threshold set
+//  -> Both slices on a single line
+// 2) Set a threshold and log the same map inside a scope
+//  -> Long slice rendered as an indented block, short slice unchanged
+// 3) Log the same map with the JSON formatter
+//  -> Slice kept as an array
+////
+func Test_Alog_ExpandSlices(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	m := map[string]interface{}{
+		"long":  []string{"a", "b", "c"},
+		"short": []int{1, 2},
+	}
+
+	// No threshold
+	LogMap("TEST", INFO, m)
+
+	// With threshold
+	ExpandSlices(2)
+	func() {
+		defer LogScope("TEST", INFO, "scope").Close()
+		LogMap("TEST", INFO, m)
+	}()
+
+	// Check the result
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "long: [a b c]"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "short: [1 2]"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Start: scope"},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "long:", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "a", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "b", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "c", nIndent: 2},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "short: [1 2]", nIndent: 1},
+		ExpEntry{channel: "TEST ", level: "INFO", body: "End: scope"},
+	}))
+
+	// JSON unchanged
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	LogMap("TEST", INFO, m)
+	if got := w.Entries(); assert.Equal(t, 1, len(got)) {
+		assert.Equal(t, []interface{}{"a", "b", "c"}, got[0].MapData["long"])
+	}
+}
+
+////
+// FatalExitCode - Test the exit code used by Fatalf
+//
+// 1) Replace the exit function and call Fatalf
+//  -> Line logged then exit called with the default code of 1
+// 2) Set a fatal exit code and call Fatalf on a channel
+//  -> Exit called with the configured code
+// 3) Call FatalfWithCode
+//  -> Exit called with the given code
+////
+func Test_Alog_FatalExitCode(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	// Fake exit
+	codes := []int{}
+	nLinesAtExit := []int{}
+	prevExit := exitFunc
+	exitFunc = func(code int) {
+		codes = append(codes, code)
+		nLinesAtExit = append(nLinesAtExit, len(entries))
+	}
+	defer func() { exitFunc = prevExit }()
+
+	// Default code
+	assert.Equal(t, 1, GetFatalExitCode())
+	Fatalf("TEST", FATAL, "Default")
+
+	// Configured code
+	SetFatalExitCode(3)
+	UseChannel("TEST").Fatalf(FATAL, "Configured")
+
+	// Per-call code
+	FatalfWithCode(42, "TEST", FATAL, "Per call")
+
+	assert.Equal(t, []int{1, 3, 42}, codes)
+	assert.Equal(t, []int{1, 2, 3}, nLinesAtExit)
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "FATL", body: "Default"},
+		ExpEntry{channel: "TEST ", level: "FATL", body: "Configured"},
+		ExpEntry{channel: "TEST ", level: "FATL", body: "Per call"},
+	}))
+}
+
+
+////
+// SetExitFunc - Test replacing the function Fatalf exits with
+//
+// 1) Set an exit function and call Fatalf with a buffered writer
+//  -> Exit called once with 1 after the line is written and flushed
+// 2) Restore the default with nil
+//  -> Exit function is os.Exit again
+////
+func Test_Alog_SetExitFunc(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetAll()
+	buf := &bytes.Buffer{}
+	bw := bufio.NewWriter(buf)
+	SetWriter(bw)
+
+	codes := []int{}
+	written := []string{}
+	SetExitFunc(func(code int) {
+		codes = append(codes, code)
+		written = append(written, buf.String())
+	})
+	Fatalf("TEST", FATAL, "Going down")
+	assert.Equal(t, []int{1}, codes)
+	if assert.Len(t, written, 1) {
+		assert.Contains(t, written[0], "Going down")
+	}
+
+	// Restore
+	SetExitFunc(nil)
+	assert.Equal(t, reflect.ValueOf(os.Exit).Pointer(), reflect.ValueOf(exitFunc).Pointer())
+}
+
+////
+// PanicError - Test the value Panicf panics with
+//
+// 1) Panicf at an enabled level
+//  -> Recovered *PanicError with channel, level, message and formatted lines
+// 2) Panicf at a disabled level
+//  -> Recovered *PanicError with channel, level and message
+//  -> No formatted lines and nothing written
+////
+func Test_Alog_PanicError(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+
+	recovered := func(fn func()) (r interface{}) {
+		defer func() { r = recover() }()
+		fn()
+		return nil
+	}
+
+	// Enabled
+	r := recovered(func() { Panicf("TEST", ERROR, "Bad value %d", 42) })
+	pe, ok := r.(*PanicError)
+	if assert.True(t, ok) {
+		assert.Equal(t, LogChannel("TEST"), pe.Channel)
+		assert.Equal(t, ERROR, pe.Level)
+		assert.Equal(t, "Bad value 42", pe.Message)
+		assert.Contains(t, pe.Formatted, "[TEST :ERRR] Bad value 42")
+		assert.Equal(t, pe.Formatted, pe.Error())
+	}
+
+	// Disabled
+	r = recovered(func() { UseChannel("TEST").Panicf(DEBUG, "Quiet %s", "panic") })
+	pe, ok = r.(*PanicError)
+	if assert.True(t, ok) {
+		assert.Equal(t, LogChannel("TEST"), pe.Channel)
+		assert.Equal(t, DEBUG, pe.Level)
+		assert.Equal(t, "Quiet panic", pe.Message)
+		assert.Equal(t, "", pe.Formatted)
+		assert.Equal(t, "Quiet panic", pe.Error())
+	}
+	assert.Empty(t, w.Lines())
+}
+////
+// MapValueRenderer - Test rendering of nested map data values
+//
+// 1) Log a map with nested maps, slices and bytes several times
+//  -> Nested keys sorted and bytes rendered as strings, identical each time
+// 2) Set a custom renderer that JSON encodes values
+//  -> Custom rendering used
+////
+func Test_Alog_MapValueRenderer(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Set up the writer to capture logged lines
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+
+	m := map[string]interface{}{
+		"nested": map[string]interface{}{
+			"zeta":  1,
+			"alpha": map[string]int{"y": 2, "x": 1},
+			"mid":   []interface{}{[]byte("raw"), map[string]string{"b": "2", "a": "1"}},
+		},
+		"bytes": []byte("hello"),
+	}
+
+	// Default rendering
+	for i := 0; i < 10; i++ {
+		LogMap("TEST", INFO, m)
+	}
+	for i := 0; i < 10; i++ {
+		assert.True(t, VerifyLogs(entries[2*i:2*i+2], []ExpEntry{
+			ExpEntry{channel: "TEST ", level: "INFO", body: "bytes: hello"},
+			ExpEntry{channel: "TEST ", level: "INFO", body: "nested: map[alpha:map[x:1 y:2] mid:[raw map[a:1 b:2]] zeta:1]"},
+		}))
+	}
+
+	// Custom rendering
+	entries = entries[:0]
+	SetMapValueRenderer(func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	})
+	LogMap("TEST", INFO, map[string]interface{}{"obj": map[string]int{"b": 2, "a": 1}})
+	assert.True(t, VerifyLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: `obj: {"a":1,"b":2}`},
+	}))
+}
+
+////
+// PrintConfigMap - Test that the full configuration is reported
+//
+// 1) Change each setting from its default
+// 2) Get the config map and JSON
+//  -> Each setting present with the configured value
+////
+func Test_Alog_PrintConfigMap(t *testing.T) {
+	Config(DEBUG, ChannelMap{"TEST": DEBUG2})
+	defer ResetDefaults()
+	SetServiceName("svc")
+	SetLabels(map[string]string{"tenant": "acme"})
+	SetMaxChannelLen(7)
+	DisableIndent()
+	SetMaxIndent(4)
+	MaxIndentDepth(5)
+	EnableGID()
+	EnableFullFuncSig()
+	EnableScopeCorrelation()
+	ExpandSlices(3)
+	SetLevelOffset(-1)
+	EnableStdStreamSplit()
+	EnableErrorContext(8)
+	SetFatalExitCode(2)
+	SetChannelGroups(map[LogChannel]string{"TEST": "tests"})
+	UseJSONLogFormatter()
+
+	assert.Equal(t, map[string]interface{}{
+		"default_level":      "debug",
+		"channel_map":        map[string]interface{}{"TEST": "debug2"},
+		"channel_groups":     map[string]interface{}{"TEST": "tests"},
+		"service_name":       "svc",
+		"labels":             map[string]interface{}{"tenant": "acme"},
+		"channel_header_len": 7,
+		"indent_string":      "  ",
+		"enable_indent":      false,
+		"max_indent":         4,
+		"max_indent_depth":   5,
+		"enable_gid":         true,
+		"full_func_sig":      true,
+		"scope_correlation":  true,
+		"scope_timing":       false,
+		"error_chain":        false,
+		"health_escalation":  false,
+		"sequence":           false,
+		"std_stream_split":   true,
+		"dual_output":        false,
+		"delta_timestamps":   false,
+		"time_location":      "UTC",
+		"error_context":      8,
+		"expand_slices":      3,
+		"level_offset":       -1,
+		"fatal_exit_code":    2,
+		"formatter":          "json",
+	}, PrintConfigMap())
+
+	// JSON
+	cfg := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(PrintConfigJSON()), &cfg))
+	assert.Equal(t, len(PrintConfigMap()), len(cfg))
+	assert.Equal(t, "svc", cfg["service_name"])
+
+	// Std and custom formatters
+	UseStdLogFormatter()
+	assert.Equal(t, "std", PrintConfigMap()["formatter"])
+	SetFormatter(bufferOnlyFormatter{})
+	assert.Equal(t, "alog.bufferOnlyFormatter", PrintConfigMap()["formatter"])
+}
+
+////
+// ResetAll - Test that all package state is cleared
+//
+// 1) Change the configuration, start a temporary dynamic configuration, set
+//    handlers/renderers and log to accumulate stats
+// 2) Call ResetAll
+//  -> Configuration back to defaults, no temporary configuration active,
+//     stats cleared, handlers removed
+// 3) Wait past the dynamic configuration timeout
+//  -> Configuration not touched by the discarded override
+////
+func Test_Alog_ResetAll(t *testing.T) {
+	defer ResetAll()
+
+	// Register things
+	Config(INFO, ChannelMap{})
+	assert.Nil(t, ConfigureDynamicLogging(DynamicLogConfig{
+		DefaultLevel: "debug",
+		Timeout:      1,
+	}))
+	SetOutputTransform(func(b []byte) []byte { return b })
+	SetWriteErrorHandler(func(error, LogEntry) {})
+	SetMapValueRenderer(func(interface{}) string { return "" })
+	RegisterMessageRedactor(regexp.MustCompile("x"), "y")
+	Log("TEST", INFO, "Counted")
+	Log("TEST", DEBUG4, "Suppressed")
+	assert.NotEqual(t, 0, len(GetStats().Emitted))
+
+	// Reset
+	ResetAll()
+	assert.Equal(t, OFF, GetDefaultLevel())
+	assert.Equal(t, 0, len(GetChannelMap()))
+	stats := GetStats()
+	assert.Equal(t, 0, len(stats.Emitted))
+	assert.Equal(t, 0, len(stats.Suppressed))
+	assert.NotNil(t, CancelDynamicLogging())
+	assert.Nil(t, std.outputTransform)
+	assert.Nil(t, std.writeErrorHandler)
+	assert.Nil(t, std.mapValueRenderer)
+	assert.Equal(t, "x", redactMessage("x"))
+
+	// Make sure the discarded override does not fire
+	ConfigDefaultLevel(WARNING)
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, WARNING, GetDefaultLevel())
+}
+
+////
+// PrintConfig - Test the string representation of the configuration
+//
+// 1) Print with an empty channel map
+//  -> Header lines only, no trailing newline
+// 2) Print with several channels
+//  -> One line per channel in sorted order, no trailing newline
+////
+func Test_Alog_PrintConfig(t *testing.T) {
+	defer ResetDefaults()
+
+	// Empty map
+	Config(INFO, ChannelMap{})
+	assert.Equal(t, "Default Level: INFO\nChannel Map:", PrintConfig())
+
+	// Several channels
+	Config(WARNING, ChannelMap{"ZED": DEBUG, "ABC": INFO, "MID": DEBUG3})
+	assert.Equal(t,
+		"Default Level: WARN\nChannel Map:\n  ABC: info\n  MID: debug3\n  ZED: debug",
+		PrintConfig())
+}
+
+////
+// Make sure that the std header only looks up the goroutine id when the GID is
+// displayed since the lookup parses the runtime stack
+//
+// 1) Count the allocations for a header with GID disabled and enabled
+//  -> The header with GID disabled allocates less
+////
+func Test_Alog_HeaderSkipsGID(t *testing.T) {
+	defer ResetDefaults()
+	e := LogEntry{Channel: "TEST", Level: INFO, Timestamp: time.Now()}
+	buf := &bytes.Buffer{}
+	header := func() {
+		buf.Reset()
+		StdLogFormatter{}.makeHeader(buf, e)
+	}
+	without := testing.AllocsPerRun(100, header)
+	EnableGID()
+	with := testing.AllocsPerRun(100, header)
+	assert.Less(t, without, with)
+}
+
+// JSON Tests //////////////////////////////////////////////////////////////////
+
+////
+// JSON Basic Channel - Verify basic functionality of JSON formatter using
+//  LogChannel
+//
+// 1) Configure to use JSON formatter
+// 2) Log a formatted line that should print
+//  -> Log line parses as JSON and is correctly formatted
+// 3) Log on a disabled level
+//  -> Line not logged
+////
+func Test_Alog_JSONBasicChannel(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigJSONLogWriter(&entries)
+	ConfigDefaultLevel(DEBUG)
+	ch := UseChannel("MAIN")
+
+	// Log a simple line on info
+	ch.Log(INFO, "[%d] This is a formatted test", 10)
+
+	// Log a line on debug3 that shouldn't show up
+	ch.Log(DEBUG3, "This shouldn't show up")
+
+	// Check the result
+	assert.True(t, len(entries) == 1)
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "MAIN", level: "info", body: "[10] This is a formatted test"},
+	}))
+
+	// Reset for next test
+	ResetDefaults()
+}
+
+////
+// JSON Numeric Level - Verify the numeric level alongside level_str
+//
+// 1) Log on every level with the JSON formatter
+//  -> level matches the LogLevel enum and level_str matches the name
+//  -> JSONToLogEntry parses back the same level
+// 2) Parse lines where level and level_str disagree or one is missing
+//  -> level is preferred, level_str is the fallback
+// 3) Parse a legacy line whose "level" is not a number
+//  -> level kept in map data, level_str used for the level
+// 4) Parse a line with an out of range level, or a non-numeric level and no
+//    level_str
+//  -> Error
+////
+func Test_Alog_JSONNumericLevel(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG4)
+	defer ResetDefaults()
+
+	// Round trip
+	for lvl := FATAL; lvl <= DEBUG4; lvl++ {
+		w.Reset()
+		Log("TEST", lvl, "msg")
+		lines := w.Lines()
+		if !assert.Len(t, lines, 1) {
+			continue
+		}
+		raw := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &raw))
+		assert.Equal(t, float64(lvl), raw["level"])
+		assert.Equal(t, LevelToHumanString(lvl), raw["level_str"])
+		le, err := JSONToLogEntry(lines[0])
+		if assert.Nil(t, err) {
+			assert.Equal(t, lvl, le.Level)
+			assert.Nil(t, le.MapData)
+		}
+	}
+	assert.Equal(t, 4, int(INFO))
+	assert.Equal(t, 10, int(DEBUG4))
+
+	// Preference and fallback
+	base := `"channel":"TEST","timestamp":"2021/01/02 03:04:05","num_indent":0,"message":"m"`
+	le, err := JSONToLogEntry(`{` + base + `,"level":7,"level_str":"info"}`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, DEBUG1, le.Level)
+	}
+	le, err = JSONToLogEntry(`{` + base + `,"level":2}`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, ERROR, le.Level)
+	}
+	le, err = JSONToLogEntry(`{` + base + `,"level_str":"debug3"}`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, DEBUG3, le.Level)
+	}
+	_, err = JSONToLogEntry(`{` + base + `}`)
+	assert.NotNil(t, err)
+
+	// Legacy level key
+	le, err = JSONToLogEntry(`{` + base + `,"level":"high","level_str":"warning"}`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, WARNING, le.Level)
+		assert.Equal(t, map[string]interface{}{"level": "high"}, le.MapData)
+	}
+
+	// Out of range
+	_, err = JSONToLogEntry(`{` + base + `,"level":11,"level_str":"info"}`)
+	assert.NotNil(t, err)
+	_, err = JSONToLogEntry(`{` + base + `,"level":"info"}`)
+	assert.NotNil(t, err)
+}
+
+////
+// JSON Happy Path - Repeat the HappyPath test from the Std formatter with JSON
+//
+// 1) Log with no configuration
+//  -> No logs created
+// 2) Log to a channel without a specific level filter
+//  -> Use the default level
+// 3) LUog to a channel with a specific level filter
+//  -> se that filter
+// 4) Log with formatting operators
+//  -> Ensure formatting is properly printed
+// 5) Log to a channel with a long name
+//  -> Ensure channel name gets truncated correctly
+////
+func Test_Alog_JSONHappyPath(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigJSONLogWriter(&entries)
+
+	// Log with no configuration
+	Log("TEST", INFO, "Test1")
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{}))
+	entries = []string{}
+
+	// Set default level and try again
+	ConfigDefaultLevel(INFO)
+	Log("TEST", INFO, "Test2")
+	Log("TEST", DEBUG, "Debug test!")
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST", level: "info", body: "Test2"},
+	}))
+	entries = []string{}
+
+	// Set TEST channel back to OFF
+	ConfigChannel("TEST", OFF)
+	Log("TEST", INFO, "Can't see me...")
+	Log("FOO", INFO, "Use the default!")
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "FOO", level: "info", body: "Use the default!"},
+	}))
+	entries = []string{}
+
+	// Log with some formatting
+	for i := 1; i < 3; i++ {
+		Log("FOO", INFO, "This is formatting test [%d]", i)
+	}
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "FOO", level: "info", body: "This is formatting test [1]"},
+		ExpEntry{channel: "FOO", level: "info", body: "This is formatting test [2]"},
+	}))
+	entries = []string{}
+
+	// Test a really long channel name (don't truncate for JSON)
+	Log("LONGCHANNEL", INFO, "Line me up nice...")
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "LONGCHANNEL", level: "info", body: "Line me up nice..."},
+	}))
+	entries = []string{}
+
+	// Reset for next test
+	ResetDefaults()
+}
+
+////
+// JSON Labels - Test static labels with JSON output
+//
+// 1) Configure labels and log lines with and without map data
+//  -> Labels logged as a separate field on every line
+//  -> Map data unaffected
+////
+func Test_Alog_JSONLabels(t *testing.T) {
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	SetLabels(map[string]string{"tenant": "acme", "region": "eu"})
+
+	Log("TEST", INFO, "Hi there")
+	LogMap("TEST", INFO, map[string]interface{}{"key": "val"})
+
+	// Check the result
+	entries := w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		for _, e := range entries {
+			assert.Equal(t, map[string]string{"tenant": "acme", "region": "eu"}, e.Labels)
+		}
+		assert.Equal(t, 0, len(entries[0].MapData))
+		assert.Equal(t, map[string]interface{}{"key": "val"}, entries[1].MapData)
+	}
+}
+
+////
+// JSON Service Name - Test ServiceName functionality with JSON output
+//
+// 1) Configure a service name
+// 2) Log an enabled message
+//  -> Logged with service name
+// 3) Log a disabled message
+//  -> Not logged
+////
+func Test_Alog_JSONServiceName(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigJSONLogWriter(&entries)
+	sn := "test_service"
+	SetServiceName(sn)
+	ConfigDefaultLevel(DEBUG)
+	ch := UseChannel("MAIN")
+
+	// Log a simple line on info
+	ch.Log(INFO, "[%d] This is a formatted test", 10)
+
+	// Log a line on debug3 that shouldn't show up
+	ch.Log(DEBUG3, "This shouldn't show up")
 
 	// Check the result
 	assert.True(t, len(entries) == 1)
@@ -867,6 +2846,298 @@ func Test_Alog_JSONGID(t *testing.T) {
 	ResetDefaults()
 }
 
+////
+// JSON LogValue - Verify that a LogValue entry has a single structured field
+//
+// 1) Log a named value with LogValue
+//  -> Map data contains only the named value
+////
+func Test_Alog_JSONLogValue(t *testing.T) {
+
+	// Configure
+	entries := []string{}
+	ConfigJSONLogWriter(&entries)
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	UseChannel("TEST").LogValue(INFO, "count", 42)
+
+	// Check the result
+	assert.True(t, VerifyJSONLogs(entries, []ExpEntry{
+		ExpEntry{channel: "TEST", level: "info", body: "count = 42", mapData: map[string]interface{}{"count": 42}},
+	}))
+}
+
+////
+// JSON WithComponent - Verify the component field with JSON output
+//
+// 1) Log with a component logger
+//  -> component field present
+// 2) Log with the base logger
+//  -> component field absent
+////
+func Test_Alog_JSONWithComponent(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	base := UseChannel("HTTP")
+	base.WithComponent("router").Log(INFO, "With component")
+	base.Log(INFO, "Without component")
+
+	// Check the result
+	entries := w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, "router", entries[0].Component)
+		assert.Equal(t, "", entries[1].Component)
+	}
+	lines := w.Lines()
+	assert.Contains(t, lines[0], `"component":"router"`)
+	assert.NotContains(t, lines[1], `"component"`)
+}
+
+////
+// JSONWithFields - Test merge precedence of persistent fields
+//
+// 1) Create a ChannelLog with fields, then a child with more fields and a
+//    component
+//  -> Child fields override parent fields, component kept
+// 2) Log with a colliding call-site key
+//  -> Call-site value wins
+// 3) Log with a scope
+//  -> Start and End entries carry the fields
+////
+func Test_Alog_JSONWithFields(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG2)
+	defer ResetDefaults()
+
+	parent := UseChannel("HTTP").WithFields(map[string]interface{}{"a": "parent", "b": "parent"})
+	child := parent.WithComponent("router").WithFields(map[string]interface{}{"b": "child"})
+	child.Log(INFO, "Child")
+	child.LogWithMap(INFO, map[string]interface{}{"a": "call"}, "Call site")
+	child.LogScope(DEBUG, "scope").Close()
+	parent.Log(INFO, "Parent")
+
+	// Check the result
+	entries := w.Entries()
+	if assert.Equal(t, 5, len(entries)) {
+		assert.Equal(t, map[string]interface{}{"a": "parent", "b": "child"}, entries[0].MapData)
+		assert.Equal(t, "router", entries[0].Component)
+		assert.Equal(t, map[string]interface{}{"a": "call", "b": "child"}, entries[1].MapData)
+		assert.Equal(t, map[string]interface{}{"a": "parent", "b": "child"}, entries[2].MapData)
+		assert.Equal(t, map[string]interface{}{"a": "parent", "b": "child"}, entries[3].MapData)
+		assert.Equal(t, map[string]interface{}{"a": "parent", "b": "parent"}, entries[4].MapData)
+	}
+}
+
+////
+// StdScopeCorrelation - Test pairing Start/End lines by scope id
+//
+// 1) Enable scope correlation and log two sibling scopes with the same name
+//  -> Start and End of each scope share a scope= id in the header
+//  -> The ids of the two scopes differ
+// 2) Log the same with JSON
+//  -> Start and End share scope_id
+////
+func Test_Alog_StdScopeCorrelation(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	EnableScopeCorrelation()
+	defer ResetDefaults()
+	entries := []string{}
+	ConfigStdLogWriter(&entries)
+	siblings := func() {
+		for i := 0; i < 2; i++ {
+			LogScope("TEST", INFO, "foo").Close()
+		}
+	}
+
+	siblings()
+	r := regexp.MustCompile(`^[0-9/]* [0-9:]* \[TEST :INFO scope=([0-9a-f]+)\] (Start|End): foo\n$`)
+	ids := []string{}
+	for _, entry := range entries {
+		m := r.FindStringSubmatch(entry)
+		if assert.Len(t, m, 3, entry) {
+			ids = append(ids, m[1])
+		}
+	}
+	if assert.Len(t, ids, 4) {
+		assert.Equal(t, ids[0], ids[1])
+		assert.Equal(t, ids[2], ids[3])
+		assert.NotEqual(t, ids[0], ids[2])
+	}
+
+	// JSON
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	siblings()
+	logged := w.Entries()
+	if assert.Len(t, logged, 4) {
+		assert.NotEqual(t, "", logged[0].ScopeID)
+		assert.Equal(t, logged[0].ScopeID, logged[1].ScopeID)
+		assert.Equal(t, logged[2].ScopeID, logged[3].ScopeID)
+		assert.NotEqual(t, logged[0].ScopeID, logged[2].ScopeID)
+	}
+}
+
+////
+// JSONScopeCorrelation - Test scope_id and scope_seq on entries in scopes
+//
+// 1) Enable scope correlation and log inside nested scopes
+//  -> Each entry carries the id of the innermost scope and scope_seq increases
+//     monotonically within each scope, including the Start and End entries
+//  -> Entries outside of any scope have no scope id
+// 2) Log inside scopes on several goroutines at once
+//  -> scope_seq is monotonically increasing for each scope id
+// 3) Disable scope correlation and log inside a scope
+//  -> No scope id
+////
+func Test_Alog_JSONScopeCorrelation(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG2)
+	EnableScopeCorrelation()
+	defer ResetDefaults()
+	assert.True(t, ScopeCorrelationEnabled())
+
+	// Nested scopes
+	Log("TEST", INFO, "Before")
+	func() {
+		defer LogScope("TEST", INFO, "outer").Close()
+		Log("TEST", INFO, "a")
+		func() {
+			defer LogScope("TEST", INFO, "inner").Close()
+			Log("TEST", INFO, "b")
+		}()
+		Log("TEST", INFO, "c")
+	}()
+	Log("TEST", INFO, "After")
+
+	entries := w.Entries()
+	if assert.Equal(t, 9, len(entries)) {
+		outer := entries[1].ScopeID
+		inner := entries[3].ScopeID
+		assert.NotEqual(t, "", outer)
+		assert.NotEqual(t, "", inner)
+		assert.NotEqual(t, outer, inner)
+		type scopeExp struct {
+			id  string
+			seq uint64
+		}
+		exp := []scopeExp{
+			{"", 0},
+			{outer, 0},
+			{outer, 1},
+			{inner, 0},
+			{inner, 1},
+			{inner, 2},
+			{outer, 2},
+			{outer, 3},
+			{"", 0},
+		}
+		for i, e := range exp {
+			assert.Equal(t, e.id, entries[i].ScopeID, "entry %d", i)
+			assert.Equal(t, e.seq, entries[i].ScopeSeq, "entry %d", i)
+		}
+		assert.NotContains(t, w.Lines()[0], "scope_seq")
+	}
+
+	// Concurrent scopes
+	w.Reset()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer LogScope("TEST", INFO, "worker").Close()
+			for j := 0; j < 10; j++ {
+				Log("TEST", INFO, "step %d", j)
+			}
+		}()
+	}
+	wg.Wait()
+	lastSeq := map[string]uint64{}
+	for _, e := range w.Entries() {
+		if prev, ok := lastSeq[e.ScopeID]; ok {
+			assert.Equal(t, prev+1, e.ScopeSeq)
+		} else {
+			assert.Equal(t, uint64(0), e.ScopeSeq)
+		}
+		lastSeq[e.ScopeID] = e.ScopeSeq
+	}
+	assert.Equal(t, 4, len(lastSeq))
+	assert.NotContains(t, lastSeq, "")
+
+	// Disabled
+	w.Reset()
+	DisableScopeCorrelation()
+	LogScope("TEST", INFO, "disabled").Close()
+	for _, e := range w.Entries() {
+		assert.Equal(t, "", e.ScopeID)
+	}
+}
+
+////
+// JSON ChannelGroups - Verify the subsystem field for grouped channels
+//
+// 1) Group two channels into one subsystem and a third into another
+// 2) Log on each grouped channel, an ungrouped channel and a scope
+//  -> subsystem field set from each channel's group
+//  -> subsystem field absent for the ungrouped channel
+// 3) Remove the grouping
+//  -> subsystem field absent everywhere
+////
+func Test_Alog_JSONChannelGroups(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	groups := map[LogChannel]string{"HTTP": "api", "GRPC": "api", "DB": "storage"}
+	SetChannelGroups(groups)
+	groups["CACHE"] = "storage"
+
+	Log("HTTP", INFO, "http")
+	UseChannel("GRPC").Log(INFO, "grpc")
+	Log("DB", INFO, "db")
+	Log("CACHE", INFO, "cache")
+	LogScope("HTTP", INFO, "scope").Close()
+
+	// Check the result
+	entries := w.Entries()
+	if assert.Equal(t, 6, len(entries)) {
+		assert.Equal(t, "api", entries[0].Subsystem)
+		assert.Equal(t, "api", entries[1].Subsystem)
+		assert.Equal(t, "storage", entries[2].Subsystem)
+		assert.Equal(t, "", entries[3].Subsystem)
+		assert.Equal(t, "api", entries[4].Subsystem)
+		assert.Equal(t, "api", entries[5].Subsystem)
+	}
+	lines := w.Lines()
+	assert.Contains(t, lines[0], `"subsystem":"api"`)
+	assert.NotContains(t, lines[3], `"subsystem"`)
+
+	// Removed
+	w.Reset()
+	SetChannelGroups(nil)
+	Log("HTTP", INFO, "http")
+	assert.NotContains(t, w.Lines()[0], `"subsystem"`)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Parallel Tests //////////////////////////////////////////////////////////////
 ////////////////////////////////////////////////////////////////////////////////
@@ -924,3 +3195,156 @@ func Test_Alog_Parallel_FnLog(t *testing.T) {
 	f2()
 	f1()
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Benchmarks //////////////////////////////////////////////////////////////////
+////////////////////////////////////////////////////////////////////////////////
+
+func Benchmark_Alog_PrintfStd(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("BNCH", INFO, "Hello %s number %d", "world", i)
+	}
+}
+
+// Compare against Benchmark_Alog_PrintfStd to see the cost of the goroutine id
+// lookup, which is only paid when GID display is enabled
+func Benchmark_Alog_PrintfStdGID(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	EnableGID()
+	defer ResetDefaults()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("BNCH", INFO, "Hello %s number %d", "world", i)
+	}
+}
+
+func benchmarkStdHeader(b *testing.B, gid bool) {
+	if gid {
+		EnableGID()
+	}
+	defer ResetDefaults()
+	e := LogEntry{Channel: "BNCH", Level: INFO, Timestamp: time.Now()}
+	buf := &bytes.Buffer{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		StdLogFormatter{}.makeHeader(buf, e)
+	}
+}
+
+func Benchmark_Alog_StdHeader(b *testing.B) {
+	benchmarkStdHeader(b, false)
+}
+
+func Benchmark_Alog_StdHeaderGID(b *testing.B) {
+	benchmarkStdHeader(b, true)
+}
+
+func Benchmark_Alog_GetGID(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getGID()
+	}
+}
+
+func Benchmark_Alog_PrintfStdMultiline(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("BNCH", INFO, "Line one\nLine two\nLine %d", i)
+	}
+}
+
+func Benchmark_Alog_LogMapStd(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	b.ReportAllocs()
+	m := map[string]interface{}{"a": 1, "b": "two"}
+	for i := 0; i < b.N; i++ {
+		LogMap("BNCH", INFO, m)
+	}
+}
+
+func Benchmark_Alog_PrintfJSON(b *testing.B) {
+	SetWriter(ioutil.Discard)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("BNCH", INFO, "Hello %s number %d", "world", i)
+	}
+}
+
+// Compare the allocations of FormatEntry, which returns one string per line,
+// with FormatEntryTo, which the core uses to format into a pooled buffer
+func benchmarkFormatEntry(b *testing.B, f BufferLogFormatter, toBuffer bool) {
+	e := LogEntry{
+		Channel:   "BNCH",
+		Level:     INFO,
+		Timestamp: time.Now(),
+		Format:    "Hello %s number %d\nSecond line",
+		Expansion: []interface{}{"world", 42},
+		MapData:   map[string]interface{}{"a": 1, "b": "two"},
+	}
+	buf := &bytes.Buffer{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if toBuffer {
+			buf.Reset()
+			f.FormatEntryTo(buf, e)
+		} else {
+			for _, line := range f.FormatEntry(e) {
+				buf.Reset()
+				buf.WriteString(line)
+			}
+		}
+	}
+}
+
+func Benchmark_Alog_FormatEntryStd(b *testing.B) {
+	benchmarkFormatEntry(b, StdLogFormatter{}, false)
+}
+
+func Benchmark_Alog_FormatEntryToStd(b *testing.B) {
+	benchmarkFormatEntry(b, StdLogFormatter{}, true)
+}
+
+func Benchmark_Alog_FormatEntryJSON(b *testing.B) {
+	benchmarkFormatEntry(b, JSONLogFormatter{}, false)
+}
+
+func Benchmark_Alog_FormatEntryToJSON(b *testing.B) {
+	benchmarkFormatEntry(b, JSONLogFormatter{}, true)
+}
+
+func benchmarkDeepIndent(b *testing.B, maxIndent int) {
+	SetWriter(ioutil.Discard)
+	ConfigDefaultLevel(INFO)
+	SetMaxIndent(maxIndent)
+	defer ResetDefaults()
+	for i := 0; i < 10000; i++ {
+		Indent()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Log("BNCH", INFO, "Deep")
+	}
+}
+
+func Benchmark_Alog_DeepIndent(b *testing.B) {
+	benchmarkDeepIndent(b, 0)
+}
+
+func Benchmark_Alog_DeepIndentCapped(b *testing.B) {
+	benchmarkDeepIndent(b, 32)
+}
@@ -0,0 +1,133 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//-- Clock ---------------------------------------------------------------------
+
+// Get the current time from the configured clock in the configured location
+func (cfg *alogger) now() time.Time {
+	if nil != cfg.clock {
+		return cfg.clock().In(cfg.location)
+	}
+	return time.Now().In(cfg.location)
+}
+
+// SetClock - Set the function used to timestamp entries. This is intended for
+// tests that need deterministic timestamps. Pass nil to restore time.Now.
+func SetClock(clock func() time.Time) {
+	defaultLogger.SetClock(clock)
+}
+
+//-- Time Location -------------------------------------------------------------
+
+// Layout of the UTC offset added to JSON timestamps outside of UTC
+const jsonOffsetLayout = "-07:00"
+
+// SetTimeLocation - Set the location that timestamps are captured in, e.g.
+// time.Local for local time in console logs. The default is UTC. Pass nil to
+// restore UTC. Outside of UTC, the JSONLogFormatter adds the UTC offset to the
+// timestamp (e.g. "2021/03/04 17:04:05 +02:00") so that correlation across
+// zones is not lost.
+func SetTimeLocation(loc *time.Location) {
+	defaultLogger.SetTimeLocation(loc)
+}
+
+// UseLocalTime - Capture timestamps in the local time zone of the host
+func UseLocalTime() {
+	SetTimeLocation(time.Local)
+}
+
+// Add the UTC offset to a formatted JSON timestamp if it is not in UTC
+func formatJSONTimestamp(formatted string, ts time.Time) string {
+	if ts.Location() == time.UTC {
+		return formatted
+	}
+	return formatted + " " + ts.Format(jsonOffsetLayout)
+}
+
+//-- Delta Timestamps ----------------------------------------------------------
+
+// The timestamp of the last line written to each writer
+type deltaTracker struct {
+	mutex sync.Mutex
+	last  map[io.Writer]time.Time
+}
+
+// Get the time since the previous line written to the writer and record the
+// new timestamp. The first line for a writer has a delta of zero, as does a
+// line with a timestamp earlier than the previous one.
+func (d *deltaTracker) since(w io.Writer, ts time.Time) time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var delta time.Duration
+	if prev, ok := d.last[w]; ok && ts.After(prev) {
+		delta = ts.Sub(prev)
+	}
+	d.last[w] = ts
+	return delta
+}
+
+// Get the writer whose previous line an entry's delta is measured against
+//
+// NOTE: This does not provide a lock since it is an implementation only
+//  function. Any use of it must be inside a read lock
+////
+func (cfg *alogger) deltaWriter(level LogLevel) io.Writer {
+	if len(cfg.outputs) > 0 {
+		return cfg.outputs[0].writer
+	}
+	if nil != cfg.levelWriters {
+		if w, ok := cfg.levelWriters[level]; ok {
+			return w
+		}
+	}
+	return cfg.writer
+}
+
+// Format a delta for the std header, e.g. "+0.003s"
+func formatDelta(d time.Duration) string {
+	return "+" + strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+}
+
+// UseDeltaTimestamps - Show the time elapsed since the previous line written
+// to the same writer (e.g. "+0.003s") in place of the absolute timestamp in the
+// std header. The first line after enabling shows "+0.000s". This is intended
+// for reading bursty output during development. JSON output keeps the absolute
+// timestamp.
+func UseDeltaTimestamps() {
+	defaultLogger.UseDeltaTimestamps()
+}
+
+// UseAbsoluteTimestamps - Show the absolute timestamp in the std header again
+func UseAbsoluteTimestamps() {
+	defaultLogger.UseAbsoluteTimestamps()
+}
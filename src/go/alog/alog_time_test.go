@@ -0,0 +1,197 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"strings"
+	"testing"
+	"time"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Clock and Delta Timestamps //////////////////////////////////////////
+
+// Clock that advances by a fixed list of steps on each call
+type fakeClock struct {
+	now   time.Time
+	steps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	if len(c.steps) > 0 {
+		c.now = c.now.Add(c.steps[0])
+		c.steps = c.steps[1:]
+	}
+	return c.now
+}
+
+////
+// SetClock - Timestamp entries with an injected clock
+// 1) Set a fixed clock and log with JSON
+//  -> Entry timestamp from the clock
+// 2) Restore the default clock
+//  -> Entry timestamp close to now
+////
+func Test_AlogTime_SetClock(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Fixed
+	fixed := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return fixed })
+	Log("TEST", INFO, "fixed")
+	assert.Contains(t, w.Lines()[0], `"timestamp":"2020/01/02 03:04:05"`)
+
+	// Default
+	w.Reset()
+	SetClock(nil)
+	Log("TEST", INFO, "now")
+	entries := w.Entries()
+	if assert.Equal(t, 1, len(entries)) {
+		assert.WithinDuration(t, time.Now().UTC(), entries[0].Timestamp, time.Minute)
+	}
+}
+
+////
+// UseDeltaTimestamps - Show the time since the previous line in the header
+// 1) Use a fake clock and log several lines to one writer
+//  -> First line +0.000s, then the deltas between clock readings
+// 2) Log a multi-line entry
+//  -> Every line of the entry shows the same delta
+// 3) Split severe entries onto a separate writer
+//  -> Deltas are tracked per writer
+// 4) Use absolute timestamps again
+//  -> Absolute timestamp in the header
+////
+func Test_AlogTime_DeltaTimestamps(t *testing.T) {
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	SetClock(clock.Now)
+	UseDeltaTimestamps()
+	assert.Equal(t, true, PrintConfigMap()["delta_timestamps"])
+
+	// Deltas
+	clock.steps = []time.Duration{0, 3 * time.Millisecond, 1500 * time.Millisecond, 0}
+	Log("TEST", INFO, "one")
+	Log("TEST", INFO, "two")
+	Log("TEST", INFO, "three")
+	Log("TEST", INFO, "four")
+	assert.Equal(t, []string{
+		"+0.000s [TEST :INFO] one\n",
+		"+0.003s [TEST :INFO] two\n",
+		"+1.500s [TEST :INFO] three\n",
+		"+0.000s [TEST :INFO] four\n",
+	}, w.Lines())
+
+	// Multi-line
+	w.Reset()
+	clock.steps = []time.Duration{250 * time.Millisecond}
+	Log("TEST", INFO, "line one\nline two")
+	assert.Equal(t, []string{
+		"+0.250s [TEST :INFO] line one\n",
+		"+0.250s [TEST :INFO] line two\n",
+	}, w.Lines())
+
+	// Per writer
+	w.Reset()
+	errW := NewMemoryWriter()
+	std.enableStreamSplit(errW, w)
+	clock.steps = []time.Duration{time.Second, time.Second, time.Second}
+	Log("TEST", ERROR, "first error")
+	Log("TEST", INFO, "info")
+	Log("TEST", ERROR, "second error")
+	assert.Equal(t, []string{"+2.000s [TEST :INFO] info\n"}, w.Lines())
+	assert.Equal(t, []string{
+		"+0.000s [TEST :ERRR] first error\n",
+		"+2.000s [TEST :ERRR] second error\n",
+	}, errW.Lines())
+
+	// Absolute
+	DisableStdStreamSplit()
+	w.Reset()
+	UseAbsoluteTimestamps()
+	Log("TEST", INFO, "absolute")
+	assert.True(t, strings.HasPrefix(w.Lines()[0], "2020/01/02 03:04:"), w.Lines()[0])
+}
+
+////
+// SetTimeLocation - Capture timestamps in a configured location
+// 1) Log with a fixed UTC+5 location and both formatters
+//  -> Std hour is shifted by five hours
+//  -> JSON timestamp carries the offset and parses to the same instant
+// 2) Reset the defaults
+//  -> Timestamps in UTC again without an offset
+// 3) Use local time
+//  -> Location reported as Local
+////
+func Test_AlogTime_Location(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	ts := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	w := NewMemoryWriter()
+
+	// Fixed location
+	SetWriter(w)
+	SetClock(func() time.Time { return ts })
+	SetTimeLocation(time.FixedZone("UTC+5", 5*60*60))
+	Log("TEST", INFO, "Hello")
+	UseJSONLogFormatter()
+	Log("TEST", INFO, "Hello")
+	lines := w.Lines()
+	if assert.Equal(t, 2, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], "2021/03/04 15:00:00 [TEST :INFO]"))
+		assert.Contains(t, lines[1], `"timestamp":"2021/03/04 15:00:00 +05:00"`)
+		e, err := JSONToLogEntry(lines[1])
+		if assert.Nil(t, err) {
+			assert.True(t, ts.Equal(e.Timestamp))
+		}
+	}
+
+	// Reset
+	ResetDefaults()
+	ConfigDefaultLevel(INFO)
+	w.Reset()
+	SetWriter(w)
+	SetClock(func() time.Time { return ts })
+	UseJSONLogFormatter()
+	Log("TEST", INFO, "Hello")
+	if lines = w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], `"timestamp":"2021/03/04 10:00:00"`)
+	}
+
+	// Local
+	UseLocalTime()
+	assert.Equal(t, "Local", PrintConfigMap()["time_location"])
+}
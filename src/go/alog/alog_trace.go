@@ -0,0 +1,105 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"context"
+)
+
+//-- Trace Correlation ---------------------------------------------------------
+
+// SetTraceExtractor - Set the function used to extract the trace and span ids
+// of the active span from a context.Context for LogCtx and ChannelLog.Ctx. The
+// extractor returns false if the context holds no span. This keeps alog free
+// of a dependency on a tracing library; for OpenTelemetry, the extractor can
+// be built on trace.SpanContextFromContext. Pass nil to disable extraction.
+func SetTraceExtractor(f func(ctx context.Context) (traceID, spanID string, ok bool)) {
+	defaultLogger.SetTraceExtractor(f)
+}
+
+// Extract the trace and span ids from a context with the configured extractor
+func (cfg *alogger) traceIDs(ctx context.Context) (string, string) {
+	cfg.mutex.RLock()
+	extract := cfg.traceExtractor
+	cfg.mutex.RUnlock()
+	if nil == extract || nil == ctx {
+		return "", ""
+	}
+	if traceID, spanID, ok := extract(ctx); ok {
+		return traceID, spanID
+	}
+	return "", ""
+}
+
+// LogCtx - Log a message tagged with the trace and span ids of the active span
+// in ctx (trace_id and span_id in JSON output)
+func LogCtx(ctx context.Context, channel LogChannel, level LogLevel, format string, v ...interface{}) {
+	testHelper()()
+	traceID, spanID := std.traceIDs(ctx)
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		TraceID:   traceID,
+		SpanID:    spanID,
+	})
+}
+
+// LogWithMapCtx - Log a message with map data tagged with the trace and span
+// ids of the active span in ctx
+func LogWithMapCtx(ctx context.Context, channel LogChannel, level LogLevel, mapData map[string]interface{}, format string, v ...interface{}) {
+	testHelper()()
+	traceID, spanID := std.traceIDs(ctx)
+	std.log(LogEntry{
+		Channel:   channel,
+		Level:     level,
+		Format:    format,
+		Expansion: v,
+		MapData:   mapData,
+		TraceID:   traceID,
+		SpanID:    spanID,
+	})
+}
+
+// Ctx - Create a copy of this ChannelLog that tags every entry with the trace
+// and span ids of the active span in ctx. The ids are extracted once, when
+// the copy is created.
+func (ch *channelLogImpl) Ctx(ctx context.Context) ChannelLogger {
+	traceID, spanID := ch.cfg.traceIDs(ctx)
+	return &channelLogImpl{
+		cfg:       ch.cfg,
+		channel:   ch.channel,
+		component: ch.component,
+		fields:    ch.fields,
+		traceID:   traceID,
+		spanID:    spanID,
+	}
+}
+
+// Ctx - Returns the same no-op logger
+func (n nopChannelLog) Ctx(ctx context.Context) ChannelLogger {
+	return n
+}
@@ -0,0 +1,140 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"context"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Trace Correlation ///////////////////////////////////////////////////
+
+// Context key and fake extractor standing in for a tracing library
+type fakeSpanKey struct{}
+
+type fakeSpan struct {
+	traceID string
+	spanID  string
+}
+
+func fakeExtractor(ctx context.Context) (string, string, bool) {
+	if span, ok := ctx.Value(fakeSpanKey{}).(fakeSpan); ok {
+		return span.traceID, span.spanID, true
+	}
+	return "", "", false
+}
+
+////
+// LogCtx - Tag entries with the trace and span ids from a context
+// 1) Log with a context holding a span with the std formatter
+//  -> Ids appended to the header and parsed back from the line
+// 2) Log with a context without a span
+//  -> No ids in the header
+// 3) Log with a context holding a span with the JSON formatter
+//  -> trace_id and span_id fields set
+////
+func Test_AlogTrace_LogCtx(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetTraceExtractor(fakeExtractor)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ctx := context.WithValue(context.Background(), fakeSpanKey{}, fakeSpan{"4bf92f35", "00f067aa"})
+
+	// Std
+	LogCtx(ctx, "TEST", INFO, "Hello %s", "world")
+	LogCtx(context.Background(), "TEST", INFO, "No span")
+	lines := w.Lines()
+	if assert.Equal(t, 2, len(lines)) {
+		assert.Contains(t, lines[0], " [TEST :INFO trace=4bf92f35 span=00f067aa] Hello world")
+		e, err := ParseLine(lines[0])
+		if assert.Nil(t, err) {
+			assert.Equal(t, "4bf92f35", e.TraceID)
+			assert.Equal(t, "00f067aa", e.SpanID)
+			assert.Equal(t, "Hello world", e.Format)
+		}
+		assert.Contains(t, lines[1], " [TEST :INFO] No span")
+	}
+
+	// JSON
+	w.Reset()
+	UseJSONLogFormatter()
+	LogWithMapCtx(ctx, "TEST", INFO, map[string]interface{}{"key": "val"}, "Hello")
+	if entries := w.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "4bf92f35", entries[0].TraceID)
+		assert.Equal(t, "00f067aa", entries[0].SpanID)
+		assert.Equal(t, map[string]interface{}{"key": "val"}, entries[0].MapData)
+	}
+}
+
+////
+// ChannelLog.Ctx - Tag every entry of a channel logger with the ids
+// 1) Create a context logger with a component and log with both formatters
+//  -> Ids present on every line, component kept
+// 2) Log with a context logger without an extractor configured
+//  -> No ids
+////
+func Test_AlogTrace_ChannelCtx(t *testing.T) {
+	ConfigDefaultLevel(INFO)
+	SetTraceExtractor(fakeExtractor)
+	defer ResetDefaults()
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ctx := context.WithValue(context.Background(), fakeSpanKey{}, fakeSpan{"abc", "def"})
+
+	ch := UseChannel("TEST").WithComponent("comp").Ctx(ctx)
+	ch.Log(INFO, "One")
+	ch.WithFields(map[string]interface{}{"a": 1}).Infof("Two")
+	entries := w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		for _, e := range entries {
+			assert.Equal(t, "abc", e.TraceID)
+			assert.Equal(t, "def", e.SpanID)
+			assert.Equal(t, "comp", e.Component)
+		}
+	}
+
+	// Std
+	w.Reset()
+	UseStdLogFormatter()
+	ch.Log(INFO, "Three")
+	if lines := w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], " [TEST :INFO trace=abc span=def] (comp) Three")
+	}
+
+	// No extractor
+	w.Reset()
+	SetTraceExtractor(nil)
+	UseChannel("TEST").Ctx(ctx).Log(INFO, "Four")
+	if lines := w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], " [TEST :INFO] Four")
+	}
+	assert.NotNil(t, NopChannelLog().Ctx(ctx))
+}
@@ -0,0 +1,159 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+//-- Discard Writer ------------------------------------------------------------
+
+// DiscardWriter - io.Writer that drops everything written to it. This is the
+// same as ioutil.Discard, provided here so that silencing the logs (e.g. with
+// SetWriter(alog.DiscardWriter)) reads clearly from the call site.
+var DiscardWriter io.Writer = discardWriter{}
+
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+//-- Memory Writer -------------------------------------------------------------
+
+// MemoryWriter - io.Writer implementation that captures log lines in memory.
+// This is intended for use in test suites that need to assert on log output.
+type MemoryWriter struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+// NewMemoryWriter - Create an empty MemoryWriter
+func NewMemoryWriter() *MemoryWriter {
+	return &MemoryWriter{lines: []string{}}
+}
+
+// Write - Record a single line. Each call to Write is recorded as one line,
+// exactly as written (including the trailing newline).
+func (w *MemoryWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	w.lines = append(w.lines, string(p))
+	w.mutex.Unlock()
+	return len(p), nil
+}
+
+// Lines - Get a copy of all lines captured so far
+func (w *MemoryWriter) Lines() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	out := make([]string, len(w.lines))
+	copy(out, w.lines)
+	return out
+}
+
+// Reset - Discard all captured lines
+func (w *MemoryWriter) Reset() {
+	w.mutex.Lock()
+	w.lines = []string{}
+	w.mutex.Unlock()
+}
+
+// Entries - Parse all captured lines as JSON log lines. This is only useful
+// when the JSONLogFormatter is active. Lines that fail to parse are skipped.
+func (w *MemoryWriter) Entries() []LogEntry {
+
+	// NOTE: Parse outside of the lock since parsing may log if this writer is
+	//  the configured writer
+	out := []LogEntry{}
+	for _, line := range w.Lines() {
+		if le, err := JSONToLogEntry(line); nil == err && nil != le {
+			out = append(out, *le)
+		}
+	}
+	return out
+}
+
+//-- Entry Capture -------------------------------------------------------------
+
+// LogFormatter implementation that records entries rather than formatting them
+type captureFormatter struct {
+	mutex   sync.Mutex
+	entries []LogEntry
+}
+
+// FormatEntry - Record the entry and produce no output lines
+func (f *captureFormatter) FormatEntry(e LogEntry) []string {
+	e.logger = nil
+	f.mutex.Lock()
+	f.entries = append(f.entries, e)
+	f.mutex.Unlock()
+	return []string{}
+}
+
+// CaptureEntries - Run fn with a formatter installed that captures every
+// emitted entry, then restore the previous formatters and return the captured
+// entries. Level filtering is applied as usual and nothing is written to the
+// configured writer, the per-channel formatters' output or the dual outputs
+// while fn runs.
+//
+// NOTE: The formatter is global, so entries logged by other goroutines while fn
+//  is running are captured as well.
+////
+func CaptureEntries(fn func()) []LogEntry {
+	return defaultLogger.CaptureEntries(fn)
+}
+
+//-- Testing Writer ------------------------------------------------------------
+
+// TestingLogger - The subset of testing.TB used by the TestingWriter
+type TestingLogger interface {
+	Helper()
+	Log(args ...interface{})
+}
+
+// TestingWriter - io.Writer implementation that mirrors log lines to the Go
+// testing output (e.g. a *testing.T) so they are shown with go test -v and
+// attached to the test that produced them.
+//
+// When configured with SetWriter, every function on the alog logging path is
+// marked as a test helper so that lines are attributed to the calling line in
+// the test rather than to alog internals.
+type TestingWriter struct {
+	t TestingLogger
+}
+
+// NewTestingWriter - Create a TestingWriter for the given test
+func NewTestingWriter(t TestingLogger) *TestingWriter {
+	return &TestingWriter{t: t}
+}
+
+// Write - Log the line to the test output without its trailing newline
+func (w *TestingWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
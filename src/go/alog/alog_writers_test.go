@@ -0,0 +1,434 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package alog
+
+import (
+	// Standard
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests - Memory Writer ///////////////////////////////////////////////////////
+
+////
+// MemoryWriter Std - Capture plain text lines
+// 1) Configure a MemoryWriter as the writer
+// 2) Log a few lines
+//  -> Lines captured in order
+// 3) Reset the writer
+//  -> No lines captured
+////
+func Test_AlogWriters_MemoryWriterStd(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Log some lines
+	Log("TEST", INFO, "Line one")
+	Log("TEST", DEBUG, "Not logged")
+	Log("TEST", WARNING, "Line two")
+	assert.True(t, VerifyLogs(w.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "Line one"},
+		ExpEntry{channel: "TEST ", level: "WARN", body: "Line two"},
+	}))
+
+	// Reset
+	w.Reset()
+	assert.Equal(t, 0, len(w.Lines()))
+	Log("TEST", INFO, "After reset")
+	assert.Equal(t, 1, len(w.Lines()))
+}
+
+////
+// MemoryWriter Entries - Parse captured JSON lines as LogEntry objects
+// 1) Configure a MemoryWriter with the JSON formatter
+// 2) Log a line and a map
+//  -> Entries parse with the correct level, channel and map data
+// 3) Write a non-JSON line
+//  -> Non-JSON line is skipped by Entries
+////
+func Test_AlogWriters_MemoryWriterEntries(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseJSONLogFormatter()
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+
+	// Log some lines
+	Log("TEST", INFO, "Hello %s", "world")
+	LogMap("MAP", DEBUG, map[string]interface{}{"key": "val"})
+	w.Write([]byte("not json\n"))
+
+	// Validate
+	assert.Equal(t, 3, len(w.Lines()))
+	entries := w.Entries()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, LogChannel("TEST"), entries[0].Channel)
+		assert.Equal(t, INFO, entries[0].Level)
+		assert.Equal(t, "Hello world", entries[0].Format)
+		assert.Equal(t, LogChannel("MAP"), entries[1].Channel)
+		assert.Equal(t, DEBUG, entries[1].Level)
+		assert.Equal(t, "val", entries[1].MapData["key"])
+	}
+}
+
+// Tests - Entry Capture ///////////////////////////////////////////////////////
+
+////
+// CaptureEntries - Capture structured entries from a function
+// 1) Configure a writer and default level
+// 2) Capture entries from a function that logs text and map data
+//  -> Only enabled entries captured
+//  -> Level and MapData available directly
+//  -> Nothing written to the writer
+// 3) Log after capturing
+//  -> Previous formatter restored
+////
+func Test_AlogWriters_CaptureEntries(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Capture
+	entries := CaptureEntries(func() {
+		ch := UseChannel("TEST")
+		ch.Log(WARNING, "Watch out for %s", "that")
+		ch.Log(DEBUG, "Not enabled")
+		ch.LogMap(INFO, map[string]interface{}{"key": 1})
+	})
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, WARNING, entries[0].Level)
+		assert.Equal(t, LogChannel("TEST"), entries[0].Channel)
+		assert.Equal(t, "Watch out for %s", entries[0].Format)
+		assert.Equal(t, []interface{}{"that"}, entries[0].Expansion)
+		assert.Equal(t, INFO, entries[1].Level)
+		assert.Equal(t, map[string]interface{}{"key": 1}, entries[1].MapData)
+	}
+	assert.Equal(t, 0, len(w.Lines()))
+
+	// Make sure the formatter was restored
+	Log("TEST", INFO, "After capture")
+	assert.True(t, VerifyLogs(w.Lines(), []ExpEntry{
+		ExpEntry{channel: "TEST ", level: "INFO", body: "After capture"},
+	}))
+}
+
+////
+// CaptureEntries - Capture past the channel formatters and dual outputs
+// 1) Set a channel formatter override and capture from that channel
+//  -> Entry captured, nothing written
+// 2) Enable dual output and capture
+//  -> Each entry captured once, nothing written to either output
+// 3) Log after capturing
+//  -> Channel formatter and dual outputs restored
+////
+func Test_AlogWriters_CaptureEntriesOverrides(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	// Channel formatter
+	SetChannelFormatter("JSON", JSONLogFormatter{})
+	entries := CaptureEntries(func() {
+		Log("JSON", INFO, "structured")
+	})
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "structured", entries[0].Format)
+	}
+	assert.Equal(t, 0, len(w.Lines()))
+
+	// Dual output
+	human := NewMemoryWriter()
+	machine := NewMemoryWriter()
+	EnableDualOutput(human, machine)
+	entries = CaptureEntries(func() {
+		Log("TEST", INFO, "both")
+	})
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, 0, len(human.Lines()))
+	assert.Equal(t, 0, len(machine.Lines()))
+
+	// Restored
+	Log("JSON", INFO, "after")
+	assert.Equal(t, 1, len(human.Lines()))
+	assert.Equal(t, 1, len(machine.Lines()))
+	DisableDualOutput()
+	Log("JSON", INFO, "after")
+	if lines := w.Lines(); assert.Equal(t, 1, len(lines)) {
+		assert.Contains(t, lines[0], `"message":"after"`)
+	}
+}
+
+////
+// Logger.CaptureEntries - Capture entries from a Logger instance
+// 1) Capture from a function that logs through a NewLogger instance
+//  -> Entries of that Logger captured, nothing written to its writer
+//  -> Entries of the default Logger not captured
+////
+func Test_AlogWriters_LoggerCaptureEntries(t *testing.T) {
+	appWriter := NewMemoryWriter()
+	SetWriter(appWriter)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+
+	l := NewLogger()
+	w := NewMemoryWriter()
+	l.SetWriter(w)
+	l.ConfigDefaultLevel(INFO)
+	entries := l.CaptureEntries(func() {
+		l.Log("LIB", INFO, "from the library")
+		l.Log("LIB", DEBUG, "not enabled")
+		Log("APP", INFO, "from the app")
+	})
+	if assert.Equal(t, 1, len(entries)) {
+		assert.Equal(t, "from the library", entries[0].Format)
+	}
+	assert.Equal(t, 0, len(w.Lines()))
+	assert.Equal(t, 1, len(appWriter.Lines()))
+}
+
+// Tests - Testing Writer //////////////////////////////////////////////////////
+
+// Fake TestingLogger that attributes each line the same way the testing
+// package does: to the first frame that has not been marked as a helper
+type attributingLogger struct {
+	mutex   sync.Mutex
+	helpers map[string]bool
+	lines   []string
+}
+
+func (l *attributingLogger) Helper() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	pc, _, _, _ := runtime.Caller(1)
+	l.helpers[runtime.FuncForPC(pc).Name()] = true
+}
+
+func (l *attributingLogger) Log(args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	pcs := make([]uintptr, 50)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !l.helpers[frame.Function] {
+			parts := strings.Split(frame.File, "/")
+			l.lines = append(l.lines, fmt.Sprintf("%s:%d: %s", parts[len(parts)-1], frame.Line, fmt.Sprint(args...)))
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+////
+// TestingWriter - Make sure lines are attributed to the calling test line
+// 1) Configure a TestingWriter with a fake logger that attributes lines like
+//  the testing package does
+// 2) Log through the package functions, a ChannelLog, and a scope
+//  -> Each line attributed to this file at the line of the log call
+// 3) Configure a different writer
+//  -> Helpers no longer marked
+////
+func Test_AlogWriters_TestingWriter(t *testing.T) {
+
+	// Configure
+	l := &attributingLogger{helpers: map[string]bool{}}
+	SetWriter(NewTestingWriter(l))
+	ConfigDefaultLevel(DEBUG)
+	defer ResetDefaults()
+
+	// Log and record the expected line numbers
+	ch := UseChannel("TEST")
+	expLines := []int{}
+	_, _, line, _ := runtime.Caller(0)
+	Log("TEST", INFO, "Package log")
+	expLines = append(expLines, line+1)
+	_, _, line, _ = runtime.Caller(0)
+	ch.Log(INFO, "Channel log")
+	expLines = append(expLines, line+1)
+	_, _, line, _ = runtime.Caller(0)
+	ch.LogMap(INFO, map[string]interface{}{"key": "val"})
+	expLines = append(expLines, line+1)
+	_, _, line, _ = runtime.Caller(0)
+	scope := ch.LogScope(DEBUG, "scope")
+	expLines = append(expLines, line+1)
+	_, _, line, _ = runtime.Caller(0)
+	scope.Close()
+	expLines = append(expLines, line+1)
+
+	// Check the attribution
+	if assert.Equal(t, len(expLines), len(l.lines)) {
+		for i, expLine := range expLines {
+			assert.True(
+				t,
+				strings.HasPrefix(l.lines[i], fmt.Sprintf("alog_writers_test.go:%d: ", expLine)),
+				"Bad attribution: %s", l.lines[i],
+			)
+		}
+	}
+	assert.True(t, strings.HasSuffix(l.lines[0], "Package log"))
+
+	// Log to the real test output as an example
+	SetWriter(NewTestingWriter(t))
+	Log("TEST", INFO, "This line is attributed to this test in go test -v")
+
+	// Switch away and make sure the helper isn't called anymore
+	nHelpers := len(l.helpers)
+	l.helpers = map[string]bool{}
+	SetWriter(NewMemoryWriter())
+	Log("TEST", INFO, "Not mirrored")
+	assert.Equal(t, 0, len(l.helpers))
+	assert.True(t, nHelpers > 0)
+}
+
+// Tests - Discard Writer and Null Formatter ///////////////////////////////////
+
+////
+// DiscardWriter - Drop everything written
+// 1) Write directly to the DiscardWriter
+//  -> Full length reported with no error
+// 2) Log with the DiscardWriter configured
+//  -> No error reported to the write error handler
+////
+func Test_AlogWriters_DiscardWriter(t *testing.T) {
+	n, err := DiscardWriter.Write([]byte("dropped\n"))
+	assert.Equal(t, 8, n)
+	assert.Nil(t, err)
+
+	SetWriter(DiscardWriter)
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	nErrors := 0
+	SetWriteErrorHandler(func(error, LogEntry) { nErrors++ })
+	Log("TEST", INFO, "dropped")
+	assert.Equal(t, 0, nErrors)
+}
+
+////
+// NullFormatter - Silence output while keeping level filtering
+// 1) Configure a MemoryWriter with the null formatter and log at several
+//    levels, including a map and a scope
+//  -> No lines reach the writer
+//  -> IsEnabled still follows the level configuration
+//  -> Entries are still counted as emitted
+// 2) Switch back to the std formatter
+//  -> Lines reach the writer again
+////
+func Test_AlogWriters_NullFormatter(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseNullFormatter()
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	defer ResetStats()
+	ResetStats()
+	assert.Equal(t, "null", PrintConfigMap()["formatter"])
+
+	// Log
+	Log("TEST", INFO, "Line one")
+	Log("TEST", ERROR, "Line two\nwith two lines")
+	LogMap("TEST", INFO, map[string]interface{}{"key": "val"})
+	LogScope("TEST", INFO, "scope").Close()
+	assert.Equal(t, 0, len(w.Lines()))
+	assert.True(t, IsEnabled("TEST", INFO))
+	assert.False(t, IsEnabled("TEST", DEBUG))
+	assert.Equal(t, uint64(4), GetStats().Emitted[INFO])
+
+	// Back to std
+	UseStdLogFormatter()
+	Log("TEST", INFO, "Line three")
+	assert.Equal(t, 1, len(w.Lines()))
+}
+
+////
+// MultiFormatter - Write a std and a JSON line for each entry
+// 1) Configure a MemoryWriter with a Std+JSON multi formatter and log
+//  -> Std line followed by the JSON line for each entry
+// 2) Reverse the order of the formatters
+//  -> JSON line followed by the std line
+////
+func Test_AlogWriters_MultiFormatter(t *testing.T) {
+
+	// Configure
+	w := NewMemoryWriter()
+	SetWriter(w)
+	UseMultiFormatter(StdLogFormatter{}, JSONLogFormatter{})
+	ConfigDefaultLevel(INFO)
+	defer ResetDefaults()
+	assert.Equal(t, "multi", PrintConfigMap()["formatter"])
+
+	// Log
+	Log("TEST", INFO, "Line one")
+	Log("TEST", WARNING, "Line two")
+	lines := w.Lines()
+	assert.Equal(t, 4, len(lines))
+	if len(lines) == 4 {
+		assert.True(t, VerifyLogs([]string{lines[0], lines[2]}, []ExpEntry{
+			ExpEntry{channel: "TEST ", level: "INFO", body: "Line one"},
+			ExpEntry{channel: "TEST ", level: "WARN", body: "Line two"},
+		}))
+		assert.True(t, VerifyJSONLogs([]string{lines[1], lines[3]}, []ExpEntry{
+			ExpEntry{channel: "TEST", level: "info", body: "Line one"},
+			ExpEntry{channel: "TEST", level: "warning", body: "Line two"},
+		}))
+	}
+
+	// Reverse
+	w.Reset()
+	UseMultiFormatter(JSONLogFormatter{}, StdLogFormatter{})
+	Log("TEST", INFO, "Line three")
+	lines = w.Lines()
+	assert.Equal(t, 2, len(lines))
+	if len(lines) == 2 {
+		assert.True(t, VerifyJSONLogs(lines[:1], []ExpEntry{
+			ExpEntry{channel: "TEST", level: "info", body: "Line three"},
+		}))
+		assert.True(t, VerifyLogs(lines[1:], []ExpEntry{
+			ExpEntry{channel: "TEST ", level: "INFO", body: "Line three"},
+		}))
+	}
+}
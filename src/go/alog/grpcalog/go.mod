@@ -0,0 +1,11 @@
+module github.com/IBM/alchemy-logging/src/go/alog/grpcalog
+
+go 1.16
+
+replace github.com/IBM/alchemy-logging/src/go => ../../
+
+require (
+	github.com/IBM/alchemy-logging/src/go v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/grpc v1.46.2
+)
@@ -0,0 +1,106 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+// Package grpcalog provides gRPC server interceptors that log each call with
+// alog. It is a separate module so that alog itself does not depend on gRPC.
+package grpcalog
+
+import (
+	// Standard
+	"context"
+	"time"
+
+	// Third Party
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+//-- Server Interceptors -------------------------------------------------------
+
+// Metadata key that an incoming request id is read from
+const requestIDKey = "x-request-id"
+
+// Get the request id from the incoming metadata, or an empty string if there is
+// none
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDKey); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
+
+// Run a handler inside a request scope for the method and log its status and
+// duration before the scope closes
+func logCall(ctx context.Context, channel alog.LogChannel, level alog.LogLevel, method string, handler func() error) error {
+	if !alog.IsEnabled(channel, level) {
+		return handler()
+	}
+	defer alog.RequestScope(channel, level, requestID(ctx), "%s", method).Close()
+	start := time.Now()
+	err := handler()
+	duration := time.Since(start)
+	code := status.Code(err)
+	alog.LogWithMap(channel, level, map[string]interface{}{
+		"method":      method,
+		"code":        code.String(),
+		"duration_ms": float64(duration) / float64(time.Millisecond),
+	}, "Status %s in %s", code, duration)
+	return err
+}
+
+// UnaryServerInterceptor - Get a grpc.UnaryServerInterceptor that handles each
+// call inside an alog RequestScope on the given channel and level, named by
+// the full method name. Entries logged by the handler are indented inside the
+// scope and tagged with the request id, which is taken from the x-request-id
+// metadata if present. Before the scope closes, a line with the status code
+// and the duration of the call is logged, with the method, code and
+// duration_ms as map data.
+func UnaryServerInterceptor(channel alog.LogChannel, level alog.LogLevel) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var resp interface{}
+		err := logCall(ctx, channel, level, info.FullMethod, func() error {
+			var hErr error
+			resp, hErr = handler(ctx, req)
+			return hErr
+		})
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor - Get a grpc.StreamServerInterceptor that logs each
+// streaming call like UnaryServerInterceptor. The scope stays open for the
+// life of the stream.
+func StreamServerInterceptor(channel alog.LogChannel, level alog.LogLevel) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return logCall(ss.Context(), channel, level, info.FullMethod, func() error {
+			return handler(srv, ss)
+		})
+	}
+}
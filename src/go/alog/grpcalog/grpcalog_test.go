@@ -0,0 +1,158 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package grpcalog
+
+import (
+	// Standard
+	"context"
+	"net"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+// Tests - Server Interceptors /////////////////////////////////////////////////
+
+// Health server that logs from inside each call
+type loggingHealthServer struct {
+	*health.Server
+}
+
+func (s loggingHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	alog.Log("GRPC", alog.INFO, "Checking %s", req.Service)
+	return s.Server.Check(ctx, req)
+}
+
+func (s loggingHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, ws grpc_health_v1.Health_WatchServer) error {
+	alog.Log("GRPC", alog.INFO, "Watching %s", req.Service)
+	return status.Error(codes.Unimplemented, "no watching")
+}
+
+// Start an in-memory server with the interceptors and connect a client to it
+func startServer(t *testing.T) (grpc_health_v1.HealthClient, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor("GRPC", alog.INFO)),
+		grpc.StreamInterceptor(StreamServerInterceptor("GRPC", alog.INFO)),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("ok", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(srv, loggingHealthServer{hs})
+	go srv.Serve(lis)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	assert.Nil(t, err)
+	return grpc_health_v1.NewHealthClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+////
+// UnaryServerInterceptor - Calls logged in a request scope
+// 1) Make a successful call with a request id in the metadata
+//  -> Start, handler, status and End entries, all with the request id
+//  -> Handler entry indented inside the scope
+//  -> Status entry has the method, OK code and a duration
+// 2) Make a failing call
+//  -> Status entry has the NotFound code
+////
+func Test_GrpcAlog_Unary(t *testing.T) {
+	w := alog.NewMemoryWriter()
+	alog.SetWriter(w)
+	alog.UseJSONLogFormatter()
+	alog.ConfigDefaultLevel(alog.INFO)
+	defer alog.ResetDefaults()
+	client, stop := startServer(t)
+	defer stop()
+
+	// Success
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1")
+	_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "ok"})
+	assert.Nil(t, err)
+	entries := w.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "Start: /grpc.health.v1.Health/Check", entries[0].Format)
+		assert.Equal(t, "Checking ok", entries[1].Format)
+		assert.Equal(t, 1, entries[1].NIndent)
+		assert.Equal(t, "/grpc.health.v1.Health/Check", entries[2].MapData["method"])
+		assert.Equal(t, "OK", entries[2].MapData["code"])
+		assert.Contains(t, entries[2].MapData, "duration_ms")
+		assert.Equal(t, "End: /grpc.health.v1.Health/Check", entries[3].Format)
+		for _, e := range entries {
+			assert.Equal(t, "req-1", e.RequestID)
+		}
+	}
+
+	// Failure
+	w.Reset()
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	entries = w.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "NotFound", entries[2].MapData["code"])
+		assert.NotEqual(t, "", entries[0].RequestID)
+	}
+}
+
+////
+// StreamServerInterceptor - Streaming calls logged in a request scope
+// 1) Open a stream that the handler ends with an error
+//  -> Start, handler, status and End entries
+//  -> Status entry has the Unimplemented code
+////
+func Test_GrpcAlog_Stream(t *testing.T) {
+	w := alog.NewMemoryWriter()
+	alog.SetWriter(w)
+	alog.UseJSONLogFormatter()
+	alog.ConfigDefaultLevel(alog.INFO)
+	defer alog.ResetDefaults()
+	client, stop := startServer(t)
+	defer stop()
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "ok"})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	entries := w.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "Start: /grpc.health.v1.Health/Watch", entries[0].Format)
+		assert.Equal(t, "Watching ok", entries[1].Format)
+		assert.Equal(t, "Unimplemented", entries[2].MapData["code"])
+		assert.Equal(t, "End: /grpc.health.v1.Health/Watch", entries[3].Format)
+	}
+}
@@ -45,7 +45,7 @@ type TestWriter struct {
 	entries *[]string
 }
 
-func (w TestWriter) Write(p []byte) (int, error) {
+func (w *TestWriter) Write(p []byte) (int, error) {
 	w.mu.Lock()
 	n, err := os.Stderr.Write(p)
 	*w.entries = append(*(w.entries), string(p))
@@ -55,14 +55,14 @@ func (w TestWriter) Write(p []byte) (int, error) {
 
 // ConfigStdLogWriter - Helper to configure test writer to capture Std log lines
 func ConfigStdLogWriter(entries *[]string) {
-	SetWriter(TestWriter{entries: entries})
+	SetWriter(&TestWriter{entries: entries})
 	UseStdLogFormatter()
 }
 
 // ConfigJSONLogWriter - Helper to configure test writer to capture json log
 // lines
 func ConfigJSONLogWriter(entries *[]string) {
-	SetWriter(TestWriter{entries: entries})
+	SetWriter(&TestWriter{entries: entries})
 	UseJSONLogFormatter()
 }
 
@@ -89,11 +89,12 @@ func matchExp(entry string, exp ExpEntry, verbose bool) bool {
 	//  bracked header. The only thing that can fall in here is the service name.
 	//  This section is optional, so may be empty
 	// - "\\[([^:]*):" - Open the bracketed header and parse the channel
-	// - "([^\\]:]*)" - Parse the level
-	// - "([^\\]\\s]*)\\]" - Parse the thread id if present (optional)
+	// - "([^\\]:\\s]*)" - Parse the level
+	// - "([^\\]\\s]*)" - Parse the thread id if present (optional)
+	// - "(?: [^\\]]*)?\\]" - Skip any other header fields such as scope= (optional)
 	// - " ([\\s]*)" - Parse the indentation whitespace
 	// - "([^\\s].*)\n$" - Parse the message to the end of the line
-	r := regexp.MustCompile("^[0-9/]* [0-9:]* ([^\\]]*)\\[([^:]*):([^\\]:]*)([^\\]\\s]*)\\] ([\\s]*)([^\\s].*)\n$")
+	r := regexp.MustCompile("^[0-9/]* [0-9:]* ([^\\]]*)\\[([^:]*):([^\\]:\\s]*)([^\\]\\s]*)(?: [^\\]]*)?\\] ([\\s]*)([^\\s].*)\n$")
 
 	// Parse the log with the regex and make sure there's a (possibly empty) match
 	// for each of the regex groups.
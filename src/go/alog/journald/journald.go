@@ -0,0 +1,164 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+// Package journald provides an alog writer that sends entries to the systemd
+// journal over its native protocol. The writer is only available on Linux; on
+// other platforms NewWriter returns an error.
+package journald
+
+import (
+	// Standard
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+//-- Field Encoding ------------------------------------------------------------
+
+// Maximum length of a journal field name
+const maxFieldNameLen = 64
+
+// Priority - Get the syslog priority used as the journal PRIORITY field for an
+// alog level. FATAL maps to critical (2), ERROR to error (3), WARNING to
+// warning (4), INFO to informational (6) and TRACE and all debug levels to
+// debug (7).
+func Priority(level alog.LogLevel) int {
+	switch {
+	case level <= alog.FATAL:
+		return 2
+	case level == alog.ERROR:
+		return 3
+	case level == alog.WARNING:
+		return 4
+	case level == alog.INFO:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// Convert a map data key to a valid journal field name: uppercase letters,
+// digits and underscores, not starting with an underscore (which is reserved
+// for fields set by the journal itself) and at most 64 characters. Returns the
+// empty string if nothing is left.
+func fieldName(key string) string {
+	b := strings.Builder{}
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_")
+	if len(name) > maxFieldNameLen {
+		name = name[:maxFieldNameLen]
+	}
+	return name
+}
+
+// Render a map data value as a field value. Strings are used as they are and
+// everything else is encoded as JSON, falling back to fmt if that fails.
+func fieldValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if b, err := json.Marshal(v); nil == err {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// Write a single field in the native protocol. Values without a newline are
+// written as NAME=value. Values with a newline are written as the name, a
+// newline, the value's length as a little-endian uint64 and the value.
+func writeField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.Contains(value, "\n") {
+		buf.WriteByte('\n')
+		binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buf.WriteByte('=')
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// Encode a formatted line as a journal message. With an entry, the PRIORITY
+// comes from its level and the channel, level, service name, request id, trace
+// ids and map data are added as fields. Map data keys are converted to field
+// names, and any that collide with one of the standard fields are prefixed
+// with FIELD_.
+func encodeEntry(e *alog.LogEntry, line []byte) []byte {
+	buf := &bytes.Buffer{}
+	fields := map[string]bool{}
+	add := func(name, value string) {
+		fields[name] = true
+		writeField(buf, name, value)
+	}
+	add("MESSAGE", strings.TrimSuffix(string(line), "\n"))
+	if nil == e {
+		add("PRIORITY", "6")
+		return buf.Bytes()
+	}
+	add("PRIORITY", fmt.Sprint(Priority(e.Level)))
+	add("ALOG_CHANNEL", string(e.Channel))
+	add("ALOG_LEVEL", alog.LevelToHumanString(e.Level))
+	if len(e.Servicename) > 0 {
+		add("SYSLOG_IDENTIFIER", e.Servicename)
+	}
+	if len(e.RequestID) > 0 {
+		add("ALOG_REQUEST_ID", e.RequestID)
+	}
+	if len(e.TraceID) > 0 {
+		add("ALOG_TRACE_ID", e.TraceID)
+	}
+	if len(e.SpanID) > 0 {
+		add("ALOG_SPAN_ID", e.SpanID)
+	}
+
+	// Map data in key order so the encoding is stable
+	keys := make([]string, 0, len(e.MapData))
+	for k := range e.MapData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := fieldName(k)
+		if len(name) == 0 {
+			continue
+		}
+		if fields[name] {
+			name = fieldName("FIELD_" + name)
+		}
+		add(name, fieldValue(e.MapData[k]))
+	}
+	return buf.Bytes()
+}
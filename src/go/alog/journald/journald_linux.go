@@ -0,0 +1,97 @@
+//go:build linux
+// +build linux
+
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package journald
+
+import (
+	// Standard
+	"net"
+	"sync"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+//-- Journal Writer ------------------------------------------------------------
+
+// Path of the journal's native protocol socket
+const socketPath = "/run/systemd/journal/socket"
+
+// Writer - alog.EntryWriter implementation that sends each line to the
+// systemd journal as a message with the entry's PRIORITY and fields. Lines
+// written without an entry (through Write) are sent at PRIORITY 6. All methods
+// are safe to call from multiple goroutines.
+//
+// NOTE: Each line is sent as a single datagram, so a line too large for the
+//  socket's buffer is rejected by the kernel and reported through the error of
+//  the write.
+////
+type Writer struct {
+	mutex sync.Mutex
+	conn  *net.UnixConn
+}
+
+// NewWriter - Create a Writer connected to the local journal
+func NewWriter() (*Writer, error) {
+	return newWriter(socketPath)
+}
+
+// Create a Writer connected to the socket at the given path
+func newWriter(path string) (*Writer, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if nil != err {
+		return nil, err
+	}
+	return &Writer{conn: conn}, nil
+}
+
+// Send a single encoded message, returning n as the bytes written
+func (w *Writer) send(msg []byte, n int) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, err := w.conn.Write(msg); nil != err {
+		return 0, err
+	}
+	return n, nil
+}
+
+// WriteEntry - Send a line with the fields of its entry
+func (w *Writer) WriteEntry(e *alog.LogEntry, p []byte) (int, error) {
+	return w.send(encodeEntry(e, p), len(p))
+}
+
+// Write - Send a line that is not associated with an entry
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.send(encodeEntry(nil, p), len(p))
+}
+
+// Close - Close the connection to the journal
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.conn.Close()
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package journald
+
+import (
+	// Standard
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+// Tests - Journal Writer //////////////////////////////////////////////////////
+
+////
+// Writer - Test sending entries to a journal socket
+// 1) Log an entry with map data through a Writer connected to a local socket
+//  -> One datagram with the encoded fields
+// 2) Close the writer
+//  -> Writing fails
+////
+func Test_Journald_Writer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	sock, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer sock.Close()
+	w, err := newWriter(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	alog.SetWriter(w)
+	alog.ConfigDefaultLevel(alog.INFO)
+	defer alog.ResetDefaults()
+
+	alog.LogWithMap("TEST", alog.ERROR, map[string]interface{}{"user": "bob"}, "Failed")
+	buf := make([]byte, 4096)
+	n, err := sock.Read(buf)
+	assert.Nil(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "MESSAGE="), msg)
+	assert.Contains(t, msg, "\nPRIORITY=3\nALOG_CHANNEL=TEST\nALOG_LEVEL=error\n")
+	assert.Contains(t, msg, "\nUSER=bob\n")
+
+	// Closed
+	assert.Nil(t, w.Close())
+	_, err = w.Write([]byte("late\n"))
+	assert.NotNil(t, err)
+}
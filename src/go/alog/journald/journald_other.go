@@ -0,0 +1,61 @@
+//go:build !linux
+// +build !linux
+
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package journald
+
+import (
+	// Standard
+	"errors"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+//-- Journal Writer ------------------------------------------------------------
+
+// Writer - The journal writer is only available on Linux
+type Writer struct{}
+
+// NewWriter - Always fails since the journal is only available on Linux
+func NewWriter() (*Writer, error) {
+	return nil, errors.New("journald is only supported on linux")
+}
+
+// WriteEntry - Always fails
+func (w *Writer) WriteEntry(e *alog.LogEntry, p []byte) (int, error) {
+	return 0, errors.New("journald is only supported on linux")
+}
+
+// Write - Always fails
+func (w *Writer) Write(p []byte) (int, error) {
+	return 0, errors.New("journald is only supported on linux")
+}
+
+// Close - No-op
+func (w *Writer) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+/*------------------------------------------------------------------------------
+ * MIT License
+ *
+ * Copyright (c) 2021 IBM
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *----------------------------------------------------------------------------*/
+
+package journald
+
+import (
+	// Standard
+	"encoding/binary"
+	"testing"
+
+	// Third Party
+	"github.com/stretchr/testify/assert"
+
+	// Local
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+// Tests - Field Encoding //////////////////////////////////////////////////////
+
+////
+// Priority - Test the mapping from alog levels to journal priorities
+////
+func Test_Journald_Priority(t *testing.T) {
+	assert.Equal(t, 2, Priority(alog.FATAL))
+	assert.Equal(t, 3, Priority(alog.ERROR))
+	assert.Equal(t, 4, Priority(alog.WARNING))
+	assert.Equal(t, 6, Priority(alog.INFO))
+	assert.Equal(t, 7, Priority(alog.TRACE))
+	assert.Equal(t, 7, Priority(alog.DEBUG))
+	assert.Equal(t, 7, Priority(alog.DEBUG4))
+}
+
+////
+// FieldName - Test converting map data keys to journal field names
+////
+func Test_Journald_FieldName(t *testing.T) {
+	assert.Equal(t, "USER_ID", fieldName("user_id"))
+	assert.Equal(t, "HTTP_STATUS", fieldName("http.status"))
+	assert.Equal(t, "HIDDEN", fieldName("_hidden"))
+	assert.Equal(t, "", fieldName("__"))
+	assert.Equal(t, "A_B", fieldName("a-b"))
+	long := ""
+	for i := 0; i < 70; i++ {
+		long += "a"
+	}
+	assert.Len(t, fieldName(long), 64)
+}
+
+////
+// EncodeEntry - Test encoding entries in the native protocol
+// 1) Encode an entry with a service name, request id and map data
+//  -> Standard fields first, then map data in key order
+//  -> Map data colliding with a standard field prefixed with FIELD_
+//  -> Non-string values encoded as JSON
+// 2) Encode a multi-line value
+//  -> Written with its length as a little-endian uint64
+// 3) Encode a line without an entry
+//  -> Message at PRIORITY 6
+////
+func Test_Journald_EncodeEntry(t *testing.T) {
+
+	// Fields
+	e := &alog.LogEntry{
+		Channel:     "HTTP",
+		Level:       alog.WARNING,
+		Servicename: "web",
+		RequestID:   "req-1",
+		MapData: map[string]interface{}{
+			"status":   503,
+			"priority": "high",
+			"tags":     []string{"a", "b"},
+		},
+	}
+	assert.Equal(t,
+		"MESSAGE=Slow response\n"+
+			"PRIORITY=4\n"+
+			"ALOG_CHANNEL=HTTP\n"+
+			"ALOG_LEVEL=warning\n"+
+			"SYSLOG_IDENTIFIER=web\n"+
+			"ALOG_REQUEST_ID=req-1\n"+
+			"FIELD_PRIORITY=high\n"+
+			"STATUS=503\n"+
+			"TAGS=[\"a\",\"b\"]\n",
+		string(encodeEntry(e, []byte("Slow response\n"))))
+
+	// Multi-line
+	e = &alog.LogEntry{Channel: "TEST", Level: alog.INFO}
+	length := make([]byte, 8)
+	binary.LittleEndian.PutUint64(length, 9)
+	assert.Equal(t,
+		"MESSAGE\n"+string(length)+"line\nline\n"+
+			"PRIORITY=6\n"+
+			"ALOG_CHANNEL=TEST\n"+
+			"ALOG_LEVEL=info\n",
+		string(encodeEntry(e, []byte("line\nline\n"))))
+
+	// No entry
+	assert.Equal(t, "MESSAGE=plain\nPRIORITY=6\n", string(encodeEntry(nil, []byte("plain\n"))))
+}
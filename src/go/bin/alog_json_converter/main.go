@@ -60,7 +60,6 @@ func main() {
 			reader = fReader
 		}
 	}
-	bufReader := bufio.NewReader(reader)
 
 	// Set up the output writer
 	writer := os.Stdout
@@ -74,20 +73,17 @@ func main() {
 	}
 	bufWriter := bufio.NewWriter(writer)
 
-	// Read each line from input and write to output
-	for {
-		if line, err := bufReader.ReadString('\n'); nil != err {
-			os.Exit(0)
-		} else {
-			if outlines, err := alog.JSONToPlainText(line); nil != err {
-				fmt.Printf("Error converting line [%s]\n", line)
-				fmt.Printf("%v\n", err)
-			} else {
-				for _, outline := range outlines {
-					bufWriter.WriteString(outline)
-					bufWriter.Flush()
-				}
-			}
-		}
+	// Convert each line from input and write to output, reporting lines that
+	// cannot be converted without stopping
+	err := alog.ConvertJSONStream(reader, bufWriter, func(line string, err error) {
+		fmt.Fprintf(os.Stderr, "Error converting line [%s]\n", line)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	})
+	if flushErr := bufWriter.Flush(); nil == err {
+		err = flushErr
+	}
+	if nil != err {
+		fmt.Fprintf(os.Stderr, "Error converting input: %v\n", err)
+		os.Exit(1)
 	}
 }
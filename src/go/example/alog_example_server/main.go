@@ -52,11 +52,10 @@ func main() {
 	// Bind dynamic log handler
 	http.HandleFunc("/logging", alog.DynamicHandler)
 
-	// Bind simple function that does some logging
-	http.HandleFunc("/demo", func(w http.ResponseWriter, r *http.Request) {
+	// Bind simple function that does some logging. The middleware logs each
+	// request in its own scope along with the response status.
+	http.Handle("/demo", alog.HTTPMiddleware("HNDLR", alog.TRACE)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ch := alog.UseChannel("HNDLR")
-		defer ch.LogScope(alog.TRACE, "Handling /demo").Close()
-
 		ch.Log(alog.WARNING, "WATCH OUT!")
 		ch.Log(alog.INFO, "Standard stuff...")
 		if ch.IsEnabled(alog.DEBUG) {
@@ -67,7 +66,7 @@ func main() {
 			}
 		}
 		w.WriteHeader(http.StatusOK)
-	})
+	})))
 
 	// Start serving requests
 	ch.Log(alog.FATAL, "%s", http.ListenAndServe(":"+*listenPort, nil))